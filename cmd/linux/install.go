@@ -0,0 +1,164 @@
+package main
+
+import (
+	_ "embed"
+	"errors"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+//go:embed conf/zaparoo.service
+var serviceFile string
+
+//go:embed conf/blacklist-zaparoo.conf
+var modprobeFile string
+
+//go:embed conf/60-zaparoo.rules
+var udevFile string
+
+const (
+	servicePath  = "/etc/systemd/system/zaparoo.service"
+	modprobePath = "/etc/modprobe.d/blacklist-zaparoo.conf"
+	udevPath     = "/etc/udev/rules.d/60-zaparoo.rules"
+)
+
+// The service runs as the user who ran the install with sudo, so it uses
+// their config and data folders.
+func serviceUser() (*user.User, *user.Group, error) {
+	name := os.Getenv("SUDO_USER")
+	if name == "" || name == "root" {
+		return nil, nil, errors.New("install must be run with sudo from the user account running the service")
+	}
+
+	u, err := user.Lookup(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	g, err := user.LookupGroupId(u.Gid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return u, g, nil
+}
+
+// serviceUnit fills in the systemd service file to run the binary at exe as
+// the given user.
+func serviceUnit(u *user.User, g *user.Group, exe string) string {
+	return strings.NewReplacer(
+		"%%USER%%", u.Username,
+		"%%GROUP%%", g.Name,
+		"%%EXEC%%", exe,
+		"%%WORKING%%", filepath.Dir(exe),
+	).Replace(serviceFile)
+}
+
+// writeIfChanged writes data to the file at path if it doesn't exist or its
+// contents are different, so installing again after an update refreshes
+// any files which changed. Returns true if the file was written.
+func writeIfChanged(path string, data string) (bool, error) {
+	current, err := os.ReadFile(path)
+	if err == nil && string(current) == data {
+		return false, nil
+	} else if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+
+	err = os.WriteFile(path, []byte(data), 0644)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func install() error {
+	// install and prep systemd service
+	u, g, err := serviceUser()
+	if err != nil {
+		return err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	changed, err := writeIfChanged(servicePath, serviceUnit(u, g, exe))
+	if err != nil {
+		return err
+	} else if changed {
+		err = exec.Command("systemctl", "daemon-reload").Run()
+		if err != nil {
+			return err
+		}
+		err = exec.Command("systemctl", "enable", "zaparoo").Run()
+		if err != nil {
+			return err
+		}
+	}
+
+	// install udev rules and refresh
+	changed, err = writeIfChanged(udevPath, udevFile)
+	if err != nil {
+		return err
+	} else if changed {
+		err = exec.Command("udevadm", "control", "--reload-rules").Run()
+		if err != nil {
+			return err
+		}
+		err = exec.Command("udevadm", "trigger").Run()
+		if err != nil {
+			return err
+		}
+	}
+
+	// install modprobe blacklist
+	_, err = writeIfChanged(modprobePath, modprobeFile)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func uninstall() error {
+	if _, err := os.Stat(servicePath); !os.IsNotExist(err) {
+		err = exec.Command("systemctl", "disable", "zaparoo").Run()
+		if err != nil {
+			return err
+		}
+		err = exec.Command("systemctl", "stop", "zaparoo").Run()
+		if err != nil {
+			return err
+		}
+		err = exec.Command("systemctl", "daemon-reload").Run()
+		if err != nil {
+			return err
+		}
+		err = os.Remove(servicePath)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := os.Stat(modprobePath); !os.IsNotExist(err) {
+		err = os.Remove(modprobePath)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := os.Stat(udevPath); !os.IsNotExist(err) {
+		err = os.Remove(udevPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
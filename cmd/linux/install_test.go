@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServiceUserNeedsSudo(t *testing.T) {
+	for _, name := range []string{"", "root"} {
+		t.Setenv("SUDO_USER", name)
+		if _, _, err := serviceUser(); err == nil {
+			t.Errorf("expected error with SUDO_USER=%q", name)
+		}
+	}
+}
+
+func TestServiceUser(t *testing.T) {
+	cur, err := user.Current()
+	if err != nil {
+		t.Skip(err)
+	} else if cur.Username == "root" {
+		t.Skip("needs a non-root user")
+	}
+	t.Setenv("SUDO_USER", cur.Username)
+
+	u, g, err := serviceUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.Uid != cur.Uid {
+		t.Errorf("wrong user: %s", u.Username)
+	}
+	if g.Gid != cur.Gid {
+		t.Errorf("wrong group: %s", g.Name)
+	}
+}
+
+func TestServiceUnit(t *testing.T) {
+	u := &user.User{Username: "zaparoo"}
+	g := &user.Group{Name: "players"}
+
+	unit := serviceUnit(u, g, "/opt/zaparoo/zaparoo")
+
+	for _, want := range []string{
+		"User=zaparoo\n",
+		"Group=players\n",
+		"WorkingDirectory=/opt/zaparoo\n",
+		"ExecStart=/opt/zaparoo/zaparoo\n",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("service file missing %q:\n%s", want, unit)
+		}
+	}
+
+	if strings.Contains(unit, "%%") {
+		t.Errorf("service file has unfilled values:\n%s", unit)
+	}
+}
+
+func TestWriteIfChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "zaparoo.service")
+
+	for _, c := range []struct {
+		data    string
+		changed bool
+	}{
+		{"first", true},
+		{"first", false},
+		{"second", true},
+	} {
+		changed, err := writeIfChanged(path, c.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changed != c.changed {
+			t.Errorf("writing %q: expected changed %v, got %v", c.data, c.changed, changed)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != c.data {
+			t.Errorf("expected file to contain %q, got %q", c.data, data)
+		}
+	}
+}
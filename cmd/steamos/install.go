@@ -9,8 +9,6 @@ import (
 	"strings"
 )
 
-// TODO: allow updating if files have changed
-
 //go:embed conf/zaparoo.service
 var serviceFile string
 
@@ -26,20 +24,38 @@ const (
 	udevPath     = "/etc/udev/rules.d/60-zaparoo.rules"
 )
 
+// writeIfChanged writes data to the file at path if it doesn't exist or its
+// contents are different, so installing again after an update refreshes
+// any files which changed. Returns true if the file was written.
+func writeIfChanged(path string, data string) (bool, error) {
+	current, err := os.ReadFile(path)
+	if err == nil && string(current) == data {
+		return false, nil
+	} else if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+
+	err = os.WriteFile(path, []byte(data), 0644)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 func install() error {
 	// install and prep systemd service
-	if _, err := os.Stat(servicePath); os.IsNotExist(err) {
-		exe, err := os.Executable()
-		if err != nil {
-			exe = "/home/deck/zaparoo/" + config.AppName
-		}
-		serviceFile = strings.ReplaceAll(serviceFile, "%%EXEC%%", exe)
-		serviceFile = strings.ReplaceAll(serviceFile, "%%WORKING%%", filepath.Dir(exe))
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "/home/deck/zaparoo/" + config.AppName
+	}
+	serviceFile = strings.ReplaceAll(serviceFile, "%%EXEC%%", exe)
+	serviceFile = strings.ReplaceAll(serviceFile, "%%WORKING%%", filepath.Dir(exe))
 
-		err = os.WriteFile(servicePath, []byte(serviceFile), 0644)
-		if err != nil {
-			return err
-		}
+	changed, err := writeIfChanged(servicePath, serviceFile)
+	if err != nil {
+		return err
+	} else if changed {
 		err = exec.Command("systemctl", "daemon-reload").Run()
 		if err != nil {
 			return err
@@ -51,11 +67,10 @@ func install() error {
 	}
 
 	// install udev rules and refresh
-	if _, err := os.Stat(udevPath); os.IsNotExist(err) {
-		err = os.WriteFile(udevPath, []byte(udevFile), 0644)
-		if err != nil {
-			return err
-		}
+	changed, err = writeIfChanged(udevPath, udevFile)
+	if err != nil {
+		return err
+	} else if changed {
 		err = exec.Command("udevadm", "control", "--reload-rules").Run()
 		if err != nil {
 			return err
@@ -67,11 +82,9 @@ func install() error {
 	}
 
 	// install modprobe blacklist
-	if _, err := os.Stat(modprobePath); os.IsNotExist(err) {
-		err = os.WriteFile(modprobePath, []byte(modprobeFile), 0644)
-		if err != nil {
-			return err
-		}
+	_, err = writeIfChanged(modprobePath, modprobeFile)
+	if err != nil {
+		return err
 	}
 
 	return nil
@@ -25,6 +25,7 @@ require (
 require (
 	github.com/creack/goselect v0.1.2 // indirect
 	github.com/gdamore/encoding v1.0.1 // indirect
+	github.com/jfreymuth/vorbis v1.0.2 // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
@@ -38,6 +39,7 @@ require (
 	github.com/andygrunwald/vdf v1.1.0
 	github.com/clausecker/nfc/v2 v2.1.4
 	github.com/ebfe/scard v0.0.0-20230420082256-7db3f9b7c8a7
+	github.com/eclipse/paho.mqtt.golang v1.4.3
 	github.com/gdamore/tcell/v2 v2.8.1
 	github.com/go-chi/chi/v5 v5.0.12
 	github.com/go-chi/cors v1.2.1
@@ -46,6 +48,7 @@ require (
 	github.com/google/uuid v1.6.0
 	github.com/gorilla/websocket v1.5.3
 	github.com/hsanjuan/go-ndef v0.0.1
+	github.com/jfreymuth/oggvorbis v1.0.5
 	github.com/olahol/melody v1.2.1
 	github.com/pelletier/go-toml/v2 v2.2.3
 	github.com/rivo/tview v0.0.0-20241227133733-17b7edb88c57
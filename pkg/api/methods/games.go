@@ -242,14 +242,20 @@ func HandleMedia(env requests.RequestEnv) (any, error) {
 	}
 
 	if env.Platform.ActiveGamePath() != "" {
-		system, err := assets.GetSystemMetadata(env.Platform.ActiveSystem())
+		systemId := env.Platform.ActiveSystem()
+		systemName := systemId
+
+		// media started by generic launchers may not have a known system
+		system, err := assets.GetSystemMetadata(systemId)
 		if err != nil {
-			return nil, errors.New("error getting system metadata: " + err.Error())
+			log.Warn().Err(err).Msgf("error getting system metadata: %s", systemId)
+		} else {
+			systemName = system.Name
 		}
 
 		resp.Active = append(resp.Active, models.PlayingResponse{
-			SystemId:   system.Id,
-			SystemName: system.Name,
+			SystemId:   systemId,
+			SystemName: systemName,
 			MediaName:  env.Platform.ActiveGameName(),
 			MediaPath:  env.Platform.NormalizePath(env.Config, env.Platform.ActiveGamePath()),
 		})
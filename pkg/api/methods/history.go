@@ -1,9 +1,17 @@
 package methods
 
 import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
 	"github.com/ZaparooProject/zaparoo-core/pkg/api/models/requests"
+	"github.com/ZaparooProject/zaparoo-core/pkg/database"
 	"github.com/rs/zerolog/log"
 )
 
@@ -39,10 +47,98 @@ func HandleTokens(env requests.RequestEnv) (any, error) {
 	return resp, nil
 }
 
+const defaultHistoryLimit = 25
+
+// historyQuery builds a query from request params, limited to entries of
+// the active profile.
+func historyQuery(
+	params models.HistoryParams,
+	limit int,
+	profile string,
+) (database.HistoryQuery, error) {
+	q := database.HistoryQuery{
+		Limit:   limit,
+		Success: params.Success,
+		Profile: &profile,
+	}
+
+	if params.Limit != nil {
+		if *params.Limit < 0 {
+			return q, ErrInvalidParams
+		}
+		q.Limit = *params.Limit
+	}
+
+	if params.Cursor != nil {
+		q.Cursor = *params.Cursor
+	}
+
+	if params.Since != nil {
+		q.Since = *params.Since
+	}
+
+	if params.Until != nil {
+		q.Until = *params.Until
+	}
+
+	if params.UID != nil {
+		q.UID = *params.UID
+	}
+
+	if params.Text != nil {
+		q.Text = *params.Text
+	}
+
+	if params.Source != nil {
+		q.Source = *params.Source
+	}
+
+	return q, nil
+}
+
+func historyResponseEntry(e database.HistoryEntry) models.HistoryReponseEntry {
+	resp := models.HistoryReponseEntry{
+		Id:      e.Id,
+		Time:    e.Time,
+		Type:    e.Type,
+		UID:     e.UID,
+		Text:    e.Text,
+		Data:    e.Data,
+		Source:  e.Source,
+		Success: e.Success,
+	}
+
+	if e.Error != nil {
+		resp.Error = &models.LaunchError{
+			Code:        e.Error.Code,
+			Message:     e.Error.Message,
+			Command:     e.Error.Command,
+			Stage:       e.Error.Stage,
+			Candidates:  append(make([]string, 0), e.Error.Candidates...),
+			Suggestions: append(make([]string, 0), e.Error.Suggestions...),
+		}
+	}
+
+	return resp
+}
+
 func HandleHistory(env requests.RequestEnv) (any, error) {
 	log.Info().Msg("received history request")
 
-	entries, err := env.Database.GetHistory()
+	var params models.HistoryParams
+	if len(env.Params) > 0 {
+		err := json.Unmarshal(env.Params, &params)
+		if err != nil {
+			return nil, ErrInvalidParams
+		}
+	}
+
+	q, err := historyQuery(params, defaultHistoryLimit, env.Config.Profile())
+	if err != nil {
+		return nil, err
+	}
+
+	entries, next, err := env.Database.GetHistory(q)
 	if err != nil {
 		log.Error().Err(err).Msgf("error getting history")
 		return nil, errors.New("error getting history")
@@ -53,15 +149,90 @@ func HandleHistory(env requests.RequestEnv) (any, error) {
 	}
 
 	for i, e := range entries {
-		resp.Entries[i] = models.HistoryReponseEntry{
-			Time:    e.Time,
-			Type:    e.Type,
-			UID:     e.UID,
-			Text:    e.Text,
-			Data:    e.Data,
-			Success: e.Success,
-		}
+		resp.Entries[i] = historyResponseEntry(e)
+	}
+
+	if next != "" {
+		resp.Next = &next
 	}
 
 	return resp, nil
 }
+
+// HandleHistoryExport returns all history entries matching the filters as a
+// single CSV or JSON document.
+func HandleHistoryExport(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received history export request")
+
+	var params models.HistoryExportParams
+	if len(env.Params) > 0 {
+		err := json.Unmarshal(env.Params, &params)
+		if err != nil {
+			return nil, ErrInvalidParams
+		}
+	}
+
+	format := strings.ToLower(params.Format)
+	if format == "" {
+		format = "csv"
+	} else if format != "csv" && format != "json" {
+		return nil, ErrInvalidParams
+	}
+
+	q, err := historyQuery(params.HistoryParams, 0, env.Config.Profile())
+	if err != nil {
+		return nil, err
+	}
+
+	entries, _, err := env.Database.GetHistory(q)
+	if err != nil {
+		log.Error().Err(err).Msgf("error getting history")
+		return nil, errors.New("error getting history")
+	}
+
+	rs := make([]models.HistoryReponseEntry, len(entries))
+	for i, e := range entries {
+		rs[i] = historyResponseEntry(e)
+	}
+
+	var buf bytes.Buffer
+	if format == "json" {
+		err = json.NewEncoder(&buf).Encode(rs)
+	} else {
+		w := csv.NewWriter(&buf)
+		err = w.Write([]string{"id", "time", "type", "uid", "text", "data", "source", "success", "error"})
+		for _, e := range rs {
+			if err != nil {
+				break
+			}
+			msg := ""
+			if e.Error != nil {
+				msg = e.Error.Message
+			}
+			err = w.Write([]string{
+				e.Id,
+				e.Time.Format(time.RFC3339),
+				e.Type,
+				e.UID,
+				e.Text,
+				e.Data,
+				e.Source,
+				strconv.FormatBool(e.Success),
+				msg,
+			})
+		}
+		w.Flush()
+		if err == nil {
+			err = w.Error()
+		}
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("error exporting history")
+		return nil, errors.New("error exporting history")
+	}
+
+	return models.HistoryExportResponse{
+		Format: format,
+		Data:   buf.String(),
+	}, nil
+}
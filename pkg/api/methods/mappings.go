@@ -3,6 +3,7 @@ package methods
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
 	"github.com/ZaparooProject/zaparoo-core/pkg/api/models/requests"
 	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
@@ -23,7 +24,7 @@ func HandleMappings(env requests.RequestEnv) (any, error) {
 		Mappings: make([]models.MappingResponse, 0),
 	}
 
-	mappings, err := env.Database.GetAllMappings()
+	mappings, err := env.Database.GetProfileMappings(env.Config.Profile())
 	if err != nil {
 		log.Error().Err(err).Msg("error getting mappings")
 		return nil, errors.New("error getting mappings")
@@ -102,6 +103,7 @@ func HandleAddMapping(env requests.RequestEnv) (any, error) {
 		Match:    params.Match,
 		Pattern:  params.Pattern,
 		Override: params.Override,
+		Profile:  env.Config.Profile(),
 	}
 
 	err = env.Database.AddMapping(m)
@@ -112,6 +114,22 @@ func HandleAddMapping(env requests.RequestEnv) (any, error) {
 	return nil, nil
 }
 
+// getProfileMapping returns a mapping of the active profile. Mappings of
+// other profiles can't be seen, so they're reported as not found.
+func getProfileMapping(env requests.RequestEnv, id int) (database.Mapping, error) {
+	m, err := env.Database.GetMapping(strconv.Itoa(id))
+	if err != nil {
+		return m, err
+	}
+
+	if m.Profile != env.Config.Profile() {
+		log.Warn().Msgf("mapping %d belongs to another profile: %s", id, m.Profile)
+		return database.Mapping{}, fmt.Errorf("mapping not found: %d", id)
+	}
+
+	return m, nil
+}
+
 func HandleDeleteMapping(env requests.RequestEnv) (any, error) {
 	log.Info().Msg("received delete mapping request")
 
@@ -125,6 +143,11 @@ func HandleDeleteMapping(env requests.RequestEnv) (any, error) {
 		return nil, ErrInvalidParams
 	}
 
+	_, err = getProfileMapping(env, params.Id)
+	if err != nil {
+		return nil, err
+	}
+
 	err = env.Database.DeleteMapping(strconv.Itoa(params.Id))
 	if err != nil {
 		return nil, err
@@ -179,7 +202,7 @@ func HandleUpdateMapping(env requests.RequestEnv) (any, error) {
 		return nil, ErrInvalidParams
 	}
 
-	oldMapping, err := env.Database.GetMapping(strconv.Itoa(params.Id))
+	oldMapping, err := getProfileMapping(env, params.Id)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,87 @@
+package methods
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models/requests"
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/database"
+)
+
+// addProfileMapping adds a mapping to the given profile and returns its ID.
+func addProfileMapping(t *testing.T, env requests.RequestEnv, profile string) int {
+	err := env.Database.AddMapping(database.Mapping{
+		Label:    profile,
+		Enabled:  true,
+		Type:     database.MappingTypeText,
+		Match:    database.MatchTypeExact,
+		Pattern:  "test",
+		Override: "**launch.random:snes",
+		Profile:  profile,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ms, err := env.Database.GetProfileMappings(profile)
+	if err != nil || len(ms) != 1 {
+		t.Fatalf("mapping not added: %v", err)
+	}
+
+	id, err := strconv.Atoi(ms[0].Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestMappingsOtherProfile(t *testing.T) {
+	env := testEnv(t, true, nil)
+	baseId := addProfileMapping(t, env, "")
+	kidsId := addProfileMapping(t, env, "kids")
+
+	profilePath := config.ProfilePath(env.Config.Path(), "kids")
+	err := os.MkdirAll(filepath.Dir(profilePath), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(profilePath, []byte("[audio]\nscan_feedback = false\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = env.Config.SetProfile("kids")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	label := "changed"
+	env.Params, _ = json.Marshal(models.UpdateMappingParams{Id: baseId, Label: &label})
+	if _, err := HandleUpdateMapping(env); err == nil {
+		t.Error("updated mapping of another profile")
+	}
+
+	env.Params, _ = json.Marshal(models.DeleteMappingParams{Id: baseId})
+	if _, err := HandleDeleteMapping(env); err == nil {
+		t.Error("deleted mapping of another profile")
+	}
+
+	m, err := env.Database.GetMapping(strconv.Itoa(baseId))
+	if err != nil || m.Label != "" {
+		t.Errorf("mapping of another profile changed: %+v, %v", m, err)
+	}
+
+	env.Params, _ = json.Marshal(models.UpdateMappingParams{Id: kidsId, Label: &label})
+	if _, err := HandleUpdateMapping(env); err != nil {
+		t.Errorf("error updating mapping of active profile: %v", err)
+	}
+
+	env.Params, _ = json.Marshal(models.DeleteMappingParams{Id: kidsId})
+	if _, err := HandleDeleteMapping(env); err != nil {
+		t.Errorf("error deleting mapping of active profile: %v", err)
+	}
+}
@@ -0,0 +1,278 @@
+package methods
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models/requests"
+	"github.com/ZaparooProject/zaparoo-core/pkg/database"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/playlists"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/playlists/saved"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/state"
+	"github.com/ZaparooProject/zaparoo-core/pkg/utils"
+	"github.com/rs/zerolog/log"
+)
+
+func playlistItemsFromParams(ps []models.PlaylistItemParams) []database.PlaylistItem {
+	items := make([]database.PlaylistItem, 0, len(ps))
+	for _, p := range ps {
+		items = append(items, database.PlaylistItem{
+			Name:      p.Name,
+			ZapScript: p.ZapScript,
+		})
+	}
+	return items
+}
+
+func applyPlaylistOptions(o *database.PlaylistOptions, params models.PlaylistOptionsParams) {
+	if params.Shuffle != nil {
+		o.Shuffle = *params.Shuffle
+	}
+
+	if params.Repeat != nil {
+		o.Repeat = *params.Repeat
+	}
+
+	if params.Advance != nil {
+		o.Advance = *params.Advance
+	}
+
+	if params.AdvanceOnStop != nil {
+		o.AdvanceOnStop = *params.AdvanceOnStop
+	}
+}
+
+func HandlePlaylists(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received playlists request")
+
+	ps, err := env.Database.GetAllPlaylists()
+	if err != nil {
+		log.Error().Err(err).Msg("error getting playlists")
+		return nil, errors.New("error getting playlists")
+	}
+
+	resp := models.AllPlaylistsResponse{
+		Playlists: make([]models.PlaylistResponse, 0, len(ps)),
+	}
+
+	for _, p := range ps {
+		pr := models.PlaylistResponse{
+			Id:            p.Id,
+			Added:         time.Unix(p.Added, 0).Format(time.RFC3339),
+			Name:          p.Name,
+			Items:         make([]models.PlaylistItemResponse, 0, len(p.Items)),
+			Shuffle:       p.Shuffle,
+			Repeat:        p.Repeat,
+			Advance:       p.Advance,
+			AdvanceOnStop: p.AdvanceOnStop,
+		}
+
+		if pr.Repeat == "" {
+			pr.Repeat = playlists.RepeatAll
+		}
+
+		for _, item := range p.Items {
+			pr.Items = append(pr.Items, models.PlaylistItemResponse{
+				Name:      item.Name,
+				ZapScript: item.ZapScript,
+			})
+		}
+
+		resp.Playlists = append(resp.Playlists, pr)
+	}
+
+	return resp, nil
+}
+
+// playlistFileAllowed returns true if a playlist file can be loaded by the
+// client. Remote clients can only load files from the data dir or a root
+// dir, so they can't read arbitrary files from the host.
+func playlistFileAllowed(env requests.RequestEnv, path string) bool {
+	return env.IsLocal || utils.PathInDataOrRootDir(env.Config, env.Platform, path)
+}
+
+func HandleAddPlaylist(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received add playlist request")
+
+	if len(env.Params) == 0 {
+		return nil, ErrMissingParams
+	}
+
+	var params models.AddPlaylistParams
+	err := json.Unmarshal(env.Params, &params)
+	if err != nil {
+		return nil, ErrInvalidParams
+	}
+
+	p := database.Playlist{
+		Name:  params.Name,
+		Items: make([]database.PlaylistItem, 0),
+	}
+
+	if params.Path != nil && params.Items != nil {
+		return nil, ErrInvalidParams
+	} else if params.Path != nil {
+		if !playlistFileAllowed(env, *params.Path) {
+			log.Warn().Msgf("remote client not allowed to load playlist file: %s", *params.Path)
+			return nil, ErrNotAllowed
+		}
+
+		pls, err := playlists.Load(*params.Path)
+		if err != nil {
+			log.Error().Err(err).Msgf("error loading playlist file: %s", *params.Path)
+			return nil, err
+		}
+		p.Items = saved.Items(pls.Items)
+		p.PlaylistOptions = saved.Options(pls.Options)
+	} else if params.Items != nil {
+		p.Items = playlistItemsFromParams(*params.Items)
+	}
+
+	applyPlaylistOptions(&p.PlaylistOptions, params.PlaylistOptionsParams)
+
+	return nil, env.Database.AddPlaylist(p)
+}
+
+func HandleUpdatePlaylist(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received update playlist request")
+
+	if len(env.Params) == 0 {
+		return nil, ErrMissingParams
+	}
+
+	var params models.UpdatePlaylistParams
+	err := json.Unmarshal(env.Params, &params)
+	if err != nil {
+		return nil, ErrInvalidParams
+	}
+
+	p, err := env.Database.GetPlaylist(strconv.Itoa(params.Id))
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Name != nil {
+		p.Name = *params.Name
+	}
+
+	if params.Items != nil {
+		p.Items = playlistItemsFromParams(*params.Items)
+	}
+
+	applyPlaylistOptions(&p.PlaylistOptions, params.PlaylistOptionsParams)
+
+	return nil, env.Database.UpdatePlaylist(strconv.Itoa(params.Id), p)
+}
+
+func HandleDeletePlaylist(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received delete playlist request")
+
+	if len(env.Params) == 0 {
+		return nil, ErrMissingParams
+	}
+
+	var params models.DeletePlaylistParams
+	err := json.Unmarshal(env.Params, &params)
+	if err != nil {
+		return nil, ErrInvalidParams
+	}
+
+	return nil, env.Database.DeletePlaylist(strconv.Itoa(params.Id))
+}
+
+func HandlePlayPlaylist(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received play playlist request")
+
+	if len(env.Params) == 0 {
+		return nil, ErrMissingParams
+	}
+
+	var params models.PlayPlaylistParams
+	err := json.Unmarshal(env.Params, &params)
+	if err != nil {
+		return nil, ErrInvalidParams
+	}
+
+	var p database.Playlist
+	if params.Id != nil {
+		p, err = env.Database.GetPlaylist(strconv.Itoa(*params.Id))
+	} else if params.Name != nil {
+		p, err = env.Database.GetPlaylistByName(*params.Name)
+	} else {
+		return nil, ErrMissingParams
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(p.Items) == 0 {
+		return nil, errors.New("playlist is empty: " + p.Name)
+	}
+
+	applyPlaylistOptions(&p.PlaylistOptions, params.PlaylistOptionsParams)
+	switch p.Repeat {
+	case "", playlists.RepeatAll, playlists.RepeatOne, playlists.RepeatNone:
+	default:
+		return nil, ErrInvalidParams
+	}
+
+	pls := saved.ToPlaylist(p)
+	if params.Index != nil {
+		pls, err = playlists.Goto(*pls, *params.Index)
+		if err != nil {
+			return nil, ErrInvalidParams
+		}
+	}
+
+	log.Info().Msgf("playing playlist: %s", p.Name)
+	env.PlaylistQueue <- pls
+
+	return nil, nil
+}
+
+func HandleActivePlaylist(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received active playlist request")
+	return state.ActivePlaylistResponse(env.State.GetActivePlaylist()), nil
+}
+
+func HandleNextPlaylist(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received next playlist request")
+
+	pls := env.State.GetActivePlaylist()
+	if pls == nil {
+		return nil, errors.New("no playlist active")
+	}
+
+	// nil at the end of a playlist without repeat, which stops it
+	env.PlaylistQueue <- playlists.Next(*pls)
+
+	return nil, nil
+}
+
+func HandlePreviousPlaylist(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received previous playlist request")
+
+	pls := env.State.GetActivePlaylist()
+	if pls == nil {
+		return nil, errors.New("no playlist active")
+	}
+
+	env.PlaylistQueue <- playlists.Previous(*pls)
+
+	return nil, nil
+}
+
+func HandleStopPlaylist(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received stop playlist request")
+
+	if env.State.GetActivePlaylist() == nil {
+		return nil, errors.New("no playlist active")
+	}
+
+	env.PlaylistQueue <- nil
+
+	return nil, nil
+}
@@ -0,0 +1,269 @@
+package methods
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models/requests"
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/database"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+	"github.com/ZaparooProject/zaparoo-core/pkg/readers"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/playlists"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/state"
+)
+
+// testPlatform only implements the dirs, launchers and readers. Any other
+// method panics.
+type testPlatform struct {
+	platforms.Platform
+	dataDir  string
+	rootDirs []string
+}
+
+func (p testPlatform) DataDir() string {
+	return p.dataDir
+}
+
+func (p testPlatform) RootDirs(_ *config.Instance) []string {
+	return p.rootDirs
+}
+
+func (testPlatform) Launchers(_ *config.Instance) []platforms.Launcher {
+	return nil
+}
+
+func (testPlatform) SupportedReaders(_ *config.Instance) []readers.Reader {
+	return nil
+}
+
+func testEnv(t *testing.T, local bool, params any) requests.RequestEnv {
+	cfg, err := config.NewConfig(t.TempDir(), config.BaseDefaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := database.OpenFile(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return requests.RequestEnv{
+		Platform: testPlatform{
+			dataDir:  t.TempDir(),
+			rootDirs: []string{t.TempDir()},
+		},
+		Config:   cfg,
+		Database: db,
+		Params:   data,
+		IsLocal:  local,
+	}
+}
+
+func writePlaylistFile(t *testing.T, dir string) string {
+	path := filepath.Join(dir, "test.m3u")
+	err := os.WriteFile(path, []byte("/games/one.bin\n/games/two.bin\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAddPlaylistFile(t *testing.T) {
+	outside := writePlaylistFile(t, t.TempDir())
+
+	tests := []struct {
+		name    string
+		local   bool
+		path    func(env requests.RequestEnv) string
+		allowed bool
+	}{
+		{
+			name:    "local outside dirs",
+			local:   true,
+			path:    func(requests.RequestEnv) string { return outside },
+			allowed: true,
+		},
+		{
+			name:    "remote outside dirs",
+			path:    func(requests.RequestEnv) string { return outside },
+			allowed: false,
+		},
+		{
+			name: "remote traversal",
+			path: func(env requests.RequestEnv) string {
+				rel, _ := filepath.Rel(env.Platform.DataDir(), outside)
+				return filepath.Join(env.Platform.DataDir(), rel)
+			},
+			allowed: false,
+		},
+		{
+			name: "remote symlink out of data dir",
+			path: func(env requests.RequestEnv) string {
+				link := filepath.Join(env.Platform.DataDir(), "link.m3u")
+				if err := os.Symlink(outside, link); err != nil {
+					t.Skip("symlinks not supported")
+				}
+				return link
+			},
+			allowed: false,
+		},
+		{
+			name: "remote data dir",
+			path: func(env requests.RequestEnv) string {
+				return writePlaylistFile(t, env.Platform.DataDir())
+			},
+			allowed: true,
+		},
+		{
+			name: "remote root dir",
+			path: func(env requests.RequestEnv) string {
+				return writePlaylistFile(t, env.Platform.RootDirs(env.Config)[0])
+			},
+			allowed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := testEnv(t, tt.local, nil)
+			path := tt.path(env)
+			env.Params, _ = json.Marshal(models.AddPlaylistParams{
+				Name: "test",
+				Path: &path,
+			})
+
+			_, err := HandleAddPlaylist(env)
+			if tt.allowed && err != nil {
+				t.Fatalf("expected playlist to be added, got: %v", err)
+			} else if !tt.allowed && !errors.Is(err, ErrNotAllowed) {
+				t.Fatalf("expected not allowed error, got: %v", err)
+			}
+
+			ps, err := env.Database.GetAllPlaylists()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.allowed && (len(ps) != 1 || len(ps[0].Items) != 2) {
+				t.Errorf("playlist not loaded from file: %+v", ps)
+			} else if !tt.allowed && len(ps) != 0 {
+				t.Errorf("playlist added from disallowed file: %+v", ps)
+			}
+		})
+	}
+}
+
+// playlistEnv returns an env with the given active playlist and a queue
+// for the playlists requested by the handlers.
+func playlistEnv(t *testing.T, active *playlists.Playlist) (requests.RequestEnv, chan *playlists.Playlist) {
+	env := testEnv(t, true, nil)
+
+	st, ns := state.NewState(nil)
+	t.Cleanup(st.StopService)
+	go func() {
+		for range ns {
+		}
+	}()
+	st.SetActivePlaylist(active)
+	env.State = st
+
+	q := make(chan *playlists.Playlist, 1)
+	env.PlaylistQueue = q
+
+	return env, q
+}
+
+func testActivePlaylist() *playlists.Playlist {
+	return playlists.NewPlaylist("Favourites", []playlists.PlaylistItem{
+		{Name: "Sonic", ZapScript: "genesis/sonic.md"},
+		{Name: "Mario", ZapScript: "snes/mario.sfc"},
+		{Name: "Zelda", ZapScript: "snes/zelda.sfc"},
+	})
+}
+
+func TestActivePlaylist(t *testing.T) {
+	env, _ := playlistEnv(t, nil)
+
+	resp, err := HandleActivePlaylist(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inactive := resp.(models.ActivePlaylistResponse)
+	if inactive.Active || inactive.Items == nil || inactive.Order == nil {
+		t.Errorf("inactive playlist reported wrong: %+v", inactive)
+	}
+
+	env, _ = playlistEnv(t, playlists.Next(*testActivePlaylist()))
+
+	resp, err = HandleActivePlaylist(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	active := resp.(models.ActivePlaylistResponse)
+	if !active.Active || active.Name != "Favourites" || len(active.Items) != 3 {
+		t.Errorf("active playlist reported wrong: %+v", active)
+	}
+	if active.Index != 1 || active.Items[active.Index].Name != "Mario" {
+		t.Errorf("wrong current item: %+v", active)
+	}
+}
+
+func TestPlaylistControls(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(requests.RequestEnv) (any, error)
+		// index of the queued playlist's current item, -1 for a stop
+		want int
+	}{
+		{"next", HandleNextPlaylist, 2},
+		{"previous", HandlePreviousPlaylist, 0},
+		{"stop", HandleStopPlaylist, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, q := playlistEnv(t, playlists.Next(*testActivePlaylist()))
+
+			_, err := tt.handler(env)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			select {
+			case pls := <-q:
+				if tt.want == -1 && pls != nil {
+					t.Errorf("expected stop, got: %+v", pls)
+				} else if tt.want != -1 && (pls == nil || pls.ItemIndex() != tt.want) {
+					t.Errorf("expected item %d, got: %+v", tt.want, pls)
+				}
+			default:
+				t.Fatal("nothing queued")
+			}
+		})
+
+		t.Run(tt.name+" inactive", func(t *testing.T) {
+			env, q := playlistEnv(t, nil)
+
+			_, err := tt.handler(env)
+			if err == nil {
+				t.Error("expected error with no active playlist")
+			}
+			if len(q) != 0 {
+				t.Error("playlist queued with no active playlist")
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package methods
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models/requests"
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/rs/zerolog/log"
+)
+
+func HandleProfiles(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received profiles request")
+
+	ps, err := env.Config.Profiles()
+	if err != nil {
+		log.Error().Err(err).Msg("error listing profiles")
+		return nil, errors.New("error listing profiles")
+	}
+
+	return models.ProfilesResponse{
+		Profiles: ps,
+		Active:   env.Config.Profile(),
+	}, nil
+}
+
+// HandleSwitchProfile makes a profile active, or goes back to the config
+// file's values if the profile is empty. Clients are notified of the switch
+// by the service. Remote clients can't leave an active profile or enter one
+// which allows more to be run.
+func HandleSwitchProfile(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received switch profile request")
+
+	if len(env.Params) == 0 {
+		return nil, ErrMissingParams
+	}
+
+	var params models.SwitchProfileParams
+	err := json.Unmarshal(env.Params, &params)
+	if err != nil {
+		return nil, ErrInvalidParams
+	}
+
+	if !env.IsLocal {
+		err = env.Config.CheckProfileSwitch(params.Profile)
+		if errors.Is(err, config.ErrProfileRestricted) {
+			log.Warn().Err(err).Msg("remote client not allowed to switch profile")
+			return nil, fmt.Errorf("%w: %s", ErrNotAllowed, err)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	err = env.Config.SetProfile(params.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, env.Config.Save()
+}
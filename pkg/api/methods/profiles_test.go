@@ -0,0 +1,63 @@
+package methods
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+)
+
+func TestSwitchProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		local   bool
+		from    string
+		to      string
+		allowed bool
+	}{
+		{"remote enter", false, "", "kids", true},
+		{"remote leave", false, "kids", "", false},
+		{"local leave", true, "kids", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := testEnv(t, tt.local, models.SwitchProfileParams{Profile: tt.to})
+
+			err := os.MkdirAll(env.Config.ProfilesPath(), 0755)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = os.WriteFile(
+				filepath.Join(env.Config.ProfilesPath(), "kids.toml"),
+				[]byte("[readers.scan]\nignore_system = [\"PC\"]\n"),
+				0644,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = env.Config.SetProfile(tt.from)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = HandleSwitchProfile(env)
+			if tt.allowed && err != nil {
+				t.Fatal(err)
+			} else if !tt.allowed && !errors.Is(err, ErrNotAllowed) {
+				t.Fatalf("expected not allowed error, got: %v", err)
+			}
+
+			want := tt.to
+			if !tt.allowed {
+				want = tt.from
+			}
+			if got := env.Config.Profile(); got != want {
+				t.Errorf("active profile = %q, want %q", got, want)
+			}
+		})
+	}
+}
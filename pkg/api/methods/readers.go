@@ -5,6 +5,8 @@ import (
 	"errors"
 	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
 	"github.com/ZaparooProject/zaparoo-core/pkg/api/models/requests"
+	"github.com/ZaparooProject/zaparoo-core/pkg/audio"
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
 	"github.com/rs/zerolog/log"
 )
 
@@ -48,5 +50,7 @@ func HandleReaderWrite(env requests.RequestEnv) (any, error) {
 		env.State.SetWroteToken(t)
 	}
 
+	audio.Feedback(env.Platform, env.Config, config.SoundWriteComplete)
+
 	return nil, nil
 }
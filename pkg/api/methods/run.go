@@ -9,6 +9,7 @@ import (
 	"github.com/ZaparooProject/zaparoo-core/pkg/config"
 	"github.com/ZaparooProject/zaparoo-core/pkg/service/tokens"
 	"golang.org/x/text/unicode/norm"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
@@ -87,6 +88,7 @@ func HandleRun(env requests.RequestEnv) (any, error) {
 
 	t.ScanTime = time.Now()
 	t.Remote = true // TODO: check if this is still necessary after api update
+	t.Source = tokens.SourceApi + ":" + env.ClientIp
 
 	// TODO: how do we report back errors? put channel in queue
 	env.State.SetActiveCard(t)
@@ -119,10 +121,16 @@ func HandleRunRest(
 
 		log.Info().Msgf("running token: %s", text)
 
+		clientIp, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			clientIp = r.RemoteAddr
+		}
+
 		t := tokens.Token{
 			Text:     norm.NFC.String(text),
 			ScanTime: time.Now(),
 			Remote:   true,
+			Source:   tokens.SourceRest + ":" + clientIp,
 		}
 
 		st.SetActiveCard(t)
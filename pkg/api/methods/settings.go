@@ -2,35 +2,268 @@ package methods
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
 	"github.com/ZaparooProject/zaparoo-core/pkg/api/models/requests"
 	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/database/gamesdb"
 	"github.com/rs/zerolog/log"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"strings"
 )
 
+func launchOptionsToModel(o config.LaunchOptions) models.LaunchOptions {
+	return models.LaunchOptions{
+		Args:      o.Args,
+		Env:       o.Env,
+		Cwd:       o.Cwd,
+		PreLaunch: o.PreLaunch,
+		PostExit:  o.PostExit,
+		ExitDelay: o.ExitDelay,
+	}
+}
+
+func launchOptionsFromModel(o models.LaunchOptions) config.LaunchOptions {
+	return config.LaunchOptions{
+		Args:      o.Args,
+		Env:       o.Env,
+		Cwd:       o.Cwd,
+		PreLaunch: o.PreLaunch,
+		PostExit:  o.PostExit,
+		ExitDelay: o.ExitDelay,
+	}
+}
+
 func HandleSettings(env requests.RequestEnv) (any, error) {
 	log.Info().Msg("received settings request")
 
+	// settings are the config file's values, profiles are only changed
+	// through their own methods
+	vals := env.Config.BaseValues()
+
 	resp := models.SettingsResponse{
+		Profile:                 vals.Profile,
 		RunZapScript:            env.State.RunZapScriptEnabled(),
-		DebugLogging:            env.Config.DebugLogging(),
-		AudioScanFeedback:       env.Config.AudioFeedback(),
-		ReadersAutoDetect:       env.Config.Readers().AutoDetect,
-		ReadersScanMode:         env.Config.ReadersScan().Mode,
-		ReadersScanExitDelay:    env.Config.ReadersScan().ExitDelay,
+		DebugLogging:            vals.DebugLogging,
+		AudioScanFeedback:       vals.Audio.ScanFeedback,
+		AudioVolume:             config.DefaultVolume,
+		AudioSounds:             make(map[string]string),
+		NoticesEnabled:          vals.Notices.Enabled,
+		NoticesDuration:         vals.Notices.Duration,
+		ReadersAutoDetect:       vals.Readers.AutoDetect,
+		ReadersScanMode:         vals.Readers.Scan.Mode,
+		ReadersScanExitDelay:    vals.Readers.Scan.ExitDelay,
 		ReadersScanIgnoreSystem: make([]string, 0),
+		ReadersConnect:          make([]models.ReaderConnection, 0),
+		SystemsDefault:          make([]models.SystemDefault, 0),
+		LaunchersDefault:        make([]models.LauncherDefault, 0),
+		LaunchersCustom:         make([]models.CustomLauncher, 0),
+		LaunchersIndexRoot:      make([]string, 0),
+		LaunchersAllowFile:      make([]string, 0),
+		ZapScriptAllowExecute:   make([]string, 0),
+		ServiceApiPort:          vals.Service.ApiPort,
+		ServiceDeviceId:         vals.Service.DeviceId,
+		ServiceAllowRun:         make([]string, 0),
+		HistoryRetentionDays:    vals.History.RetentionDays,
+		HistoryMaxEntries:       vals.History.MaxEntries,
+		MqttEnabled:             vals.Mqtt.Enabled,
+		MqttBroker:              vals.Mqtt.Broker,
+		MqttUsername:            vals.Mqtt.Username,
+		MqttPasswordSet:         vals.Mqtt.Password != "",
+		MqttTopicPrefix:         vals.Mqtt.TopicPrefix,
+		MqttDiscovery:           vals.Mqtt.Discovery,
+		MqttDiscoveryPrefix:     vals.Mqtt.DiscoveryPrefix,
+		Mappings:                make([]models.ConfigMapping, 0),
+	}
+
+	if vals.Audio.Volume != nil {
+		resp.AudioVolume = *vals.Audio.Volume
+	}
+	for event, path := range vals.Audio.Sounds {
+		resp.AudioSounds[event] = path
+	}
+
+	resp.ReadersScanIgnoreSystem = append(resp.ReadersScanIgnoreSystem, vals.Readers.Scan.IgnoreSystem...)
+	resp.LaunchersIndexRoot = append(resp.LaunchersIndexRoot, vals.Launchers.IndexRoot...)
+	resp.LaunchersAllowFile = append(resp.LaunchersAllowFile, vals.Launchers.AllowFile...)
+	resp.ZapScriptAllowExecute = append(resp.ZapScriptAllowExecute, vals.ZapScript.AllowExecute...)
+	resp.ServiceAllowRun = append(resp.ServiceAllowRun, vals.Service.AllowRun...)
+
+	for _, rc := range vals.Readers.Connect {
+		resp.ReadersConnect = append(resp.ReadersConnect, models.ReaderConnection{
+			Driver: rc.Driver,
+			Path:   rc.Path,
+		})
+	}
+
+	for _, sd := range vals.Systems.Default {
+		resp.SystemsDefault = append(resp.SystemsDefault, models.SystemDefault{
+			System:        sd.System,
+			Launcher:      sd.Launcher,
+			BeforeExit:    sd.BeforeExit,
+			LaunchOptions: launchOptionsToModel(sd.LaunchOptions),
+		})
+	}
+
+	for _, ld := range vals.Launchers.Default {
+		resp.LaunchersDefault = append(resp.LaunchersDefault, models.LauncherDefault{
+			Launcher:      ld.Launcher,
+			LaunchOptions: launchOptionsToModel(ld.LaunchOptions),
+		})
+	}
+
+	for _, lc := range vals.Launchers.Custom {
+		resp.LaunchersCustom = append(resp.LaunchersCustom, models.CustomLauncher{
+			Id:         lc.Id,
+			System:     lc.System,
+			Folders:    lc.Folders,
+			Extensions: lc.Extensions,
+			Schemes:    lc.Schemes,
+			Command:    lc.Command,
+		})
+	}
+
+	resp.LaunchersRetroArch = models.RetroArchSettings{
+		Path:         vals.Launchers.RetroArch.Path,
+		CoresDir:     vals.Launchers.RetroArch.CoresDir,
+		PlaylistsDir: vals.Launchers.RetroArch.PlaylistsDir,
+		CommandPort:  vals.Launchers.RetroArch.CommandPort,
+		Cores:        make([]models.RetroArchCore, 0),
+	}
+	for _, rc := range vals.Launchers.RetroArch.Core {
+		resp.LaunchersRetroArch.Cores = append(resp.LaunchersRetroArch.Cores, models.RetroArchCore{
+			System: rc.System,
+			Core:   rc.Core,
+		})
 	}
 
-	for _, s := range env.Config.ReadersScan().IgnoreSystem {
-		resp.ReadersScanIgnoreSystem = append(
-			resp.ReadersScanIgnoreSystem,
-			s,
-		)
+	for _, m := range vals.Mappings.Entry {
+		resp.Mappings = append(resp.Mappings, models.ConfigMapping{
+			TokenKey:     m.TokenKey,
+			MatchPattern: m.MatchPattern,
+			ZapScript:    m.ZapScript,
+		})
 	}
 
 	return resp, nil
 }
 
+func launchOptionsEqual(a, b config.LaunchOptions) bool {
+	if (a.ExitDelay == nil) != (b.ExitDelay == nil) ||
+		(a.ExitDelay != nil && *a.ExitDelay != *b.ExitDelay) {
+		return false
+	}
+	return slices.Equal(a.Args, b.Args) && maps.Equal(a.Env, b.Env) &&
+		a.Cwd == b.Cwd && a.PreLaunch == b.PreLaunch && a.PostExit == b.PostExit
+}
+
+// keyedLaunchOptions are the launch options of a systems or launchers
+// default entry, with the entry's system or launcher ID.
+type keyedLaunchOptions struct {
+	id         string
+	beforeExit string
+	opts       config.LaunchOptions
+}
+
+// launchOptionsChanged returns true if two lists of default entries have
+// different launch options or before exit scripts. Entries without any are
+// ignored, so other defaults can still be changed.
+func launchOptionsChanged(a, b []keyedLaunchOptions) bool {
+	withOptions := func(ks []keyedLaunchOptions) []keyedLaunchOptions {
+		var set []keyedLaunchOptions
+		for _, k := range ks {
+			if k.beforeExit != "" || !launchOptionsEqual(k.opts, config.LaunchOptions{}) {
+				set = append(set, k)
+			}
+		}
+		return set
+	}
+
+	a, b = withOptions(a), withOptions(b)
+	if len(a) != len(b) {
+		return true
+	}
+
+	for i := range a {
+		if !strings.EqualFold(a[i].id, b[i].id) ||
+			a[i].beforeExit != b[i].beforeExit ||
+			!launchOptionsEqual(a[i].opts, b[i].opts) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// localOnlySettings returns the settings set in params which can only be
+// changed by a client on the same device as the service, compared to the
+// current values. They control what can be run, so a remote client could
+// otherwise allow itself to run anything. Launch options are run or passed
+// to every launched process, so other systems and launchers defaults can be
+// changed remotely but not their launch options.
+func localOnlySettings(params models.UpdateSettingsParams, vals config.Values) []string {
+	var names []string
+
+	if params.SystemsDefault != nil {
+		var current, updated []keyedLaunchOptions
+		for _, sd := range vals.Systems.Default {
+			current = append(current, keyedLaunchOptions{sd.System, sd.BeforeExit, sd.LaunchOptions})
+		}
+		for _, sd := range *params.SystemsDefault {
+			updated = append(updated, keyedLaunchOptions{
+				sd.System,
+				sd.BeforeExit,
+				launchOptionsFromModel(sd.LaunchOptions),
+			})
+		}
+		if launchOptionsChanged(current, updated) {
+			names = append(names, "systemsDefault launch options")
+		}
+	}
+
+	if params.LaunchersDefault != nil {
+		var current, updated []keyedLaunchOptions
+		for _, ld := range vals.Launchers.Default {
+			current = append(current, keyedLaunchOptions{ld.Launcher, "", ld.LaunchOptions})
+		}
+		for _, ld := range *params.LaunchersDefault {
+			updated = append(updated, keyedLaunchOptions{
+				ld.Launcher,
+				"",
+				launchOptionsFromModel(ld.LaunchOptions),
+			})
+		}
+		if launchOptionsChanged(current, updated) {
+			names = append(names, "launchersDefault launch options")
+		}
+	}
+
+	if params.LaunchersCustom != nil {
+		names = append(names, "launchersCustom")
+	}
+
+	// runs the configured binary and loads the configured cores
+	if params.LaunchersRetroArch != nil {
+		names = append(names, "launchersRetroArch")
+	}
+
+	if params.LaunchersAllowFile != nil {
+		names = append(names, "launchersAllowFile")
+	}
+
+	if params.ZapScriptAllowExecute != nil {
+		names = append(names, "zapScriptAllowExecute")
+	}
+
+	if params.ServiceAllowRun != nil {
+		names = append(names, "serviceAllowRun")
+	}
+
+	return names
+}
+
 func HandleSettingsUpdate(env requests.RequestEnv) (any, error) {
 	log.Info().Msg("received settings update request")
 
@@ -44,50 +277,315 @@ func HandleSettingsUpdate(env requests.RequestEnv) (any, error) {
 		return nil, ErrInvalidParams
 	}
 
-	if params.RunZapScript != nil {
-		log.Info().Bool("runZapScript", *params.RunZapScript).Msg("update")
-		if *params.RunZapScript {
-			env.State.SetRunZapScript(true)
-		} else {
-			env.State.SetRunZapScript(false)
-		}
+	// changes are made to a copy and only applied if they're all valid
+	before := env.Config.BaseValues()
+	vals := before
+
+	if names := localOnlySettings(params, before); !env.IsLocal && len(names) > 0 {
+		log.Warn().Strs("settings", names).Msg("remote client not allowed to update settings")
+		return nil, fmt.Errorf("%w: %s can only be changed locally", ErrNotAllowed, strings.Join(names, ", "))
 	}
 
 	if params.DebugLogging != nil {
 		log.Info().Bool("debugLogging", *params.DebugLogging).Msg("update")
-		env.Config.SetDebugLogging(*params.DebugLogging)
+		vals.DebugLogging = *params.DebugLogging
 	}
 
 	if params.AudioScanFeedback != nil {
 		log.Info().Bool("audioScanFeedback", *params.AudioScanFeedback).Msg("update")
-		env.Config.SetAudioFeedback(*params.AudioScanFeedback)
+		vals.Audio.ScanFeedback = *params.AudioScanFeedback
+	}
+
+	if params.AudioVolume != nil {
+		log.Info().Int("audioVolume", *params.AudioVolume).Msg("update")
+		volume := *params.AudioVolume
+		vals.Audio.Volume = &volume
+	}
+
+	if params.AudioSounds != nil {
+		log.Info().Any("audioSounds", *params.AudioSounds).Msg("update")
+		vals.Audio.Sounds = *params.AudioSounds
+	}
+
+	if params.NoticesEnabled != nil {
+		log.Info().Bool("noticesEnabled", *params.NoticesEnabled).Msg("update")
+		vals.Notices.Enabled = *params.NoticesEnabled
+	}
+
+	if params.NoticesDuration != nil {
+		log.Info().Float32("noticesDuration", *params.NoticesDuration).Msg("update")
+		vals.Notices.Duration = *params.NoticesDuration
 	}
 
 	if params.ReadersAutoDetect != nil {
 		log.Info().Bool("readersAutoDetect", *params.ReadersAutoDetect).Msg("update")
-		env.Config.SetAutoDetect(*params.ReadersAutoDetect)
+		vals.Readers.AutoDetect = *params.ReadersAutoDetect
 	}
 
 	if params.ReadersScanMode != nil {
 		log.Info().Str("readersScanMode", *params.ReadersScanMode).Msg("update")
 		if *params.ReadersScanMode == "" {
-			env.Config.SetScanMode(config.ScanModeTap)
-		} else if *params.ReadersScanMode == config.ScanModeTap || *params.ReadersScanMode == config.ScanModeHold {
-			env.Config.SetScanMode(*params.ReadersScanMode)
+			vals.Readers.Scan.Mode = config.ScanModeTap
 		} else {
-			return nil, ErrInvalidParams
+			vals.Readers.Scan.Mode = *params.ReadersScanMode
 		}
 	}
 
 	if params.ReadersScanExitDelay != nil {
 		log.Info().Float32("readersScanExitDelay", *params.ReadersScanExitDelay).Msg("update")
-		env.Config.SetScanExitDelay(*params.ReadersScanExitDelay)
+		vals.Readers.Scan.ExitDelay = *params.ReadersScanExitDelay
 	}
 
 	if params.ReadersScanIgnoreSystem != nil {
 		log.Info().Strs("readsScanIgnoreSystem", *params.ReadersScanIgnoreSystem).Msg("update")
-		env.Config.SetScanIgnoreSystem(*params.ReadersScanIgnoreSystem)
+		vals.Readers.Scan.IgnoreSystem = *params.ReadersScanIgnoreSystem
 	}
 
-	return nil, env.Config.Save()
+	if params.ReadersConnect != nil {
+		log.Info().Any("readersConnect", *params.ReadersConnect).Msg("update")
+		rcs := make([]config.ReadersConnect, 0, len(*params.ReadersConnect))
+		for _, rc := range *params.ReadersConnect {
+			rcs = append(rcs, config.ReadersConnect{
+				Driver: rc.Driver,
+				Path:   rc.Path,
+			})
+		}
+		vals.Readers.Connect = rcs
+	}
+
+	if params.SystemsDefault != nil {
+		log.Info().Any("systemsDefault", *params.SystemsDefault).Msg("update")
+		sds := make([]config.SystemsDefault, 0, len(*params.SystemsDefault))
+		for _, sd := range *params.SystemsDefault {
+			sds = append(sds, config.SystemsDefault{
+				System:        sd.System,
+				Launcher:      sd.Launcher,
+				BeforeExit:    sd.BeforeExit,
+				LaunchOptions: launchOptionsFromModel(sd.LaunchOptions),
+			})
+		}
+		vals.Systems.Default = sds
+	}
+
+	if params.LaunchersDefault != nil {
+		log.Info().Any("launchersDefault", *params.LaunchersDefault).Msg("update")
+		lds := make([]config.LaunchersDefault, 0, len(*params.LaunchersDefault))
+		for _, ld := range *params.LaunchersDefault {
+			lds = append(lds, config.LaunchersDefault{
+				Launcher:      ld.Launcher,
+				LaunchOptions: launchOptionsFromModel(ld.LaunchOptions),
+			})
+		}
+		vals.Launchers.Default = lds
+	}
+
+	if params.LaunchersCustom != nil {
+		log.Info().Any("launchersCustom", *params.LaunchersCustom).Msg("update")
+		lcs := make([]config.LaunchersCustom, 0, len(*params.LaunchersCustom))
+		for _, lc := range *params.LaunchersCustom {
+			lcs = append(lcs, config.LaunchersCustom{
+				Id:         lc.Id,
+				System:     lc.System,
+				Folders:    lc.Folders,
+				Extensions: lc.Extensions,
+				Schemes:    lc.Schemes,
+				Command:    lc.Command,
+			})
+		}
+		vals.Launchers.Custom = lcs
+	}
+
+	if params.LaunchersRetroArch != nil {
+		log.Info().Any("launchersRetroArch", *params.LaunchersRetroArch).Msg("update")
+		ra := *params.LaunchersRetroArch
+		rcs := make([]config.RetroArchCore, 0, len(ra.Cores))
+		for _, rc := range ra.Cores {
+			rcs = append(rcs, config.RetroArchCore{
+				System: rc.System,
+				Core:   rc.Core,
+			})
+		}
+		vals.Launchers.RetroArch = config.RetroArch{
+			Path:         ra.Path,
+			CoresDir:     ra.CoresDir,
+			PlaylistsDir: ra.PlaylistsDir,
+			CommandPort:  ra.CommandPort,
+			Core:         rcs,
+		}
+	}
+
+	if params.LaunchersIndexRoot != nil {
+		log.Info().Strs("launchersIndexRoot", *params.LaunchersIndexRoot).Msg("update")
+		vals.Launchers.IndexRoot = *params.LaunchersIndexRoot
+	}
+
+	if params.LaunchersAllowFile != nil {
+		log.Info().Strs("launchersAllowFile", *params.LaunchersAllowFile).Msg("update")
+		vals.Launchers.AllowFile = *params.LaunchersAllowFile
+	}
+
+	if params.ZapScriptAllowExecute != nil {
+		log.Info().Strs("zapScriptAllowExecute", *params.ZapScriptAllowExecute).Msg("update")
+		vals.ZapScript.AllowExecute = *params.ZapScriptAllowExecute
+	}
+
+	if params.ServiceApiPort != nil {
+		log.Info().Int("serviceApiPort", *params.ServiceApiPort).Msg("update")
+		vals.Service.ApiPort = *params.ServiceApiPort
+	}
+
+	if params.ServiceAllowRun != nil {
+		log.Info().Strs("serviceAllowRun", *params.ServiceAllowRun).Msg("update")
+		vals.Service.AllowRun = *params.ServiceAllowRun
+	}
+
+	if params.HistoryRetentionDays != nil {
+		log.Info().Int("historyRetentionDays", *params.HistoryRetentionDays).Msg("update")
+		vals.History.RetentionDays = *params.HistoryRetentionDays
+	}
+
+	if params.HistoryMaxEntries != nil {
+		log.Info().Int("historyMaxEntries", *params.HistoryMaxEntries).Msg("update")
+		vals.History.MaxEntries = *params.HistoryMaxEntries
+	}
+
+	if params.MqttEnabled != nil {
+		log.Info().Bool("mqttEnabled", *params.MqttEnabled).Msg("update")
+		vals.Mqtt.Enabled = *params.MqttEnabled
+	}
+
+	if params.MqttBroker != nil {
+		log.Info().Str("mqttBroker", *params.MqttBroker).Msg("update")
+		vals.Mqtt.Broker = *params.MqttBroker
+	}
+
+	if params.MqttUsername != nil {
+		log.Info().Str("mqttUsername", *params.MqttUsername).Msg("update")
+		vals.Mqtt.Username = *params.MqttUsername
+	}
+
+	if params.MqttPassword != nil {
+		log.Info().Msg("update mqttPassword")
+		vals.Mqtt.Password = *params.MqttPassword
+	}
+
+	if params.MqttTopicPrefix != nil {
+		log.Info().Str("mqttTopicPrefix", *params.MqttTopicPrefix).Msg("update")
+		vals.Mqtt.TopicPrefix = *params.MqttTopicPrefix
+	}
+
+	if params.MqttDiscovery != nil {
+		log.Info().Bool("mqttDiscovery", *params.MqttDiscovery).Msg("update")
+		vals.Mqtt.Discovery = *params.MqttDiscovery
+	}
+
+	if params.MqttDiscoveryPrefix != nil {
+		log.Info().Str("mqttDiscoveryPrefix", *params.MqttDiscoveryPrefix).Msg("update")
+		vals.Mqtt.DiscoveryPrefix = *params.MqttDiscoveryPrefix
+	}
+
+	if params.Mappings != nil {
+		log.Info().Any("mappings", *params.Mappings).Msg("update")
+		entries := make([]config.MappingsEntry, 0, len(*params.Mappings))
+		for _, m := range *params.Mappings {
+			entries = append(entries, config.MappingsEntry{
+				TokenKey:     m.TokenKey,
+				MatchPattern: m.MatchPattern,
+				ZapScript:    m.ZapScript,
+			})
+		}
+		vals.Mappings.Entry = entries
+	}
+
+	issues, err := config.ValidateValues(vals, gamesdb.ConfigValidateOptions(env.Platform, env.Config))
+	if err != nil {
+		return nil, err
+	}
+
+	// only errors in changed sections are reported, so existing problems in
+	// the config file don't block unrelated updates
+	changed := config.ChangedSections(before, vals)
+	var errs []string
+	for _, issue := range issues {
+		section, _, _ := strings.Cut(issue.Key, ".")
+		section, _, _ = strings.Cut(section, "[")
+		if !issue.Warning && slices.Contains(changed, section) {
+			errs = append(errs, issue.String())
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid settings: %s", strings.Join(errs, "; "))
+	}
+
+	if params.RunZapScript != nil {
+		log.Info().Bool("runZapScript", *params.RunZapScript).Msg("update")
+		env.State.SetRunZapScript(*params.RunZapScript)
+	}
+
+	env.Config.SetValues(vals)
+	// also applies the log level
+	env.Config.SetDebugLogging(vals.DebugLogging)
+
+	err = env.Config.Save()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changed) > 0 {
+		env.State.Notifications <- models.Notification{
+			Method: models.NotificationSettingsChanged,
+			Params: models.SettingsChangedResponse{
+				Sections: changed,
+			},
+		}
+	}
+
+	return nil, nil
+}
+
+func HandleSettingsValidate(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received settings validate request")
+
+	var params models.ValidateSettingsParams
+	if len(env.Params) > 0 {
+		err := json.Unmarshal(env.Params, &params)
+		if err != nil {
+			return nil, ErrInvalidParams
+		}
+	}
+
+	opts := gamesdb.ConfigValidateOptions(env.Platform, env.Config)
+
+	var issues []config.Issue
+	if params.Config != nil {
+		issues = config.Validate(config.CfgFile, []byte(*params.Config), opts)
+	} else {
+		var err error
+		issues, err = config.ValidateFile(env.Config.Path(), opts)
+		if err != nil {
+			log.Error().Err(err).Msg("error reading config file")
+			return nil, errors.New("error reading config file")
+		}
+	}
+
+	resp := models.ValidateSettingsResponse{
+		Valid:  !config.HasErrors(issues),
+		Issues: make([]models.SettingsIssue, 0, len(issues)),
+	}
+
+	for _, issue := range issues {
+		severity := "error"
+		if issue.Warning {
+			severity = "warning"
+		}
+
+		resp.Issues = append(resp.Issues, models.SettingsIssue{
+			Line:     issue.Line,
+			Key:      issue.Key,
+			Message:  issue.Message,
+			Severity: severity,
+		})
+	}
+
+	return resp, nil
 }
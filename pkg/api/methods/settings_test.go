@@ -0,0 +1,253 @@
+package methods
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/state"
+)
+
+func TestSettingsUpdateAllowLists(t *testing.T) {
+	allow := []string{"/bin/sh"}
+
+	tests := []struct {
+		name   string
+		params models.UpdateSettingsParams
+	}{
+		{
+			name:   "launchers allow file",
+			params: models.UpdateSettingsParams{LaunchersAllowFile: &allow},
+		},
+		{
+			name:   "zapscript allow execute",
+			params: models.UpdateSettingsParams{ZapScriptAllowExecute: &allow},
+		},
+		{
+			name:   "service allow run",
+			params: models.UpdateSettingsParams{ServiceAllowRun: &allow},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" remote", func(t *testing.T) {
+			env := testEnv(t, false, tt.params)
+			before := env.Config.BaseValues()
+
+			_, err := HandleSettingsUpdate(env)
+			if !errors.Is(err, ErrNotAllowed) {
+				t.Fatalf("expected not allowed error, got: %v", err)
+			}
+
+			if !reflect.DeepEqual(before, env.Config.BaseValues()) {
+				t.Error("settings changed by remote client")
+			}
+		})
+
+		t.Run(tt.name+" local", func(t *testing.T) {
+			env := testEnv(t, true, tt.params)
+			st, ns := state.NewState(nil)
+			env.State = st
+			go func() {
+				for range ns {
+				}
+			}()
+
+			_, err := HandleSettingsUpdate(env)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			vals := env.Config.BaseValues()
+			got := [][]string{
+				vals.Launchers.AllowFile,
+				vals.ZapScript.AllowExecute,
+				vals.Service.AllowRun,
+			}
+			found := false
+			for _, g := range got {
+				if reflect.DeepEqual(g, allow) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("allow list not updated by local client: %+v", got)
+			}
+		})
+	}
+}
+
+func TestSettingsUpdateRemote(t *testing.T) {
+	debug := true
+	env := testEnv(t, false, models.UpdateSettingsParams{DebugLogging: &debug})
+	st, ns := state.NewState(nil)
+	env.State = st
+	go func() {
+		for range ns {
+		}
+	}()
+
+	_, err := HandleSettingsUpdate(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !env.Config.BaseValues().DebugLogging {
+		t.Error("other settings not updated by remote client")
+	}
+}
+
+func TestSettingsUpdateCustomLaunchers(t *testing.T) {
+	custom := []models.CustomLauncher{
+		{Id: "Shell", Extensions: []string{".sh"}, Command: "sh {path}"},
+	}
+
+	env := testEnv(t, false, models.UpdateSettingsParams{LaunchersCustom: &custom})
+
+	_, err := HandleSettingsUpdate(env)
+	if !errors.Is(err, ErrNotAllowed) {
+		t.Fatalf("expected not allowed error, got: %v", err)
+	}
+
+	if len(env.Config.CustomLaunchers()) != 0 {
+		t.Error("custom launchers added by remote client")
+	}
+}
+
+func TestSettingsUpdateFeed(t *testing.T) {
+	params := map[string]any{
+		"debugLogging":    true,
+		"updateFeedUrl":   "http://192.168.1.2/feed.json",
+		"updatePublicKey": "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+	}
+	env := testEnv(t, true, params)
+	st, ns := state.NewState(nil)
+	env.State = st
+	go func() {
+		for range ns {
+		}
+	}()
+
+	_, err := HandleSettingsUpdate(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if env.Config.Update() != (config.Update{}) {
+		t.Errorf("update feed changed through the api: %+v", env.Config.Update())
+	}
+}
+
+func TestSettingsUpdateLaunchOptions(t *testing.T) {
+	preload := models.LaunchOptions{Env: map[string]string{"LD_PRELOAD": "/tmp/evil.so"}}
+
+	tests := []struct {
+		name   string
+		params models.UpdateSettingsParams
+	}{
+		{
+			name: "systems default env",
+			params: models.UpdateSettingsParams{SystemsDefault: &[]models.SystemDefault{
+				{System: "SNES", LaunchOptions: preload},
+			}},
+		},
+		{
+			name: "systems default before exit",
+			params: models.UpdateSettingsParams{SystemsDefault: &[]models.SystemDefault{
+				{System: "SNES", BeforeExit: "**execute:rm -rf ~"},
+			}},
+		},
+		{
+			name: "launchers default args",
+			params: models.UpdateSettingsParams{LaunchersDefault: &[]models.LauncherDefault{
+				{Launcher: "Steam", LaunchOptions: models.LaunchOptions{Args: []string{"-x"}}},
+			}},
+		},
+		{
+			name: "launchers default pre launch",
+			params: models.UpdateSettingsParams{LaunchersDefault: &[]models.LauncherDefault{
+				{Launcher: "Steam", LaunchOptions: models.LaunchOptions{PreLaunch: "**execute:sh"}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := testEnv(t, false, tt.params)
+			before := env.Config.BaseValues()
+
+			_, err := HandleSettingsUpdate(env)
+			if !errors.Is(err, ErrNotAllowed) {
+				t.Fatalf("expected not allowed error, got: %v", err)
+			}
+
+			if !reflect.DeepEqual(before, env.Config.BaseValues()) {
+				t.Error("launch options changed by remote client")
+			}
+		})
+	}
+}
+
+func TestSettingsUpdateDefaultsRemote(t *testing.T) {
+	params := models.UpdateSettingsParams{
+		// launch options are sent back unchanged with another entry removed
+		SystemsDefault: &[]models.SystemDefault{
+			{System: "SNES", LaunchOptions: models.LaunchOptions{Cwd: "/games"}},
+		},
+	}
+
+	env := testEnv(t, false, params)
+	vals := env.Config.BaseValues()
+	vals.Systems.Default = []config.SystemsDefault{
+		{System: "SNES", LaunchOptions: config.LaunchOptions{Cwd: "/games"}},
+		{System: "Genesis"},
+	}
+	env.Config.SetValues(vals)
+
+	st, ns := state.NewState(nil)
+	env.State = st
+	go func() {
+		for range ns {
+		}
+	}()
+
+	_, err := HandleSettingsUpdate(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := env.Config.BaseValues().Systems.Default; len(got) != 1 {
+		t.Errorf("system defaults not updated: %+v", got)
+	}
+}
+
+func TestSettingsUpdateRetroArch(t *testing.T) {
+	tests := []struct {
+		name string
+		ra   models.RetroArchSettings
+	}{
+		{"path", models.RetroArchSettings{Path: "/tmp/evil"}},
+		{"core", models.RetroArchSettings{Cores: []models.RetroArchCore{
+			{System: "SNES", Core: "/tmp/evil.so"},
+		}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ra := tt.ra
+			env := testEnv(t, false, models.UpdateSettingsParams{LaunchersRetroArch: &ra})
+			before := env.Config.BaseValues()
+
+			_, err := HandleSettingsUpdate(env)
+			if !errors.Is(err, ErrNotAllowed) {
+				t.Fatalf("expected not allowed error, got: %v", err)
+			}
+
+			if !reflect.DeepEqual(before, env.Config.BaseValues()) {
+				t.Error("retroarch settings changed by remote client")
+			}
+		})
+	}
+}
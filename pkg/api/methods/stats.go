@@ -0,0 +1,167 @@
+package methods
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models/requests"
+	"github.com/ZaparooProject/zaparoo-core/pkg/database"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/stats"
+	"github.com/rs/zerolog/log"
+)
+
+const defaultStatsLimit = 25
+
+// Parse the optional stats params and return all sessions in range along
+// with the result limit.
+func statsSessions(env requests.RequestEnv) ([]database.PlaySession, int, error) {
+	var params models.StatsParams
+	if len(env.Params) > 0 {
+		err := json.Unmarshal(env.Params, &params)
+		if err != nil {
+			return nil, 0, ErrInvalidParams
+		}
+	}
+
+	limit := defaultStatsLimit
+	if params.Limit != nil {
+		if *params.Limit < 0 {
+			return nil, 0, ErrInvalidParams
+		}
+		limit = *params.Limit
+	}
+
+	var since time.Time
+	if params.Since != nil {
+		since = *params.Since
+	}
+
+	ss, err := env.Database.GetPlaySessions(since)
+	if err != nil {
+		log.Error().Err(err).Msg("error getting play sessions")
+		return nil, 0, errors.New("error getting play sessions")
+	}
+
+	return ss, limit, nil
+}
+
+func HandleStatsMedia(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received stats media request")
+
+	ss, limit, err := statsSessions(env)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := models.StatsMediaResponse{
+		Media: make([]models.StatsMediaEntry, 0),
+	}
+
+	for _, m := range stats.MostPlayed(ss, limit) {
+		resp.Media = append(resp.Media, models.StatsMediaEntry{
+			SystemId:     m.SystemId,
+			SystemName:   m.SystemName,
+			MediaName:    m.MediaName,
+			MediaPath:    m.MediaPath,
+			Sessions:     m.Sessions,
+			TotalSeconds: int64(m.TotalTime.Seconds()),
+			LastPlayed:   m.LastPlayed,
+		})
+	}
+
+	return resp, nil
+}
+
+func HandleStatsSystems(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received stats systems request")
+
+	ss, _, err := statsSessions(env)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := models.StatsSystemsResponse{
+		Systems: make([]models.StatsSystemEntry, 0),
+	}
+
+	for _, s := range stats.SystemTotals(ss) {
+		resp.Systems = append(resp.Systems, models.StatsSystemEntry{
+			SystemId:     s.SystemId,
+			SystemName:   s.SystemName,
+			Sessions:     s.Sessions,
+			TotalSeconds: int64(s.TotalTime.Seconds()),
+		})
+	}
+
+	return resp, nil
+}
+
+func HandleStatsSessions(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received stats sessions request")
+
+	ss, limit, err := statsSessions(env)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit > 0 && len(ss) > limit {
+		ss = ss[:limit]
+	}
+
+	resp := models.StatsSessionsResponse{
+		Sessions: make([]models.StatsSessionEntry, 0, len(ss)),
+	}
+
+	for _, s := range ss {
+		e := models.StatsSessionEntry{
+			Id:         s.Id,
+			Start:      s.Start,
+			Seconds:    int64(s.Duration().Seconds()),
+			SystemId:   s.SystemId,
+			SystemName: s.SystemName,
+			MediaName:  s.MediaName,
+			MediaPath:  s.MediaPath,
+			LauncherId: s.LauncherId,
+			TokenType:  s.TokenType,
+			TokenUID:   s.TokenUID,
+			TokenText:  s.TokenText,
+		}
+
+		if s.Ended {
+			end := s.End
+			e.End = &end
+		}
+
+		resp.Sessions = append(resp.Sessions, e)
+	}
+
+	return resp, nil
+}
+
+func HandleStatsTokens(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received stats tokens request")
+
+	ss, limit, err := statsSessions(env)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := models.StatsTokensResponse{
+		Tokens: make([]models.StatsTokenEntry, 0),
+	}
+
+	for _, t := range stats.TokenUsage(ss, limit) {
+		resp.Tokens = append(resp.Tokens, models.StatsTokenEntry{
+			Type:         t.Type,
+			UID:          t.UID,
+			Text:         t.Text,
+			Sessions:     t.Sessions,
+			TotalSeconds: int64(t.TotalTime.Seconds()),
+			LastPlayed:   t.LastPlayed,
+		})
+	}
+
+	return resp, nil
+}
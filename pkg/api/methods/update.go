@@ -0,0 +1,66 @@
+package methods
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models/requests"
+	"github.com/ZaparooProject/zaparoo-core/pkg/updater"
+	"github.com/rs/zerolog/log"
+)
+
+func HandleUpdateCheck(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received update check request")
+
+	status, err := updater.Check(env.Config, env.Platform.Id())
+	if err != nil {
+		log.Error().Err(err).Msg("error checking for update")
+		return nil, err
+	}
+
+	return models.UpdateCheckResponse{
+		CurrentVersion: status.Current,
+		LatestVersion:  status.Latest,
+		Available:      status.Available,
+		Notes:          status.Notes,
+	}, nil
+}
+
+// HandleUpdateApply installs the latest release over the service's binary and
+// restarts the service if it can. Large downloads may outlast the client's
+// request, the update carries on regardless. Only local clients can apply
+// updates.
+func HandleUpdateApply(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received update apply request")
+
+	if !env.IsLocal {
+		return nil, fmt.Errorf("%w: updates can only be applied locally", ErrNotAllowed)
+	}
+
+	binPath, err := updater.BinPath()
+	if err != nil {
+		log.Error().Err(err).Msg("error getting binary path")
+		return nil, errors.New("error getting binary path")
+	}
+
+	status, err := updater.Apply(env.Config, env.Platform.Id(), binPath)
+	if err != nil {
+		log.Error().Err(err).Msg("error applying update")
+		return nil, err
+	}
+
+	resp := models.UpdateApplyResponse{
+		PreviousVersion: status.Current,
+		Version:         status.Latest,
+	}
+
+	err = updater.Restart(env.Platform, binPath)
+	if err == nil {
+		resp.Restarting = true
+	} else if !errors.Is(err, updater.ErrRestartUnsupported) {
+		log.Error().Err(err).Msg("error restarting service")
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,15 @@
+package methods
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUpdateApplyRemote(t *testing.T) {
+	env := testEnv(t, false, nil)
+
+	_, err := HandleUpdateApply(env)
+	if !errors.Is(err, ErrNotAllowed) {
+		t.Fatalf("expected not allowed error, got: %v", err)
+	}
+}
@@ -0,0 +1,262 @@
+package methods
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models/requests"
+	"github.com/ZaparooProject/zaparoo-core/pkg/database"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/webhooks"
+	"github.com/ZaparooProject/zaparoo-core/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+func webhookDeliveryResponse(wd database.WebhookDelivery) models.WebhookDeliveryResponse {
+	return models.WebhookDeliveryResponse{
+		Id:         wd.Id,
+		Time:       wd.Time,
+		Event:      wd.Event,
+		Attempts:   wd.Attempts,
+		Success:    wd.Success,
+		StatusCode: wd.StatusCode,
+		Error:      wd.Error,
+	}
+}
+
+// maskSecret hides all but the last few characters of a webhook secret, so
+// it can be recognised but not read back.
+func maskSecret(secret string) string {
+	const shown = 4
+	if len(secret) <= shown*2 {
+		return strings.Repeat("*", len(secret))
+	}
+	return strings.Repeat("*", len(secret)-shown) + secret[len(secret)-shown:]
+}
+
+func validateWebhookEvents(events []string) error {
+	for _, e := range events {
+		if !utils.Contains(webhooks.Events, e) {
+			return errors.New("invalid event: " + e)
+		}
+	}
+	return nil
+}
+
+func HandleWebhooks(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received webhooks request")
+
+	ws, err := env.Database.GetAllWebhooks()
+	if err != nil {
+		log.Error().Err(err).Msg("error getting webhooks")
+		return nil, errors.New("error getting webhooks")
+	}
+
+	resp := models.AllWebhooksResponse{
+		Webhooks: make([]models.WebhookResponse, 0),
+	}
+
+	for _, w := range ws {
+		wr := models.WebhookResponse{
+			Id:      w.Id,
+			Added:   time.Unix(w.Added, 0).Format(time.RFC3339),
+			Label:   w.Label,
+			Enabled: w.Enabled,
+			Url:     w.Url,
+			Secret:  maskSecret(w.Secret),
+			Events:  w.Events,
+		}
+
+		if wr.Events == nil {
+			wr.Events = make([]string, 0)
+		}
+
+		wds, err := env.Database.GetWebhookDeliveries(w.Id)
+		if err != nil {
+			log.Error().Err(err).Msgf("error getting webhook deliveries: %s", w.Id)
+		} else if len(wds) > 0 {
+			last := webhookDeliveryResponse(wds[0])
+			wr.LastDelivery = &last
+		}
+
+		resp.Webhooks = append(resp.Webhooks, wr)
+	}
+
+	return resp, nil
+}
+
+func HandleAddWebhook(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received add webhook request")
+
+	if len(env.Params) == 0 {
+		return nil, ErrMissingParams
+	}
+
+	var params models.AddWebhookParams
+	err := json.Unmarshal(env.Params, &params)
+	if err != nil {
+		return nil, ErrInvalidParams
+	}
+
+	err = database.ValidateWebhookUrl(params.Url)
+	if err != nil {
+		log.Error().Err(err).Msg("invalid params")
+		return nil, ErrInvalidParams
+	}
+
+	err = validateWebhookEvents(params.Events)
+	if err != nil {
+		log.Error().Err(err).Msg("invalid params")
+		return nil, ErrInvalidParams
+	}
+
+	w := database.Webhook{
+		Label:   params.Label,
+		Enabled: params.Enabled,
+		Url:     params.Url,
+		Events:  params.Events,
+	}
+
+	if params.Secret != nil {
+		w.Secret = *params.Secret
+	} else {
+		w.Secret = strings.ReplaceAll(uuid.New().String(), "-", "")
+	}
+
+	id, err := env.Database.AddWebhook(w)
+	if err != nil {
+		return nil, err
+	}
+
+	// the only time the secret is sent back unmasked
+	return models.AddWebhookResponse{
+		Id:     id,
+		Secret: w.Secret,
+	}, nil
+}
+
+func HandleDeleteWebhook(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received delete webhook request")
+
+	if len(env.Params) == 0 {
+		return nil, ErrMissingParams
+	}
+
+	var params models.WebhookIdParams
+	err := json.Unmarshal(env.Params, &params)
+	if err != nil {
+		return nil, ErrInvalidParams
+	}
+
+	return nil, env.Database.DeleteWebhook(strconv.Itoa(params.Id))
+}
+
+func HandleUpdateWebhook(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received update webhook request")
+
+	if len(env.Params) == 0 {
+		return nil, ErrMissingParams
+	}
+
+	var params models.UpdateWebhookParams
+	err := json.Unmarshal(env.Params, &params)
+	if err != nil {
+		return nil, ErrInvalidParams
+	}
+
+	if params.Events != nil {
+		err = validateWebhookEvents(*params.Events)
+		if err != nil {
+			log.Error().Err(err).Msg("invalid params")
+			return nil, ErrInvalidParams
+		}
+	}
+
+	w, err := env.Database.GetWebhook(strconv.Itoa(params.Id))
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Label != nil {
+		w.Label = *params.Label
+	}
+
+	if params.Enabled != nil {
+		w.Enabled = *params.Enabled
+	}
+
+	if params.Url != nil {
+		w.Url = *params.Url
+	}
+
+	if params.Secret != nil {
+		w.Secret = *params.Secret
+	}
+
+	if params.Events != nil {
+		w.Events = *params.Events
+	}
+
+	return nil, env.Database.UpdateWebhook(strconv.Itoa(params.Id), w)
+}
+
+func HandleWebhookDeliveries(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received webhook deliveries request")
+
+	if len(env.Params) == 0 {
+		return nil, ErrMissingParams
+	}
+
+	var params models.WebhookIdParams
+	err := json.Unmarshal(env.Params, &params)
+	if err != nil {
+		return nil, ErrInvalidParams
+	}
+
+	wds, err := env.Database.GetWebhookDeliveries(strconv.Itoa(params.Id))
+	if err != nil {
+		log.Error().Err(err).Msg("error getting webhook deliveries")
+		return nil, errors.New("error getting webhook deliveries")
+	}
+
+	resp := models.WebhookDeliveriesResponse{
+		Deliveries: make([]models.WebhookDeliveryResponse, 0, len(wds)),
+	}
+
+	for _, wd := range wds {
+		resp.Deliveries = append(resp.Deliveries, webhookDeliveryResponse(wd))
+	}
+
+	return resp, nil
+}
+
+// HandleTestWebhook sends a test payload to a webhook once and waits for the
+// result.
+func HandleTestWebhook(env requests.RequestEnv) (any, error) {
+	log.Info().Msg("received test webhook request")
+
+	if len(env.Params) == 0 {
+		return nil, ErrMissingParams
+	}
+
+	var params models.WebhookIdParams
+	err := json.Unmarshal(env.Params, &params)
+	if err != nil {
+		return nil, ErrInvalidParams
+	}
+
+	w, err := env.Database.GetWebhook(strconv.Itoa(params.Id))
+	if err != nil {
+		return nil, err
+	}
+
+	p := webhooks.NewPayload(env.Config, models.Notification{
+		Method: models.MethodWebhooksTest,
+	})
+
+	return webhookDeliveryResponse(webhooks.Test(env.Database, w, p)), nil
+}
@@ -8,33 +8,62 @@ const (
 	NotificationRunning             = "running"
 	NotificationTokensAdded         = "tokens.added"
 	NotificationTokensRemoved       = "tokens.removed"
+	NotificationTokensFailed        = "tokens.failed"
 	NotificationStopped             = "media.stopped"
 	NotificationStarted             = "media.started"
 	NotificationMediaIndexing       = "media.indexing"
+	NotificationPlaylistsChanged    = "playlists.changed"
+	NotificationSettingsChanged     = "settings.changed"
+	NotificationProfilesChanged     = "profiles.changed"
 )
 
 const (
-	MethodLaunch         = "launch" // DEPRECATED
-	MethodRun            = "run"
-	MethodStop           = "stop"
-	MethodTokens         = "tokens"
-	MethodMedia          = "media"
-	MethodMediaIndex     = "media.index"
-	MethodMediaSearch    = "media.search"
-	MethodSettings       = "settings"
-	MethodSettingsUpdate = "settings.update"
-	MethodClients        = "clients"
-	MethodClientsNew     = "clients.new"
-	MethodClientsDelete  = "clients.delete"
-	MethodSystems        = "systems"
-	MethodHistory        = "tokens.history"
-	MethodMappings       = "mappings"
-	MethodMappingsNew    = "mappings.new"
-	MethodMappingsDelete = "mappings.delete"
-	MethodMappingsUpdate = "mappings.update"
-	MethodMappingsReload = "mappings.reload"
-	MethodReadersWrite   = "readers.write"
-	MethodVersion        = "version"
+	MethodLaunch             = "launch" // DEPRECATED
+	MethodRun                = "run"
+	MethodStop               = "stop"
+	MethodTokens             = "tokens"
+	MethodMedia              = "media"
+	MethodMediaIndex         = "media.index"
+	MethodMediaSearch        = "media.search"
+	MethodSettings           = "settings"
+	MethodSettingsUpdate     = "settings.update"
+	MethodSettingsValidate   = "settings.validate"
+	MethodClients            = "clients"
+	MethodClientsNew         = "clients.new"
+	MethodClientsDelete      = "clients.delete"
+	MethodSystems            = "systems"
+	MethodHistory            = "tokens.history"
+	MethodHistoryExport      = "tokens.history.export"
+	MethodMappings           = "mappings"
+	MethodMappingsNew        = "mappings.new"
+	MethodMappingsDelete     = "mappings.delete"
+	MethodMappingsUpdate     = "mappings.update"
+	MethodMappingsReload     = "mappings.reload"
+	MethodReadersWrite       = "readers.write"
+	MethodVersion            = "version"
+	MethodWebhooks           = "webhooks"
+	MethodWebhooksNew        = "webhooks.new"
+	MethodWebhooksDelete     = "webhooks.delete"
+	MethodWebhooksUpdate     = "webhooks.update"
+	MethodWebhooksDeliveries = "webhooks.deliveries"
+	MethodWebhooksTest       = "webhooks.test"
+	MethodPlaylists          = "playlists"
+	MethodPlaylistsNew       = "playlists.new"
+	MethodPlaylistsUpdate    = "playlists.update"
+	MethodPlaylistsDelete    = "playlists.delete"
+	MethodPlaylistsPlay      = "playlists.play"
+	MethodPlaylistsActive    = "playlists.active"
+	MethodPlaylistsNext      = "playlists.next"
+	MethodPlaylistsPrevious  = "playlists.previous"
+	MethodPlaylistsStop      = "playlists.stop"
+	MethodProfiles           = "profiles"
+	MethodProfilesSwitch     = "profiles.switch"
+	MethodStatsMedia         = "stats.media"
+	MethodStatsSystems       = "stats.systems"
+	MethodStatsSessions      = "stats.sessions"
+	MethodStatsTokens        = "stats.tokens"
+	MethodUpdateCheck        = "update.check"
+	MethodUpdateApply        = "update.apply"
 )
 
 type Notification struct {
@@ -68,6 +97,83 @@ type ClientResponse struct {
 	Secret  string    `json:"secret"`
 }
 
+// Config types shared by the settings and settings.update methods.
+
+type ReaderConnection struct {
+	Driver string `json:"driver"`
+	Path   string `json:"path"`
+}
+
+type LaunchOptions struct {
+	Args      []string          `json:"args,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+	Cwd       string            `json:"cwd,omitempty"`
+	PreLaunch string            `json:"preLaunch,omitempty"`
+	PostExit  string            `json:"postExit,omitempty"`
+	ExitDelay *float32          `json:"exitDelay,omitempty"`
+}
+
+type SystemDefault struct {
+	System     string `json:"system"`
+	Launcher   string `json:"launcher"`
+	BeforeExit string `json:"beforeExit"`
+	LaunchOptions
+}
+
+type LauncherDefault struct {
+	Launcher string `json:"launcher"`
+	LaunchOptions
+}
+
+type CustomLauncher struct {
+	Id         string   `json:"id"`
+	System     string   `json:"system"`
+	Folders    []string `json:"folders"`
+	Extensions []string `json:"extensions"`
+	Schemes    []string `json:"schemes"`
+	Command    string   `json:"command"`
+}
+
+type RetroArchCore struct {
+	System string `json:"system"`
+	Core   string `json:"core"`
+}
+
+type RetroArchSettings struct {
+	Path         string          `json:"path"`
+	CoresDir     string          `json:"coresDir"`
+	PlaylistsDir string          `json:"playlistsDir"`
+	CommandPort  int             `json:"commandPort"`
+	Cores        []RetroArchCore `json:"cores"`
+}
+
+type ConfigMapping struct {
+	TokenKey     string `json:"tokenKey"`
+	MatchPattern string `json:"matchPattern"`
+	ZapScript    string `json:"zapscript"`
+}
+
+// LaunchError describes why a token's ZapScript couldn't be run.
+// Candidates are the paths checked or systems searched and suggestions are
+// ZapScript for indexed games similar to the one asked for.
+type LaunchError struct {
+	Code        string   `json:"code"`
+	Message     string   `json:"message"`
+	Command     string   `json:"command,omitempty"`
+	Stage       string   `json:"stage,omitempty"`
+	Candidates  []string `json:"candidates"`
+	Suggestions []string `json:"suggestions"`
+}
+
+// TokenFailedParams is sent when a scanned token's ZapScript couldn't be
+// run.
+type TokenFailedParams struct {
+	Type  string      `json:"type"`
+	UID   string      `json:"uid"`
+	Text  string      `json:"text"`
+	Error LaunchError `json:"error"`
+}
+
 type MediaStartedParams struct {
 	SystemId   string `json:"systemId"`
 	SystemName string `json:"systemName"`
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type SearchParams struct {
 	Query      string    `json:"query"`
 	Systems    *[]string `json:"systems"`
@@ -40,18 +42,69 @@ type UpdateMappingParams struct {
 	Override *string `json:"override"`
 }
 
+type HistoryParams struct {
+	Cursor  *string    `json:"cursor"`
+	Limit   *int       `json:"limit"`
+	Since   *time.Time `json:"since"`
+	Until   *time.Time `json:"until"`
+	UID     *string    `json:"uid"`
+	Text    *string    `json:"text"`
+	Source  *string    `json:"source"`
+	Success *bool      `json:"success"`
+}
+
+type HistoryExportParams struct {
+	HistoryParams
+	Format string `json:"format"`
+}
+
 type ReaderWriteParams struct {
 	Text string `json:"text"`
 }
 
+// Only set fields are updated. Lists replace the whole list.
 type UpdateSettingsParams struct {
-	RunZapScript            *bool     `json:"runZapScript"`
-	DebugLogging            *bool     `json:"debugLogging"`
-	AudioScanFeedback       *bool     `json:"audioScanFeedback"`
-	ReadersAutoDetect       *bool     `json:"readersAutoDetect"`
-	ReadersScanMode         *string   `json:"readersScanMode"`
-	ReadersScanExitDelay    *float32  `json:"readersScanExitDelay"`
-	ReadersScanIgnoreSystem *[]string `json:"readersScanIgnoreSystems"`
+	RunZapScript            *bool               `json:"runZapScript"`
+	DebugLogging            *bool               `json:"debugLogging"`
+	AudioScanFeedback       *bool               `json:"audioScanFeedback"`
+	AudioVolume             *int                `json:"audioVolume"`
+	AudioSounds             *map[string]string  `json:"audioSounds"`
+	NoticesEnabled          *bool               `json:"noticesEnabled"`
+	NoticesDuration         *float32            `json:"noticesDuration"`
+	ReadersAutoDetect       *bool               `json:"readersAutoDetect"`
+	ReadersScanMode         *string             `json:"readersScanMode"`
+	ReadersScanExitDelay    *float32            `json:"readersScanExitDelay"`
+	ReadersScanIgnoreSystem *[]string           `json:"readersScanIgnoreSystems"`
+	ReadersConnect          *[]ReaderConnection `json:"readersConnect"`
+	SystemsDefault          *[]SystemDefault    `json:"systemsDefault"`
+	LaunchersDefault        *[]LauncherDefault  `json:"launchersDefault"`
+	LaunchersCustom         *[]CustomLauncher   `json:"launchersCustom"`
+	LaunchersRetroArch      *RetroArchSettings  `json:"launchersRetroArch"`
+	LaunchersIndexRoot      *[]string           `json:"launchersIndexRoot"`
+	LaunchersAllowFile      *[]string           `json:"launchersAllowFile"`
+	ZapScriptAllowExecute   *[]string           `json:"zapScriptAllowExecute"`
+	ServiceApiPort          *int                `json:"serviceApiPort"`
+	ServiceAllowRun         *[]string           `json:"serviceAllowRun"`
+	HistoryRetentionDays    *int                `json:"historyRetentionDays"`
+	HistoryMaxEntries       *int                `json:"historyMaxEntries"`
+	MqttEnabled             *bool               `json:"mqttEnabled"`
+	MqttBroker              *string             `json:"mqttBroker"`
+	MqttUsername            *string             `json:"mqttUsername"`
+	MqttPassword            *string             `json:"mqttPassword"`
+	MqttTopicPrefix         *string             `json:"mqttTopicPrefix"`
+	MqttDiscovery           *bool               `json:"mqttDiscovery"`
+	MqttDiscoveryPrefix     *string             `json:"mqttDiscoveryPrefix"`
+	Mappings                *[]ConfigMapping    `json:"mappings"`
+}
+
+// Config is the contents of a config file to check instead of the current
+// one.
+type ValidateSettingsParams struct {
+	Config *string `json:"config"`
+}
+
+type SwitchProfileParams struct {
+	Profile string `json:"profile"`
 }
 
 type NewClientParams struct {
@@ -61,3 +114,69 @@ type NewClientParams struct {
 type DeleteClientParams struct {
 	Id string `json:"id"`
 }
+
+type AddWebhookParams struct {
+	Label   string   `json:"label"`
+	Enabled bool     `json:"enabled"`
+	Url     string   `json:"url"`
+	Secret  *string  `json:"secret"`
+	Events  []string `json:"events"`
+}
+
+type UpdateWebhookParams struct {
+	Id      int       `json:"id"`
+	Label   *string   `json:"label"`
+	Enabled *bool     `json:"enabled"`
+	Url     *string   `json:"url"`
+	Secret  *string   `json:"secret"`
+	Events  *[]string `json:"events"`
+}
+
+type WebhookIdParams struct {
+	Id int `json:"id"`
+}
+
+type StatsParams struct {
+	Since *time.Time `json:"since"`
+	Limit *int       `json:"limit"`
+}
+
+type PlaylistItemParams struct {
+	Name      string `json:"name"`
+	ZapScript string `json:"zapscript"`
+}
+
+// Advance is in minutes.
+type PlaylistOptionsParams struct {
+	Shuffle       *bool    `json:"shuffle"`
+	Repeat        *string  `json:"repeat"`
+	Advance       *float64 `json:"advance"`
+	AdvanceOnStop *bool    `json:"advanceOnStop"`
+}
+
+type AddPlaylistParams struct {
+	Name  string                `json:"name"`
+	Items *[]PlaylistItemParams `json:"items"`
+	// Import items from a playlist file or folder instead.
+	Path *string `json:"path"`
+	PlaylistOptionsParams
+}
+
+type UpdatePlaylistParams struct {
+	Id    int                   `json:"id"`
+	Name  *string               `json:"name"`
+	Items *[]PlaylistItemParams `json:"items"`
+	PlaylistOptionsParams
+}
+
+type DeletePlaylistParams struct {
+	Id int `json:"id"`
+}
+
+type PlayPlaylistParams struct {
+	Id    *int    `json:"id"`
+	Name  *string `json:"name"`
+	Index *int    `json:"index"`
+	// Override the playlist's saved options.
+	PlaylistOptionsParams
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/ZaparooProject/zaparoo-core/pkg/config"
 	"github.com/ZaparooProject/zaparoo-core/pkg/database"
 	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/playlists"
 	"github.com/ZaparooProject/zaparoo-core/pkg/service/state"
 	"github.com/ZaparooProject/zaparoo-core/pkg/service/tokens"
 	"github.com/google/uuid"
@@ -15,7 +16,10 @@ type RequestEnv struct {
 	State      *state.State
 	Database   *database.Database
 	TokenQueue chan<- tokens.Token
-	IsLocal    bool
-	Id         uuid.UUID
-	Params     []byte
+	// TODO: move with the token queue to a shared service struct
+	PlaylistQueue chan<- *playlists.Playlist
+	IsLocal       bool
+	ClientIp      string
+	Id            uuid.UUID
+	Params        []byte
 }
@@ -15,14 +15,68 @@ type SearchResults struct {
 	Total   int                 `json:"total"`
 }
 
+// The MQTT password is never returned, only if one is set. Mappings are the
+// ones from the config file, not the mappings directory.
 type SettingsResponse struct {
-	RunZapScript            bool     `json:"runZapScript"`
-	DebugLogging            bool     `json:"debugLogging"`
-	AudioScanFeedback       bool     `json:"audioScanFeedback"`
-	ReadersAutoDetect       bool     `json:"readersAutoDetect"`
-	ReadersScanMode         string   `json:"readersScanMode"`
-	ReadersScanExitDelay    float32  `json:"readersScanExitDelay"`
-	ReadersScanIgnoreSystem []string `json:"readersScanIgnoreSystems"`
+	Profile                 string             `json:"profile"`
+	RunZapScript            bool               `json:"runZapScript"`
+	DebugLogging            bool               `json:"debugLogging"`
+	AudioScanFeedback       bool               `json:"audioScanFeedback"`
+	AudioVolume             int                `json:"audioVolume"`
+	AudioSounds             map[string]string  `json:"audioSounds"`
+	NoticesEnabled          bool               `json:"noticesEnabled"`
+	NoticesDuration         float32            `json:"noticesDuration"`
+	ReadersAutoDetect       bool               `json:"readersAutoDetect"`
+	ReadersScanMode         string             `json:"readersScanMode"`
+	ReadersScanExitDelay    float32            `json:"readersScanExitDelay"`
+	ReadersScanIgnoreSystem []string           `json:"readersScanIgnoreSystems"`
+	ReadersConnect          []ReaderConnection `json:"readersConnect"`
+	SystemsDefault          []SystemDefault    `json:"systemsDefault"`
+	LaunchersDefault        []LauncherDefault  `json:"launchersDefault"`
+	LaunchersCustom         []CustomLauncher   `json:"launchersCustom"`
+	LaunchersRetroArch      RetroArchSettings  `json:"launchersRetroArch"`
+	LaunchersIndexRoot      []string           `json:"launchersIndexRoot"`
+	LaunchersAllowFile      []string           `json:"launchersAllowFile"`
+	ZapScriptAllowExecute   []string           `json:"zapScriptAllowExecute"`
+	ServiceApiPort          int                `json:"serviceApiPort"`
+	ServiceDeviceId         string             `json:"serviceDeviceId"`
+	ServiceAllowRun         []string           `json:"serviceAllowRun"`
+	HistoryRetentionDays    int                `json:"historyRetentionDays"`
+	HistoryMaxEntries       int                `json:"historyMaxEntries"`
+	MqttEnabled             bool               `json:"mqttEnabled"`
+	MqttBroker              string             `json:"mqttBroker"`
+	MqttUsername            string             `json:"mqttUsername"`
+	MqttPasswordSet         bool               `json:"mqttPasswordSet"`
+	MqttTopicPrefix         string             `json:"mqttTopicPrefix"`
+	MqttDiscovery           bool               `json:"mqttDiscovery"`
+	MqttDiscoveryPrefix     string             `json:"mqttDiscoveryPrefix"`
+	Mappings                []ConfigMapping    `json:"mappings"`
+}
+
+// Sections are the names of the top level config sections which changed.
+type SettingsChangedResponse struct {
+	Sections []string `json:"sections"`
+}
+
+type ProfilesResponse struct {
+	Profiles []string `json:"profiles"`
+	Active   string   `json:"active"`
+}
+
+type ProfileChangedResponse struct {
+	Profile string `json:"profile"`
+}
+
+type SettingsIssue struct {
+	Line     int    `json:"line"`
+	Key      string `json:"key"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+}
+
+type ValidateSettingsResponse struct {
+	Valid  bool            `json:"valid"`
+	Issues []SettingsIssue `json:"issues"`
 }
 
 type System struct {
@@ -36,16 +90,25 @@ type SystemsResponse struct {
 }
 
 type HistoryReponseEntry struct {
-	Time    time.Time `json:"time"`
-	Type    string    `json:"type"`
-	UID     string    `json:"uid"`
-	Text    string    `json:"text"`
-	Data    string    `json:"data"`
-	Success bool      `json:"success"`
+	Id      string       `json:"id"`
+	Time    time.Time    `json:"time"`
+	Type    string       `json:"type"`
+	UID     string       `json:"uid"`
+	Text    string       `json:"text"`
+	Data    string       `json:"data"`
+	Source  string       `json:"source"`
+	Success bool         `json:"success"`
+	Error   *LaunchError `json:"error,omitempty"`
 }
 
 type HistoryResponse struct {
 	Entries []HistoryReponseEntry `json:"entries"`
+	Next    *string               `json:"next,omitempty"`
+}
+
+type HistoryExportResponse struct {
+	Format string `json:"format"`
+	Data   string `json:"data"`
 }
 
 type AllMappingsResponse struct {
@@ -99,6 +162,21 @@ type VersionResponse struct {
 	Platform string `json:"platform"`
 }
 
+type UpdateCheckResponse struct {
+	CurrentVersion string `json:"currentVersion"`
+	LatestVersion  string `json:"latestVersion"`
+	Available      bool   `json:"available"`
+	Notes          string `json:"notes,omitempty"`
+}
+
+// Restarting is false if Core must be restarted by the user to run the
+// new version.
+type UpdateApplyResponse struct {
+	PreviousVersion string `json:"previousVersion"`
+	Version         string `json:"version"`
+	Restarting      bool   `json:"restarting"`
+}
+
 type MediaResponse struct {
 	Database IndexResponse     `json:"database"`
 	Active   []PlayingResponse `json:"active"`
@@ -108,3 +186,131 @@ type TokensResponse struct {
 	Active []TokenResponse `json:"active"`
 	Last   *TokenResponse  `json:"last,omitempty"`
 }
+
+type WebhookDeliveryResponse struct {
+	Id         string    `json:"id"`
+	Time       time.Time `json:"time"`
+	Event      string    `json:"event"`
+	Attempts   int       `json:"attempts"`
+	Success    bool      `json:"success"`
+	StatusCode int       `json:"statusCode"`
+	Error      string    `json:"error"`
+}
+
+// Secret is masked, it's only sent in full when the webhook is added.
+type WebhookResponse struct {
+	Id           string                   `json:"id"`
+	Added        string                   `json:"added"`
+	Label        string                   `json:"label"`
+	Enabled      bool                     `json:"enabled"`
+	Url          string                   `json:"url"`
+	Secret       string                   `json:"secret"`
+	Events       []string                 `json:"events"`
+	LastDelivery *WebhookDeliveryResponse `json:"lastDelivery,omitempty"`
+}
+
+type AddWebhookResponse struct {
+	Id     string `json:"id"`
+	Secret string `json:"secret"`
+}
+
+type AllWebhooksResponse struct {
+	Webhooks []WebhookResponse `json:"webhooks"`
+}
+
+type WebhookDeliveriesResponse struct {
+	Deliveries []WebhookDeliveryResponse `json:"deliveries"`
+}
+
+type StatsMediaEntry struct {
+	SystemId     string    `json:"systemId"`
+	SystemName   string    `json:"systemName"`
+	MediaName    string    `json:"mediaName"`
+	MediaPath    string    `json:"mediaPath"`
+	Sessions     int       `json:"sessions"`
+	TotalSeconds int64     `json:"totalSeconds"`
+	LastPlayed   time.Time `json:"lastPlayed"`
+}
+
+type StatsMediaResponse struct {
+	Media []StatsMediaEntry `json:"media"`
+}
+
+type StatsSystemEntry struct {
+	SystemId     string `json:"systemId"`
+	SystemName   string `json:"systemName"`
+	Sessions     int    `json:"sessions"`
+	TotalSeconds int64  `json:"totalSeconds"`
+}
+
+type StatsSystemsResponse struct {
+	Systems []StatsSystemEntry `json:"systems"`
+}
+
+type StatsSessionEntry struct {
+	Id         string     `json:"id"`
+	Start      time.Time  `json:"start"`
+	End        *time.Time `json:"end,omitempty"`
+	Seconds    int64      `json:"seconds"`
+	SystemId   string     `json:"systemId"`
+	SystemName string     `json:"systemName"`
+	MediaName  string     `json:"mediaName"`
+	MediaPath  string     `json:"mediaPath"`
+	LauncherId string     `json:"launcherId"`
+	TokenType  string     `json:"tokenType"`
+	TokenUID   string     `json:"tokenUid"`
+	TokenText  string     `json:"tokenText"`
+}
+
+type StatsSessionsResponse struct {
+	Sessions []StatsSessionEntry `json:"sessions"`
+}
+
+type StatsTokenEntry struct {
+	Type         string    `json:"type"`
+	UID          string    `json:"uid"`
+	Text         string    `json:"text"`
+	Sessions     int       `json:"sessions"`
+	TotalSeconds int64     `json:"totalSeconds"`
+	LastPlayed   time.Time `json:"lastPlayed"`
+}
+
+type StatsTokensResponse struct {
+	Tokens []StatsTokenEntry `json:"tokens"`
+}
+
+type PlaylistItemResponse struct {
+	Name      string `json:"name"`
+	ZapScript string `json:"zapscript"`
+}
+
+type PlaylistResponse struct {
+	Id            string                 `json:"id"`
+	Added         string                 `json:"added"`
+	Name          string                 `json:"name"`
+	Items         []PlaylistItemResponse `json:"items"`
+	Shuffle       bool                   `json:"shuffle"`
+	Repeat        string                 `json:"repeat"`
+	Advance       float64                `json:"advance"`
+	AdvanceOnStop bool                   `json:"advanceOnStop"`
+}
+
+type AllPlaylistsResponse struct {
+	Playlists []PlaylistResponse `json:"playlists"`
+}
+
+// Index is the index in Items of the current item and Order lists item
+// indexes in the order they will be played, starting from Position. Advance
+// is in minutes.
+type ActivePlaylistResponse struct {
+	Active        bool                   `json:"active"`
+	Name          string                 `json:"name"`
+	Items         []PlaylistItemResponse `json:"items"`
+	Index         int                    `json:"index"`
+	Position      int                    `json:"position"`
+	Order         []int                  `json:"order"`
+	Shuffle       bool                   `json:"shuffle"`
+	Repeat        string                 `json:"repeat"`
+	Advance       float64                `json:"advance"`
+	AdvanceOnStop bool                   `json:"advanceOnStop"`
+}
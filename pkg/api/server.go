@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"github.com/ZaparooProject/zaparoo-core/pkg/api/methods"
@@ -19,6 +20,7 @@ import (
 
 	"github.com/ZaparooProject/zaparoo-core/pkg/database"
 	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/playlists"
 	"github.com/ZaparooProject/zaparoo-core/pkg/service/state"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -36,15 +38,17 @@ var methodMap = map[string]func(requests.RequestEnv) (any, error){
 	models.MethodRun:    methods.HandleRun,
 	models.MethodStop:   methods.HandleStop,
 	// tokens
-	models.MethodTokens:  methods.HandleTokens,
-	models.MethodHistory: methods.HandleHistory,
+	models.MethodTokens:        methods.HandleTokens,
+	models.MethodHistory:       methods.HandleHistory,
+	models.MethodHistoryExport: methods.HandleHistoryExport,
 	// media
 	models.MethodMedia:       methods.HandleMedia,
 	models.MethodMediaIndex:  methods.HandleIndexMedia,
 	models.MethodMediaSearch: methods.HandleGames,
 	// settings
-	models.MethodSettings:       methods.HandleSettings,
-	models.MethodSettingsUpdate: methods.HandleSettingsUpdate,
+	models.MethodSettings:         methods.HandleSettings,
+	models.MethodSettingsUpdate:   methods.HandleSettingsUpdate,
+	models.MethodSettingsValidate: methods.HandleSettingsValidate,
 	// systems
 	models.MethodSystems: methods.HandleSystems,
 	// mappings
@@ -53,10 +57,38 @@ var methodMap = map[string]func(requests.RequestEnv) (any, error){
 	models.MethodMappingsDelete: methods.HandleDeleteMapping,
 	models.MethodMappingsUpdate: methods.HandleUpdateMapping,
 	models.MethodMappingsReload: methods.HandleReloadMappings,
+	// webhooks
+	models.MethodWebhooks:           methods.HandleWebhooks,
+	models.MethodWebhooksNew:        methods.HandleAddWebhook,
+	models.MethodWebhooksDelete:     methods.HandleDeleteWebhook,
+	models.MethodWebhooksUpdate:     methods.HandleUpdateWebhook,
+	models.MethodWebhooksDeliveries: methods.HandleWebhookDeliveries,
+	models.MethodWebhooksTest:       methods.HandleTestWebhook,
+	// playlists
+	models.MethodPlaylists:         methods.HandlePlaylists,
+	models.MethodPlaylistsNew:      methods.HandleAddPlaylist,
+	models.MethodPlaylistsUpdate:   methods.HandleUpdatePlaylist,
+	models.MethodPlaylistsDelete:   methods.HandleDeletePlaylist,
+	models.MethodPlaylistsPlay:     methods.HandlePlayPlaylist,
+	models.MethodPlaylistsActive:   methods.HandleActivePlaylist,
+	models.MethodPlaylistsNext:     methods.HandleNextPlaylist,
+	models.MethodPlaylistsPrevious: methods.HandlePreviousPlaylist,
+	models.MethodPlaylistsStop:     methods.HandleStopPlaylist,
+	// profiles
+	models.MethodProfiles:       methods.HandleProfiles,
+	models.MethodProfilesSwitch: methods.HandleSwitchProfile,
+	// stats
+	models.MethodStatsMedia:    methods.HandleStatsMedia,
+	models.MethodStatsSystems:  methods.HandleStatsSystems,
+	models.MethodStatsSessions: methods.HandleStatsSessions,
+	models.MethodStatsTokens:   methods.HandleStatsTokens,
 	// readers
 	models.MethodReadersWrite: methods.HandleReaderWrite,
 	// utils
 	models.MethodVersion: methods.HandleVersion,
+	// update
+	models.MethodUpdateCheck: methods.HandleUpdateCheck,
+	models.MethodUpdateApply: methods.HandleUpdateApply,
 }
 
 func handleRequest(env requests.RequestEnv, req models.RequestObject) (any, error) {
@@ -145,6 +177,7 @@ func Start(
 	cfg *config.Instance,
 	st *state.State,
 	itq chan<- tokens.Token,
+	plq chan<- *playlists.Playlist,
 	db *database.Database,
 	ns <-chan models.Notification,
 ) {
@@ -251,12 +284,14 @@ func Start(
 			log.Debug().IPAddr("ip", clientIp).Msg("parsed ip")
 
 			resp, err := handleRequest(requests.RequestEnv{
-				Platform:   pl,
-				Config:     cfg,
-				State:      st,
-				Database:   db,
-				TokenQueue: itq,
-				IsLocal:    clientIp.IsLoopback(),
+				Platform:      pl,
+				Config:        cfg,
+				State:         st,
+				Database:      db,
+				TokenQueue:    itq,
+				PlaylistQueue: plq,
+				IsLocal:       clientIp.IsLoopback(),
+				ClientIp:      clientIp.String(),
 			}, req)
 			if err != nil {
 				err := sendError(s, *req.Id, 1, err.Error())
@@ -297,8 +332,45 @@ func Start(
 		http.Redirect(w, r, "/app/", http.StatusFound)
 	})
 
-	err := http.ListenAndServe(":"+strconv.Itoa(cfg.ApiPort()), r)
-	if err != nil {
-		log.Error().Err(err).Msg("error starting http server")
+	// restart the server on the new port if it's changed in the config
+	for !st.ShouldStopService() {
+		port := cfg.ApiPort()
+		srv := &http.Server{
+			Addr:    ":" + strconv.Itoa(port),
+			Handler: r,
+		}
+
+		serveErr := make(chan error, 1)
+		go func() {
+			serveErr <- srv.ListenAndServe()
+		}()
+
+		log.Info().Msgf("api server listening on port: %d", port)
+
+	serve:
+		for {
+			select {
+			case err := <-serveErr:
+				log.Error().Err(err).Msg("error starting http server")
+				// wait for the port to change before trying again
+				for cfg.ApiPort() == port && !st.ShouldStopService() {
+					time.Sleep(500 * time.Millisecond)
+				}
+				break serve
+			case <-time.After(500 * time.Millisecond):
+				if cfg.ApiPort() == port && !st.ShouldStopService() {
+					continue
+				}
+
+				log.Info().Msg("stopping http server")
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				err := srv.Shutdown(ctx)
+				cancel()
+				if err != nil {
+					log.Error().Err(err).Msg("error stopping http server")
+				}
+				break serve
+			}
+		}
 	}
 }
@@ -0,0 +1,180 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/assets"
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+	"github.com/rs/zerolog/log"
+)
+
+// Sounds played for events which haven't been given one by the user. Events
+// missing here are silent by default.
+var defaultSounds = map[string][]byte{
+	config.SoundScan:          assets.SuccessSound,
+	config.SoundScanFail:      assets.FailSound,
+	config.SoundLaunchFail:    assets.FailSound,
+	config.SoundWriteComplete: assets.SuccessSound,
+}
+
+// legacySounds are the files in the assets folder which MiSTer played before
+// events had their own sounds. They may have been replaced by the user, so
+// they're still played in place of the defaults.
+var legacySounds = map[string]string{
+	config.SoundScan:          "success.wav",
+	config.SoundScanFail:      "fail.wav",
+	config.SoundLaunchFail:    "fail.wav",
+	config.SoundWriteComplete: "success.wav",
+}
+
+// SoundsPath returns the folder in the data directory which sounds can be
+// added to. A <event>.wav or <event>.ogg file there replaces an event's
+// default sound.
+func SoundsPath(pl platforms.Platform) string {
+	return filepath.Join(pl.DataDir(), platforms.SoundsDir)
+}
+
+// IsEvent returns true if the name is an event which can play a sound.
+func IsEvent(name string) bool {
+	return slices.Contains(config.SoundEvents, name)
+}
+
+// Feedback plays the sound for an event if audio feedback is enabled.
+// Errors are only logged, feedback is never important enough to stop for.
+func Feedback(pl platforms.Platform, cfg *config.Instance, event string) {
+	if !cfg.AudioFeedback() {
+		return
+	}
+
+	err := PlayEvent(pl, cfg, event)
+	if err != nil {
+		log.Warn().Err(err).Msgf("error playing sound: %s", event)
+	}
+}
+
+// PlayEvent plays the sound for an event. The sound set in the config is
+// used first, then one from the sounds folder, then a legacy MiSTer asset
+// and then the default.
+func PlayEvent(pl platforms.Platform, cfg *config.Instance, event string) error {
+	if !IsEvent(event) {
+		return fmt.Errorf("unknown sound event: %s", event)
+	}
+
+	if path, ok := cfg.LookupSound(event); ok {
+		if path == "" {
+			return nil
+		}
+		return PlayFile(pl, cfg, path)
+	}
+
+	if path := eventFile(pl, event); path != "" {
+		return PlayFile(pl, cfg, path)
+	}
+
+	data, ok := defaultSounds[event]
+	if !ok {
+		return nil
+	}
+
+	return play(pl, data, cfg.AudioVolume())
+}
+
+// eventFile returns the sound file for an event from the sounds folder, or
+// the legacy assets folder, if one exists.
+func eventFile(pl platforms.Platform, event string) string {
+	paths := []string{
+		filepath.Join(SoundsPath(pl), event+".wav"),
+		filepath.Join(SoundsPath(pl), event+".ogg"),
+	}
+	if name, ok := legacySounds[event]; ok {
+		paths = append(paths, filepath.Join(pl.DataDir(), platforms.AssetsDir, name))
+	}
+
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	return ""
+}
+
+// PlayFile plays a WAV or Ogg file at the configured volume. Relative paths
+// are in the sounds folder.
+func PlayFile(pl platforms.Platform, cfg *config.Instance, path string) error {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(SoundsPath(pl), path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if !IsWav(data) && !IsOgg(data) {
+		return fmt.Errorf("unsupported sound file: %s", path)
+	}
+
+	return play(pl, data, cfg.AudioVolume())
+}
+
+// decode reads a WAV or Ogg Vorbis file.
+func decode(data []byte) (*Wav, error) {
+	if IsOgg(data) {
+		return DecodeOgg(data)
+	}
+	return DecodeWav(data)
+}
+
+// play plays WAV or Ogg data, scaled to the volume. The sound is decoded
+// and written to the temp directory as a WAV file, where it's reused until
+// the service restarts.
+func play(pl platforms.Platform, data []byte, volume int) error {
+	if volume <= 0 {
+		return nil
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write(data)
+	path := filepath.Join(
+		pl.TempDir(),
+		platforms.SoundsDir,
+		fmt.Sprintf("%x-%d.wav", h.Sum64(), volume),
+	)
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		w, err := decode(data)
+		if err != nil {
+			return err
+		}
+		w.SetVolume(volume)
+
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return err
+		}
+
+		// written then moved so a player never sees a partial file
+		f, err := os.CreateTemp(filepath.Dir(path), "*.tmp")
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(w.Encode())
+		_ = f.Close()
+		if err == nil {
+			err = os.Rename(f.Name(), path)
+		}
+		if err != nil {
+			_ = os.Remove(f.Name())
+			return err
+		}
+	}
+
+	return run(players(), path, 100)
+}
@@ -0,0 +1,58 @@
+package audio
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+)
+
+type dataPlatform struct {
+	platforms.Platform
+	dataDir string
+}
+
+func (p dataPlatform) DataDir() string {
+	return p.dataDir
+}
+
+func TestEventFile(t *testing.T) {
+	pl := dataPlatform{dataDir: t.TempDir()}
+
+	write := func(dir, name string) string {
+		err := os.MkdirAll(dir, 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, name)
+		err = os.WriteFile(path, []byte{}, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	if path := eventFile(pl, config.SoundScan); path != "" {
+		t.Errorf("expected no file, got %s", path)
+	}
+
+	legacy := write(filepath.Join(pl.dataDir, platforms.AssetsDir), "success.wav")
+	if path := eventFile(pl, config.SoundScan); path != legacy {
+		t.Errorf("expected legacy asset %s, got %s", legacy, path)
+	}
+	if path := eventFile(pl, config.SoundScanFail); path != "" {
+		t.Errorf("expected no file for scan fail, got %s", path)
+	}
+
+	ogg := write(SoundsPath(pl), config.SoundScan+".ogg")
+	if path := eventFile(pl, config.SoundScan); path != ogg {
+		t.Errorf("expected %s, got %s", ogg, path)
+	}
+
+	wav := write(SoundsPath(pl), config.SoundScan+".wav")
+	if path := eventFile(pl, config.SoundScan); path != wav {
+		t.Errorf("expected %s, got %s", wav, path)
+	}
+}
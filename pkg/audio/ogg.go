@@ -0,0 +1,56 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/jfreymuth/oggvorbis"
+)
+
+// IsOgg returns true if the data starts with an Ogg page containing a Vorbis
+// stream header.
+func IsOgg(data []byte) bool {
+	if len(data) < 27 || string(data[0:4]) != "OggS" {
+		return false
+	}
+
+	// the first packet follows the page header and its segment table
+	start := 27 + int(data[26])
+	if len(data) < start+7 {
+		return false
+	}
+
+	return string(data[start:start+7]) == "\x01vorbis"
+}
+
+// DecodeOgg decodes an Ogg Vorbis file to 16-bit PCM, so it can be played
+// by the same players as a WAV file.
+func DecodeOgg(data []byte) (*Wav, error) {
+	if !IsOgg(data) {
+		return nil, errors.New("not an ogg vorbis file")
+	}
+
+	samples, format, err := oggvorbis.ReadAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if format.Channels == 0 {
+		return nil, errors.New("ogg file has no channels")
+	}
+
+	pcm := make([]byte, len(samples)*2)
+	for i, s := range samples {
+		v := int16(math.Round(math.Max(-1, math.Min(1, float64(s))) * math.MaxInt16))
+		binary.LittleEndian.PutUint16(pcm[i*2:], uint16(v))
+	}
+
+	return &Wav{
+		Format:        wavFormatPcm,
+		Channels:      uint16(format.Channels),
+		SampleRate:    uint32(format.SampleRate),
+		BitsPerSample: 16,
+		Data:          pcm,
+	}, nil
+}
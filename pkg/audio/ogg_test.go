@@ -0,0 +1,62 @@
+package audio
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/assets"
+)
+
+func TestIsOgg(t *testing.T) {
+	page := make([]byte, 28)
+	copy(page, "OggS")
+	page[26] = 1
+	page[27] = 30
+
+	if !IsOgg(append(append([]byte{}, page...), "\x01vorbis\x00\x00\x00\x00"...)) {
+		t.Error("vorbis stream not detected")
+	}
+
+	if IsOgg(append(append([]byte{}, page...), "OpusHead"...)) {
+		t.Error("opus stream detected as vorbis")
+	}
+
+	if IsOgg(append(append([]byte{}, page...), "\x80theora"...)) {
+		t.Error("theora stream detected as audio")
+	}
+
+	if IsOgg(assets.SuccessSound) {
+		t.Error("wav detected as ogg")
+	}
+}
+
+func TestDecodeOgg(t *testing.T) {
+	data, err := os.ReadFile("testdata/test.ogg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := DecodeOgg(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Format != wavFormatPcm || w.BitsPerSample != 16 {
+		t.Errorf("expected 16-bit pcm, got format %d, %d bits", w.Format, w.BitsPerSample)
+	}
+	if w.Channels == 0 || w.SampleRate == 0 || len(w.Data) == 0 {
+		t.Errorf("missing format or data: %d channels, %d Hz", w.Channels, w.SampleRate)
+	}
+
+	rw, err := DecodeWav(w.Encode())
+	if err != nil {
+		t.Fatalf("decoding encoded wav: %s", err)
+	}
+	if len(rw.Data) != len(w.Data) {
+		t.Errorf("encoded wav has %d bytes of data, expected %d", len(rw.Data), len(w.Data))
+	}
+
+	if _, err := DecodeOgg(assets.SuccessSound); err == nil {
+		t.Error("expected error for wav data")
+	}
+}
@@ -0,0 +1,136 @@
+package audio
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+var ErrNoPlayer = errors.New("no audio player found")
+
+// player is an external program sound files are played with.
+type player struct {
+	name string
+	// args returns the arguments to play a file at a volume from 0 to 100.
+	// Sounds are always played at full volume, they're scaled before.
+	args func(path string, volume int) []string
+}
+
+var mpvPlayer = player{
+	name: "mpv",
+	args: func(path string, volume int) []string {
+		return []string{
+			"--no-video",
+			"--really-quiet",
+			"--volume=" + strconv.Itoa(volume),
+			path,
+		}
+	},
+}
+
+var ffplayPlayer = player{
+	name: "ffplay",
+	args: func(path string, volume int) []string {
+		return []string{
+			"-nodisp",
+			"-autoexit",
+			"-loglevel", "quiet",
+			"-volume", strconv.Itoa(volume),
+			path,
+		}
+	},
+}
+
+var paplayPlayer = player{
+	name: "paplay",
+	args: func(path string, volume int) []string {
+		return []string{
+			"--volume=" + strconv.Itoa(volume*65536/100),
+			path,
+		}
+	},
+}
+
+var pwPlayPlayer = player{
+	name: "pw-play",
+	args: func(path string, volume int) []string {
+		return []string{
+			"--volume=" + strconv.FormatFloat(float64(volume)/100, 'f', 2, 64),
+			path,
+		}
+	},
+}
+
+var aplayPlayer = player{
+	name: "aplay",
+	args: func(path string, _ int) []string {
+		return []string{"-q", path}
+	},
+}
+
+var afplayPlayer = player{
+	name: "afplay",
+	args: func(path string, volume int) []string {
+		return []string{
+			"-v", strconv.FormatFloat(float64(volume)/100, 'f', 2, 64),
+			path,
+		}
+	},
+}
+
+var soundPlayerPlayer = player{
+	name: "powershell",
+	args: func(path string, _ int) []string {
+		path = strings.ReplaceAll(path, "'", "''")
+		return []string{
+			"-NoProfile",
+			"-NonInteractive",
+			"-Command",
+			"(New-Object Media.SoundPlayer '" + path + "').PlaySync()",
+		}
+	},
+}
+
+// players returns the audio players for the OS in order of preference.
+func players() []player {
+	switch runtime.GOOS {
+	case "windows":
+		return []player{soundPlayerPlayer, mpvPlayer, ffplayPlayer}
+	case "darwin":
+		return []player{afplayPlayer, mpvPlayer, ffplayPlayer}
+	default:
+		// aplay is the only player available on MiSTer
+		return []player{paplayPlayer, pwPlayPlayer, aplayPlayer, mpvPlayer, ffplayPlayer}
+	}
+}
+
+// run plays a WAV file with the first player available. The player is left
+// running in the background.
+func run(ps []player, path string, volume int) error {
+	for _, p := range ps {
+		bin, err := exec.LookPath(p.name)
+		if err != nil {
+			continue
+		}
+
+		cmd := exec.Command(bin, p.args(path, volume)...)
+		err = cmd.Start()
+		if err != nil {
+			return err
+		}
+		go func() {
+			err := cmd.Wait()
+			if err != nil {
+				log.Debug().Err(err).Msgf("error playing sound with %s", p.name)
+			}
+		}()
+
+		return nil
+	}
+
+	return ErrNoPlayer
+}
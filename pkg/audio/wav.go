@@ -0,0 +1,169 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	wavFormatPcm        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xfffe
+)
+
+// Wav is a decoded WAV file. Samples are interleaved and left in the
+// file's encoding, so it can be written back out unchanged.
+type Wav struct {
+	Format        uint16
+	Channels      uint16
+	SampleRate    uint32
+	BitsPerSample uint16
+	Data          []byte
+}
+
+// IsWav returns true if the data starts with a RIFF WAVE header.
+func IsWav(data []byte) bool {
+	return len(data) >= 12 &&
+		string(data[0:4]) == "RIFF" &&
+		string(data[8:12]) == "WAVE"
+}
+
+// DecodeWav reads the format and sample data of a WAV file. Integer PCM of
+// 8 to 32 bits and 32-bit float samples are supported.
+func DecodeWav(data []byte) (*Wav, error) {
+	if !IsWav(data) {
+		return nil, errors.New("not a wav file")
+	}
+
+	w := &Wav{}
+	hasFmt := false
+	hasData := false
+
+	pos := 12
+	for pos+8 <= len(data) {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		pos += 8
+
+		if size < 0 || pos+size > len(data) {
+			// some encoders write a bad size for the final chunk
+			if id != "data" {
+				return nil, fmt.Errorf("truncated wav chunk: %s", id)
+			}
+			size = len(data) - pos
+		}
+		chunk := data[pos : pos+size]
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, errors.New("invalid wav format chunk")
+			}
+			w.Format = binary.LittleEndian.Uint16(chunk[0:2])
+			w.Channels = binary.LittleEndian.Uint16(chunk[2:4])
+			w.SampleRate = binary.LittleEndian.Uint32(chunk[4:8])
+			w.BitsPerSample = binary.LittleEndian.Uint16(chunk[14:16])
+			if w.Format == wavFormatExtensible && size >= 26 {
+				// the real format is the start of the sub-format GUID
+				w.Format = binary.LittleEndian.Uint16(chunk[24:26])
+			}
+			hasFmt = true
+		case "data":
+			w.Data = chunk
+			hasData = true
+		}
+
+		// chunks are padded to an even size
+		pos += size + size%2
+	}
+
+	if !hasFmt || !hasData {
+		return nil, errors.New("wav file is missing format or data")
+	}
+
+	switch {
+	case w.Format == wavFormatPcm && w.BitsPerSample%8 == 0 &&
+		w.BitsPerSample >= 8 && w.BitsPerSample <= 32:
+	case w.Format == wavFormatFloat && w.BitsPerSample == 32:
+	default:
+		return nil, fmt.Errorf(
+			"unsupported wav encoding: format %d, %d bits",
+			w.Format,
+			w.BitsPerSample,
+		)
+	}
+
+	if w.Channels == 0 {
+		return nil, errors.New("wav file has no channels")
+	}
+
+	return w, nil
+}
+
+// Encode writes the WAV back out as a plain RIFF file.
+func (w *Wav) Encode() []byte {
+	blockAlign := w.Channels * (w.BitsPerSample / 8)
+	size := len(w.Data)
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("RIFF")
+	_ = binary.Write(buf, binary.LittleEndian, uint32(36+size+size%2))
+	buf.WriteString("WAVE")
+
+	buf.WriteString("fmt ")
+	_ = binary.Write(buf, binary.LittleEndian, uint32(16))
+	_ = binary.Write(buf, binary.LittleEndian, w.Format)
+	_ = binary.Write(buf, binary.LittleEndian, w.Channels)
+	_ = binary.Write(buf, binary.LittleEndian, w.SampleRate)
+	_ = binary.Write(buf, binary.LittleEndian, w.SampleRate*uint32(blockAlign))
+	_ = binary.Write(buf, binary.LittleEndian, blockAlign)
+	_ = binary.Write(buf, binary.LittleEndian, w.BitsPerSample)
+
+	buf.WriteString("data")
+	_ = binary.Write(buf, binary.LittleEndian, uint32(size))
+	buf.Write(w.Data)
+	if size%2 == 1 {
+		buf.WriteByte(0)
+	}
+
+	return buf.Bytes()
+}
+
+// SetVolume scales every sample by the volume, from 0 to 100. Samples are
+// modified in place.
+func (w *Wav) SetVolume(volume int) {
+	if volume >= 100 {
+		return
+	}
+	scale := math.Max(float64(volume), 0) / 100
+
+	width := int(w.BitsPerSample / 8)
+	for i := 0; i+width <= len(w.Data); i += width {
+		s := w.Data[i : i+width]
+
+		if w.Format == wavFormatFloat {
+			f := math.Float32frombits(binary.LittleEndian.Uint32(s))
+			binary.LittleEndian.PutUint32(s, math.Float32bits(f*float32(scale)))
+			continue
+		}
+
+		switch width {
+		case 1:
+			// 8-bit samples are unsigned, centred on 128
+			s[0] = uint8(int(float64(int(s[0])-128)*scale) + 128)
+		case 2:
+			v := int16(binary.LittleEndian.Uint16(s))
+			binary.LittleEndian.PutUint16(s, uint16(int16(float64(v)*scale)))
+		case 3:
+			v := int32(uint32(s[0])<<8|uint32(s[1])<<16|uint32(s[2])<<24) >> 8
+			v = int32(float64(v) * scale)
+			s[0], s[1], s[2] = byte(v), byte(v>>8), byte(v>>16)
+		case 4:
+			v := int32(binary.LittleEndian.Uint32(s))
+			binary.LittleEndian.PutUint32(s, uint32(int32(float64(v)*scale)))
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/assets"
+)
+
+func TestDecodeWav(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"success": assets.SuccessSound,
+		"fail":    assets.FailSound,
+	} {
+		w, err := DecodeWav(data)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		if w.Channels == 0 || w.SampleRate == 0 || len(w.Data) == 0 {
+			t.Errorf("%s: missing format or data: %+v", name, *w)
+		}
+
+		rw, err := DecodeWav(w.Encode())
+		if err != nil {
+			t.Fatalf("%s: decoding encoded wav: %s", name, err)
+		}
+
+		if rw.Format != w.Format || rw.Channels != w.Channels ||
+			rw.SampleRate != w.SampleRate || rw.BitsPerSample != w.BitsPerSample ||
+			!bytes.Equal(rw.Data, w.Data) {
+			t.Errorf("%s: encoded wav doesn't match", name)
+		}
+	}
+
+	if _, err := DecodeWav([]byte("not a wav file")); err == nil {
+		t.Error("expected error for invalid data")
+	}
+}
+
+func TestSetVolume(t *testing.T) {
+	samples := []int16{1000, -1000, 32767, -32768, 0}
+	data := make([]byte, len(samples)*2)
+	for i, s := range samples {
+		binary.LittleEndian.PutUint16(data[i*2:], uint16(s))
+	}
+
+	w := &Wav{
+		Format:        wavFormatPcm,
+		Channels:      1,
+		SampleRate:    44100,
+		BitsPerSample: 16,
+		Data:          data,
+	}
+	w.SetVolume(50)
+
+	want := []int16{500, -500, 16383, -16384, 0}
+	for i, s := range want {
+		got := int16(binary.LittleEndian.Uint16(w.Data[i*2:]))
+		if got != s {
+			t.Errorf("sample %d = %d, want %d", i, got, s)
+		}
+	}
+
+	w8 := &Wav{
+		Format:        wavFormatPcm,
+		Channels:      1,
+		SampleRate:    8000,
+		BitsPerSample: 8,
+		Data:          []byte{228, 28, 128},
+	}
+	w8.SetVolume(0)
+
+	if !bytes.Equal(w8.Data, []byte{128, 128, 128}) {
+		t.Errorf("8-bit samples not silenced: %v", w8.Data)
+	}
+}
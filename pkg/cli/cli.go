@@ -14,6 +14,7 @@ import (
 	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
 	"github.com/ZaparooProject/zaparoo-core/pkg/config"
 	"github.com/ZaparooProject/zaparoo-core/pkg/configui"
+	"github.com/ZaparooProject/zaparoo-core/pkg/database/gamesdb"
 	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
 	"github.com/ZaparooProject/zaparoo-core/pkg/utils"
 	"github.com/google/uuid"
@@ -33,6 +34,9 @@ type Flags struct {
 	Qr           *bool
 	Version      *bool
 	Config       *bool
+	CheckConfig  *bool
+	Update       *bool
+	Rollback     *bool
 }
 
 // SetupFlags defines all common CLI flags between platforms.
@@ -93,6 +97,21 @@ func SetupFlags() *Flags {
 			false,
 			"start the text ui to handle zaparoo config",
 		),
+		CheckConfig: flag.Bool(
+			"check-config",
+			false,
+			"validate config file and exit",
+		),
+		Update: flag.Bool(
+			"update",
+			false,
+			"install the latest release and restart the service",
+		),
+		Rollback: flag.Bool(
+			"rollback",
+			false,
+			"restore the version replaced by the last update",
+		),
 	}
 }
 
@@ -105,6 +124,36 @@ func (f *Flags) Pre(pl platforms.Platform) {
 		fmt.Printf("Zaparoo v%s (%s)\n", config.AppVersion, pl.Id())
 		os.Exit(0)
 	}
+
+	if *f.CheckConfig {
+		checkConfig(pl)
+	}
+}
+
+// checkConfig validates the config file, prints any issues found and exits.
+// The exit code is 1 if there are any errors.
+func checkConfig(pl platforms.Platform) {
+	cfgPath := config.FilePath(pl.ConfigDir())
+
+	// readers are only created to list their driver IDs
+	opts := gamesdb.ConfigValidateOptions(pl, &config.Instance{})
+
+	issues, err := config.ValidateFile(cfgPath, opts)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error reading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, issue := range issues {
+		fmt.Println(issue.String())
+	}
+
+	if config.HasErrors(issues) {
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s: ok\n", cfgPath)
+	os.Exit(0)
 }
 
 type ConnQr struct {
@@ -121,6 +170,12 @@ func (f *Flags) Post(cfg *config.Instance, pl platforms.Platform) {
 		os.Exit(0)
 	}
 
+	if *f.Update {
+		update(cfg, pl)
+	} else if *f.Rollback {
+		rollback(pl)
+	}
+
 	if *f.Write != "" {
 		data, err := json.Marshal(&models.ReaderWriteParams{
 			Text: *f.Write,
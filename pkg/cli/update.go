@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+	"github.com/ZaparooProject/zaparoo-core/pkg/updater"
+	"github.com/rs/zerolog/log"
+)
+
+// restartAfterUpdate restarts the service so it runs the binary at binPath,
+// and exits.
+func restartAfterUpdate(pl platforms.Platform, binPath string) {
+	err := updater.Restart(pl, binPath)
+	if errors.Is(err, updater.ErrRestartUnsupported) {
+		fmt.Println("Restart Zaparoo to run the new version.")
+	} else if err != nil {
+		log.Error().Err(err).Msg("error restarting service")
+		_, _ = fmt.Fprintf(os.Stderr, "Error restarting service: %v\n", err)
+		os.Exit(1)
+	} else {
+		fmt.Println("Service restarted.")
+	}
+
+	os.Exit(0)
+}
+
+// update installs the latest release from the configured feed over the
+// running binary, keeping the replaced one for rollback, and exits.
+func update(cfg *config.Instance, pl platforms.Platform) {
+	binPath, err := updater.BinPath()
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error getting binary path: %v\n", err)
+		os.Exit(1)
+	}
+
+	status, err := updater.Check(cfg, pl.Id())
+	if err != nil {
+		log.Error().Err(err).Msg("error checking for update")
+		_, _ = fmt.Fprintf(os.Stderr, "Error checking for update: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !status.Available {
+		fmt.Printf("Zaparoo v%s is up to date.\n", status.Current)
+		os.Exit(0)
+	}
+
+	fmt.Printf("Updating Zaparoo from v%s to v%s...\n", status.Current, status.Latest)
+	err = updater.Install(status, binPath)
+	if err != nil {
+		log.Error().Err(err).Msg("error installing update")
+		_, _ = fmt.Fprintf(os.Stderr, "Error installing update: %v\n", err)
+		os.Exit(1)
+	}
+
+	restartAfterUpdate(pl, binPath)
+}
+
+// rollback restores the binary replaced by the last update and exits.
+func rollback(pl platforms.Platform) {
+	binPath, err := updater.BinPath()
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error getting binary path: %v\n", err)
+		os.Exit(1)
+	}
+
+	err = updater.Rollback(binPath)
+	if err != nil {
+		log.Error().Err(err).Msg("error rolling back update")
+		_, _ = fmt.Fprintf(os.Stderr, "Error rolling back update: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("Restored the previous version.")
+	restartAfterUpdate(pl, binPath)
+}
@@ -1,17 +1,22 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"github.com/google/uuid"
 	"github.com/pelletier/go-toml/v2"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 )
 
 const (
@@ -20,22 +25,66 @@ const (
 	AppEnv        = "ZAPAROO_APP"
 	ScanModeTap   = "tap"
 	ScanModeHold  = "hold"
+	DefaultVolume = 100
+	// Seconds notices are shown for if a duration isn't set.
+	DefaultNoticeDuration = 4
 )
 
+// Events which can play a feedback sound, used as keys of the audio.sounds
+// table.
+const (
+	SoundScan            = "scan"
+	SoundScanFail        = "scan_fail"
+	SoundLaunchSuccess   = "launch_success"
+	SoundLaunchFail      = "launch_fail"
+	SoundWriteComplete   = "write_complete"
+	SoundReaderConnected = "reader_connected"
+	SoundPlaylistAdvance = "playlist_advance"
+)
+
+var SoundEvents = []string{
+	SoundScan,
+	SoundScanFail,
+	SoundLaunchSuccess,
+	SoundLaunchFail,
+	SoundWriteComplete,
+	SoundReaderConnected,
+	SoundPlaylistAdvance,
+}
+
 type Values struct {
 	ConfigSchema int       `toml:"config_schema"`
 	DebugLogging bool      `toml:"debug_logging"`
+	Profile      string    `toml:"profile,omitempty"`
 	Audio        Audio     `toml:"audio,omitempty"`
+	Notices      Notices   `toml:"notices,omitempty"`
 	Readers      Readers   `toml:"readers,omitempty"`
 	Systems      Systems   `toml:"systems,omitempty"`
 	Launchers    Launchers `toml:"launchers,omitempty"`
 	ZapScript    ZapScript `toml:"zapscript,omitempty"`
 	Service      Service   `toml:"service,omitempty"`
+	History      History   `toml:"history,omitempty"`
+	Mqtt         Mqtt      `toml:"mqtt,omitempty"`
+	Update       Update    `toml:"update,omitempty"`
 	Mappings     Mappings  `toml:"mappings,omitempty"`
 }
 
 type Audio struct {
 	ScanFeedback bool `toml:"scan_feedback,omitempty"`
+	// Volume of feedback sounds from 0 to 100, defaults to 100.
+	Volume *int `toml:"volume,omitempty"`
+	// Sound files played for events, by event name. Relative paths are in
+	// the sounds folder of the data directory and an empty path disables an
+	// event's sound.
+	Sounds map[string]string `toml:"sounds,omitempty"`
+}
+
+// Notices are short on-screen messages about scans and errors, on platforms
+// which can show them.
+type Notices struct {
+	Enabled bool `toml:"enabled,omitempty"`
+	// Seconds each notice is shown for, where the platform allows it.
+	Duration float32 `toml:"duration,omitempty"`
 }
 
 type Readers struct {
@@ -63,14 +112,74 @@ type SystemsDefault struct {
 	System     string `toml:"system"`
 	Launcher   string `toml:"launcher,omitempty"`
 	BeforeExit string `toml:"before_exit,omitempty"`
+	LaunchOptions
+}
+
+// LaunchOptions can be set for a system or a launcher. Args, Env and Cwd
+// only apply to launchers which start a process.
+type LaunchOptions struct {
+	// Extra arguments added to the launch command.
+	Args []string `toml:"args,omitempty"`
+	// Extra environment variables for the launch command.
+	Env map[string]string `toml:"env,omitempty"`
+	// Working directory of the launch command.
+	Cwd string `toml:"cwd,omitempty"`
+	// ZapScript run before media is launched. If it fails, the media isn't
+	// launched.
+	PreLaunch string `toml:"pre_launch,omitempty"`
+	// ZapScript run after launched media has exited.
+	PostExit string `toml:"post_exit,omitempty"`
+	// Overrides readers.scan.exit_delay in hold mode.
+	ExitDelay *float32 `toml:"exit_delay,omitempty"`
 }
 
 type Launchers struct {
-	IndexRoot   []string `toml:"index_root,omitempty,multiline"`
-	AllowFile   []string `toml:"allow_file,omitempty,multiline"`
+	IndexRoot   []string           `toml:"index_root,omitempty,multiline"`
+	AllowFile   []string           `toml:"allow_file,omitempty,multiline"`
+	Default     []LaunchersDefault `toml:"default,omitempty"`
+	Custom      []LaunchersCustom  `toml:"custom,omitempty"`
+	RetroArch   RetroArch          `toml:"retroarch,omitempty"`
 	allowFileRe []*regexp.Regexp
 }
 
+type LaunchersDefault struct {
+	Launcher string `toml:"launcher"`
+	LaunchOptions
+}
+
+// LaunchersCustom is a launcher defined by the user which runs a command.
+// {path} and {name} in the command are replaced with the full path of the
+// media and its filename without extension. Media must also match the
+// launchers allow_file list to be launched.
+type LaunchersCustom struct {
+	Id         string   `toml:"id"`
+	System     string   `toml:"system,omitempty"`
+	Folders    []string `toml:"folders,omitempty,multiline"`
+	Extensions []string `toml:"extensions,omitempty,multiline"`
+	Schemes    []string `toml:"schemes,omitempty,multiline"`
+	Command    string   `toml:"command"`
+}
+
+// RetroArch configures the RetroArch launchers on platforms which use them.
+type RetroArch struct {
+	// Path to the RetroArch executable, defaults to finding it in PATH.
+	Path string `toml:"path,omitempty"`
+	// Folder containing libretro cores, defaults to the platform's.
+	CoresDir string `toml:"cores_dir,omitempty"`
+	// Folder containing .lpl playlists to index, defaults to the platform's.
+	PlaylistsDir string `toml:"playlists_dir,omitempty"`
+	// UDP port of RetroArch's network command interface.
+	CommandPort int `toml:"command_port,omitempty"`
+	// Cores to use for systems, replacing the defaults.
+	Core []RetroArchCore `toml:"core,omitempty"`
+}
+
+type RetroArchCore struct {
+	System string `toml:"system"`
+	// Core name, such as "snes9x", or the full path to a core file.
+	Core string `toml:"core"`
+}
+
 type ZapScript struct {
 	AllowExecute   []string `toml:"allow_execute,omitempty,multiline"`
 	allowExecuteRe []*regexp.Regexp
@@ -83,6 +192,31 @@ type Service struct {
 	allowRunRe []*regexp.Regexp
 }
 
+type History struct {
+	RetentionDays int `toml:"retention_days,omitempty"`
+	MaxEntries    int `toml:"max_entries,omitempty"`
+}
+
+type Mqtt struct {
+	Enabled         bool   `toml:"enabled"`
+	Broker          string `toml:"broker,omitempty"`
+	Username        string `toml:"username,omitempty"`
+	Password        string `toml:"password,omitempty"`
+	TopicPrefix     string `toml:"topic_prefix,omitempty"`
+	Discovery       bool   `toml:"discovery,omitempty"`
+	DiscoveryPrefix string `toml:"discovery_prefix,omitempty"`
+}
+
+// Update is where new releases of Core are checked for. A release is only
+// installed if the feed is signed by the public key. It can only be set in
+// the config file, not through the API.
+type Update struct {
+	// URL of the release feed, updates are disabled if it's not set.
+	FeedUrl string `toml:"feed_url,omitempty"`
+	// Base64 encoded Ed25519 key the feed is signed with.
+	PublicKey string `toml:"public_key,omitempty"`
+}
+
 type MappingsEntry struct {
 	TokenKey     string `toml:"token_key,omitempty"`
 	MatchPattern string `toml:"match_pattern"`
@@ -113,21 +247,29 @@ type Instance struct {
 	mu      sync.RWMutex
 	appPath string
 	cfgPath string
-	vals    Values
+	// values from the config file, which are saved back to it
+	base Values
+	// values in use, the config file values with the active profile
+	// applied on top
+	vals Values
+	// mappings loaded from the mappings directory, kept separate from the
+	// ones in the config file so they aren't saved back to it
+	fileMappings []MappingsEntry
+	// contents of the active profile's file, the last good contents are
+	// kept if it can't be read again
+	profileData    []byte
+	profileChanged func(profile string)
 }
 
 func NewConfig(configDir string, defaults Values) (*Instance, error) {
-	cfgPath := os.Getenv(CfgEnv)
-	log.Info().Msgf("env config path: %s", cfgPath)
-
-	if cfgPath == "" {
-		cfgPath = filepath.Join(configDir, CfgFile)
-	}
+	log.Info().Msgf("env config path: %s", os.Getenv(CfgEnv))
+	cfgPath := FilePath(configDir)
 
 	cfg := Instance{
 		mu:      sync.RWMutex{},
 		appPath: os.Getenv(AppEnv),
 		cfgPath: cfgPath,
+		base:    defaults,
 		vals:    defaults,
 	}
 
@@ -145,7 +287,15 @@ func NewConfig(configDir string, defaults Values) (*Instance, error) {
 		}
 	}
 
-	err := cfg.Load()
+	// only log issues on startup, a broken config may still be usable
+	issues, err := ValidateFile(cfgPath, ValidateOptions{})
+	if err == nil {
+		for _, issue := range issues {
+			log.Warn().Msg(issue.String())
+		}
+	}
+
+	err = cfg.Load()
 	if err != nil {
 		return nil, err
 	}
@@ -153,19 +303,70 @@ func NewConfig(configDir string, defaults Values) (*Instance, error) {
 	return &cfg, nil
 }
 
+// compileRegexes prepares the allow list regexes of a newly loaded set of
+// values. Invalid entries are logged and never match.
+func compileRegexes(vals *Values) {
+	// prepare allow files regexes
+	vals.Launchers.allowFileRe = make([]*regexp.Regexp, len(vals.Launchers.AllowFile))
+	for i, allowFile := range vals.Launchers.AllowFile {
+		if runtime.GOOS == "windows" {
+			// make regex case-insensitive, if not already
+			if !strings.HasPrefix(allowFile, "(?i)") {
+				allowFile = "(?i)" + allowFile
+			}
+			// replace forward slashes with backslashes
+			allowFile = strings.ReplaceAll(allowFile, "/", "\\\\")
+		}
+
+		re, err := regexp.Compile(allowFile)
+		if err != nil {
+			log.Warn().Msgf("invalid allow file regex: %s", allowFile)
+			continue
+		}
+		vals.Launchers.allowFileRe[i] = re
+	}
+
+	// prepare allow executes regexes
+	vals.ZapScript.allowExecuteRe = make([]*regexp.Regexp, len(vals.ZapScript.AllowExecute))
+	for i, allowExecute := range vals.ZapScript.AllowExecute {
+		re, err := regexp.Compile(allowExecute)
+		if err != nil {
+			log.Warn().Msgf("invalid allow execute regex: %s", allowExecute)
+			continue
+		}
+		vals.ZapScript.allowExecuteRe[i] = re
+	}
+
+	// prepare allow runs regexes
+	vals.Service.allowRunRe = make([]*regexp.Regexp, len(vals.Service.AllowRun))
+	for i, allowRun := range vals.Service.AllowRun {
+		re, err := regexp.Compile(allowRun)
+		if err != nil {
+			log.Warn().Msgf("invalid allow run regex: %s", allowRun)
+			continue
+		}
+		vals.Service.allowRunRe[i] = re
+	}
+}
+
+// Load reads the config file from disk. The current values are only
+// replaced if the whole file is valid, and the active profile's file can be
+// applied or was applied before, in which case its last good contents are
+// kept.
 func (c *Instance) Load() error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	c.mu.RLock()
+	cfgPath := c.cfgPath
+	c.mu.RUnlock()
 
-	if c.cfgPath == "" {
+	if cfgPath == "" {
 		return errors.New("config path not set")
 	}
 
-	if _, err := os.Stat(c.cfgPath); err != nil {
+	if _, err := os.Stat(cfgPath); err != nil {
 		return err
 	}
 
-	data, err := os.ReadFile(c.cfgPath)
+	data, err := os.ReadFile(cfgPath)
 	if err != nil {
 		return err
 	}
@@ -185,53 +386,144 @@ func (c *Instance) Load() error {
 		return errors.New("schema version mismatch")
 	}
 
-	c.vals = newVals
+	compileRegexes(&newVals)
 
-	// prepare allow files regexes
-	c.vals.Launchers.allowFileRe = make([]*regexp.Regexp, len(c.vals.Launchers.AllowFile))
-	for i, allowFile := range c.vals.Launchers.AllowFile {
-		if runtime.GOOS == "windows" {
-			// make regex case-insensitive, if not already
-			if !strings.HasPrefix(allowFile, "(?i)") {
-				allowFile = "(?i)" + allowFile
-			}
-			// replace forward slashes with backslashes
-			allowFile = strings.ReplaceAll(allowFile, "/", "\\\\")
-		}
+	c.mu.RLock()
+	profile := c.base.Profile
+	profileData := c.profileData
+	c.mu.RUnlock()
 
-		re, err := regexp.Compile(allowFile)
-		if err != nil {
-			log.Warn().Msgf("invalid allow file regex: %s", allowFile)
-			continue
+	if newVals.Profile == "" {
+		profileData = nil
+	} else {
+		data, err := readProfile(cfgPath, newVals.Profile, newVals)
+		if err != nil && newVals.Profile == profile && profileData != nil {
+			// a restricted profile mustn't fall back to the config file's
+			// values because its file couldn't be read
+			log.Error().Err(err).Msgf("error reloading profile, keeping last good values: %s", profile)
+		} else if err != nil {
+			return err
+		} else {
+			profileData = data
 		}
-		c.vals.Launchers.allowFileRe[i] = re
 	}
 
-	// prepare allow executes regexes
-	c.vals.ZapScript.allowExecuteRe = make([]*regexp.Regexp, len(c.vals.ZapScript.AllowExecute))
-	for i, allowExecute := range c.vals.ZapScript.AllowExecute {
-		re, err := regexp.Compile(allowExecute)
-		if err != nil {
-			log.Warn().Msgf("invalid allow execute regex: %s", allowExecute)
-			continue
+	c.setBase(newVals, profileData)
+
+	log.Info().Any("config", logValues(newVals)).Msg("loaded config")
+
+	return nil
+}
+
+// maskSecret hides all but the last few characters of a secret, so it can
+// be recognised in the log but not read back.
+func maskSecret(secret string) string {
+	const shown = 4
+	if len(secret) <= shown*2 {
+		return strings.Repeat("*", len(secret))
+	}
+	return strings.Repeat("*", len(secret)-shown) + secret[len(secret)-shown:]
+}
+
+// logValues returns a copy of the values which is safe to write to the log,
+// with passwords and keys masked.
+func logValues(vals Values) Values {
+	vals.Mqtt.Password = maskSecret(vals.Mqtt.Password)
+	vals.Update.PublicKey = maskSecret(vals.Update.PublicKey)
+
+	// URLs may have credentials in them
+	if u, err := url.Parse(vals.Mqtt.Broker); err == nil {
+		vals.Mqtt.Broker = u.Redacted()
+	}
+	if u, err := url.Parse(vals.Update.FeedUrl); err == nil {
+		vals.Update.FeedUrl = u.Redacted()
+	}
+
+	return vals
+}
+
+// Reload validates and reads the config file from disk again, returning the
+// names of the top level sections which changed. The current values are kept
+// if the file has any errors.
+func (c *Instance) Reload() ([]string, error) {
+	before := c.Values()
+
+	issues, err := ValidateFile(c.Path(), ValidateOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, issue := range issues {
+		if !issue.Warning {
+			return nil, errors.New(issue.String())
 		}
-		c.vals.ZapScript.allowExecuteRe[i] = re
+		log.Warn().Msg(issue.String())
 	}
 
-	// prepare allow runs regexes
-	c.vals.Service.allowRunRe = make([]*regexp.Regexp, len(c.vals.Service.AllowRun))
-	for i, allowRun := range c.vals.Service.AllowRun {
-		re, err := regexp.Compile(allowRun)
-		if err != nil {
-			log.Warn().Msgf("invalid allow run regex: %s", allowRun)
-			continue
+	err = c.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return ChangedSections(before, c.Values()), nil
+}
+
+// Values returns a copy of the config values in use, with the active
+// profile applied.
+func (c *Instance) Values() Values {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.vals
+}
+
+// BaseValues returns a copy of the values from the config file, without
+// the active profile applied.
+func (c *Instance) BaseValues() Values {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.base
+}
+
+// SetValues replaces all config file values at once, such as after editing
+// a copy from BaseValues, except the active profile. Call Save to persist
+// them.
+func (c *Instance) SetValues(vals Values) {
+	compileRegexes(&vals)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// the profile can only be switched with SetProfile, which reads it
+	vals.Profile = c.base.Profile
+	c.base = vals
+	c.applyProfile()
+}
+
+// ChangedSections compares two sets of config values and returns the TOML
+// names of the top level sections which are different.
+func ChangedSections(a, b Values) []string {
+	changed := make([]string, 0)
+
+	av := reflect.ValueOf(a)
+	bv := reflect.ValueOf(b)
+	t := av.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		// unexported fields like compiled regexes are skipped by json
+		aj, _ := json.Marshal(av.Field(i).Interface())
+		bj, _ := json.Marshal(bv.Field(i).Interface())
+		if !bytes.Equal(aj, bj) {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("toml"), ",")
+			changed = append(changed, name)
 		}
-		c.vals.Service.allowRunRe[i] = re
 	}
 
-	log.Info().Any("config", c.vals).Msg("loaded config")
+	return changed
+}
 
-	return nil
+// Path returns the location of the config file.
+func (c *Instance) Path() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cfgPath
 }
 
 func (c *Instance) Save() error {
@@ -243,28 +535,48 @@ func (c *Instance) Save() error {
 	}
 
 	// set current schema version
-	c.vals.ConfigSchema = SchemaVersion
+	c.base.ConfigSchema = SchemaVersion
 
 	// generate a device id if one doesn't exist
-	if c.vals.Service.DeviceId == "" {
+	if c.base.Service.DeviceId == "" {
 		newId := uuid.New().String()
-		c.vals.Service.DeviceId = newId
+		c.base.Service.DeviceId = newId
 		log.Info().Msgf("generated new device id: %s", newId)
 	}
+	c.applyProfile()
 
-	tmpMappings := c.vals.Mappings
-	c.vals.Mappings = Mappings{}
-
-	data, err := toml.Marshal(&c.vals)
+	data, err := toml.Marshal(&c.base)
 	if err != nil {
 		return err
 	}
 
-	c.vals.Mappings = tmpMappings
-
 	return os.WriteFile(c.cfgPath, data, 0644)
 }
 
+func (c *Instance) NoticesEnabled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.vals.Notices.Enabled
+}
+
+func (c *Instance) SetNoticesEnabled(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.base.Notices.Enabled = enabled
+	c.applyProfile()
+}
+
+// NoticeDuration returns how long each notice is shown for.
+func (c *Instance) NoticeDuration() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	secs := c.vals.Notices.Duration
+	if secs <= 0 {
+		secs = DefaultNoticeDuration
+	}
+	return time.Duration(secs * float32(time.Second))
+}
+
 func (c *Instance) AudioFeedback() bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -274,7 +586,34 @@ func (c *Instance) AudioFeedback() bool {
 func (c *Instance) SetAudioFeedback(enabled bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.vals.Audio.ScanFeedback = enabled
+	c.base.Audio.ScanFeedback = enabled
+	c.applyProfile()
+}
+
+// AudioVolume returns the volume of feedback sounds, from 0 to 100.
+func (c *Instance) AudioVolume() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.vals.Audio.Volume == nil {
+		return DefaultVolume
+	}
+	return *c.vals.Audio.Volume
+}
+
+func (c *Instance) SetAudioVolume(volume int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.base.Audio.Volume = &volume
+	c.applyProfile()
+}
+
+// LookupSound returns the sound file set for an event in the config. The
+// path is empty if the event's sound has been disabled.
+func (c *Instance) LookupSound(event string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	path, ok := c.vals.Audio.Sounds[event]
+	return path, ok
 }
 
 func (c *Instance) DebugLogging() bool {
@@ -286,8 +625,13 @@ func (c *Instance) DebugLogging() bool {
 func (c *Instance) SetDebugLogging(enabled bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.vals.DebugLogging = enabled
-	if enabled {
+	c.base.DebugLogging = enabled
+	c.applyProfile()
+	setLogLevel(c.vals.DebugLogging)
+}
+
+func setLogLevel(debug bool) {
+	if debug {
 		zerolog.SetGlobalLevel(zerolog.DebugLevel)
 	} else {
 		zerolog.SetGlobalLevel(zerolog.InfoLevel)
@@ -321,19 +665,22 @@ func (c *Instance) HoldModeEnabled() bool {
 func (c *Instance) SetScanMode(mode string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.vals.Readers.Scan.Mode = mode
+	c.base.Readers.Scan.Mode = mode
+	c.applyProfile()
 }
 
 func (c *Instance) SetScanExitDelay(exitDelay float32) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.vals.Readers.Scan.ExitDelay = exitDelay
+	c.base.Readers.Scan.ExitDelay = exitDelay
+	c.applyProfile()
 }
 
 func (c *Instance) SetScanIgnoreSystem(ignoreSystem []string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.vals.Readers.Scan.IgnoreSystem = ignoreSystem
+	c.base.Readers.Scan.IgnoreSystem = ignoreSystem
+	c.applyProfile()
 }
 
 func (c *Instance) Readers() Readers {
@@ -351,13 +698,15 @@ func (c *Instance) AutoDetect() bool {
 func (c *Instance) SetAutoDetect(enabled bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.vals.Readers.AutoDetect = enabled
+	c.base.Readers.AutoDetect = enabled
+	c.applyProfile()
 }
 
 func (c *Instance) SetReaderConnections(rcs []ReadersConnect) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.vals.Readers.Connect = rcs
+	c.base.Readers.Connect = rcs
+	c.applyProfile()
 }
 
 func (c *Instance) SystemDefaults() []SystemsDefault {
@@ -399,16 +748,39 @@ func (c *Instance) ApiPort() int {
 	return c.vals.Service.ApiPort
 }
 
+func (c *Instance) DeviceId() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.vals.Service.DeviceId
+}
+
+func (c *Instance) History() History {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.vals.History
+}
+
+func (c *Instance) Mqtt() Mqtt {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.vals.Mqtt
+}
+
+func (c *Instance) Update() Update {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.vals.Update
+}
+
 func (c *Instance) IsExecuteAllowed(s string) bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	return checkAllow(c.vals.ZapScript.AllowExecute, c.vals.ZapScript.allowExecuteRe, s)
 }
 
+// LoadMappings reads all mapping files in the given directory, replacing
+// any previously loaded from it.
 func (c *Instance) LoadMappings(mappingsDir string) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	_, err := os.Stat(mappingsDir)
 	if err != nil {
 		return err
@@ -421,6 +793,7 @@ func (c *Instance) LoadMappings(mappingsDir string) error {
 
 	filesCounts := 0
 	mappingsCount := 0
+	var entries []MappingsEntry
 
 	for _, mapFile := range mapFiles {
 		if mapFile.IsDir() {
@@ -445,12 +818,16 @@ func (c *Instance) LoadMappings(mappingsDir string) error {
 			return err
 		}
 
-		c.vals.Mappings.Entry = append(c.vals.Mappings.Entry, newVals.Mappings.Entry...)
+		entries = append(entries, newVals.Mappings.Entry...)
 
 		filesCounts++
 		mappingsCount += len(newVals.Mappings.Entry)
 	}
 
+	c.mu.Lock()
+	c.fileMappings = entries
+	c.mu.Unlock()
+
 	log.Info().Msgf("loaded %d mapping files, %d mappings", filesCounts, mappingsCount)
 
 	return nil
@@ -459,7 +836,9 @@ func (c *Instance) LoadMappings(mappingsDir string) error {
 func (c *Instance) Mappings() []MappingsEntry {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.vals.Mappings.Entry
+	entries := make([]MappingsEntry, 0, len(c.vals.Mappings.Entry)+len(c.fileMappings))
+	entries = append(entries, c.vals.Mappings.Entry...)
+	return append(entries, c.fileMappings...)
 }
 
 func (c *Instance) IsRunAllowed(s string) bool {
@@ -468,6 +847,69 @@ func (c *Instance) IsRunAllowed(s string) bool {
 	return checkAllow(c.vals.Service.AllowRun, c.vals.Service.allowRunRe, s)
 }
 
+// LookupLaunchOptions returns the launch options for a launcher, combining
+// the options of its system with the launcher's own, which take precedence.
+// Either ID may be empty.
+func (c *Instance) LookupLaunchOptions(launcherId string, systemId string) LaunchOptions {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var opts LaunchOptions
+	merge := func(o LaunchOptions) {
+		if len(o.Args) > 0 {
+			opts.Args = o.Args
+		}
+		for k, v := range o.Env {
+			if opts.Env == nil {
+				opts.Env = make(map[string]string)
+			}
+			opts.Env[k] = v
+		}
+		if o.Cwd != "" {
+			opts.Cwd = o.Cwd
+		}
+		if o.PreLaunch != "" {
+			opts.PreLaunch = o.PreLaunch
+		}
+		if o.PostExit != "" {
+			opts.PostExit = o.PostExit
+		}
+		if o.ExitDelay != nil {
+			opts.ExitDelay = o.ExitDelay
+		}
+	}
+
+	if systemId != "" {
+		for _, sd := range c.vals.Systems.Default {
+			if strings.EqualFold(sd.System, systemId) {
+				merge(sd.LaunchOptions)
+			}
+		}
+	}
+
+	if launcherId != "" {
+		for _, ld := range c.vals.Launchers.Default {
+			if strings.EqualFold(ld.Launcher, launcherId) {
+				merge(ld.LaunchOptions)
+			}
+		}
+	}
+
+	return opts
+}
+
+func (c *Instance) CustomLaunchers() []LaunchersCustom {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.vals.Launchers.Custom
+}
+
+func (c *Instance) RetroArch() RetroArch {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.vals.Launchers.RetroArch
+}
+
 func (c *Instance) LookupSystemDefaults(systemId string) (SystemsDefault, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
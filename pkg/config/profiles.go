@@ -0,0 +1,306 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// ProfilesDir is the folder next to the config file which profile files are
+// loaded from. A profile is a partial config file named <profile>.toml.
+const ProfilesDir = "profiles"
+
+var profileNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ErrProfileRestricted is returned when a profile switch by a token or remote
+// client could make less restricted values active.
+var ErrProfileRestricted = errors.New("profile can only be switched locally")
+
+// ProfilePath returns the location of a profile's file, for the config file
+// at the given path.
+func ProfilePath(cfgPath string, name string) string {
+	return filepath.Join(filepath.Dir(cfgPath), ProfilesDir, name+".toml")
+}
+
+// ProfilesPath returns the folder profile files are loaded from.
+func (c *Instance) ProfilesPath() string {
+	return filepath.Join(filepath.Dir(c.Path()), ProfilesDir)
+}
+
+// Profiles returns the names of all available profiles.
+func (c *Instance) Profiles() ([]string, error) {
+	entries, err := os.ReadDir(c.ProfilesPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".toml" {
+			continue
+		}
+
+		name := strings.TrimSuffix(entry.Name(), ".toml")
+		if profileNameRe.MatchString(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// Profile returns the name of the active profile, or an empty string if no
+// profile is active.
+func (c *Instance) Profile() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.base.Profile
+}
+
+// OnProfileChange sets a function to be called with the new profile's name
+// whenever the active profile changes, whether it was switched with
+// SetProfile or by the config file being loaded.
+func (c *Instance) OnProfileChange(f func(profile string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.profileChanged = f
+}
+
+// readProfile reads a profile's file and checks it can be applied over the
+// given values.
+func readProfile(cfgPath string, name string, base Values) ([]byte, error) {
+	if !profileNameRe.MatchString(name) {
+		return nil, fmt.Errorf("invalid profile name: %s", name)
+	}
+
+	data, err := os.ReadFile(ProfilePath(cfgPath, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("profile not found: %s", name)
+	} else if err != nil {
+		return nil, err
+	}
+
+	_, err = overlayValues(base, data)
+	if err != nil {
+		return nil, fmt.Errorf("error loading profile %s: %w", name, err)
+	}
+
+	return data, nil
+}
+
+// setBase replaces the config file values and applies the profile file's
+// contents over them, then calls the profile change function if the active
+// profile changed.
+func (c *Instance) setBase(vals Values, profileData []byte) {
+	c.mu.Lock()
+	changed := c.base.Profile != vals.Profile
+	c.base = vals
+	c.profileData = profileData
+	c.applyProfile()
+	f := c.profileChanged
+	c.mu.Unlock()
+
+	if changed && f != nil {
+		f(vals.Profile)
+	}
+}
+
+// SetProfile makes the named profile active, applying its values over the
+// config file's. An empty name goes back to only the config file's values.
+// Call Save to persist it.
+func (c *Instance) SetProfile(name string) error {
+	base := c.BaseValues()
+
+	var data []byte
+	if name != "" {
+		var err error
+		data, err = readProfile(c.Path(), name, base)
+		if err != nil {
+			return err
+		}
+	}
+
+	base.Profile = name
+	c.setBase(base, data)
+
+	c.mu.RLock()
+	setLogLevel(c.vals.DebugLogging)
+	c.mu.RUnlock()
+
+	return nil
+}
+
+// subset returns true if every entry in a is also in b.
+func subset(a []string, b []string) bool {
+	for _, v := range a {
+		found := false
+		for _, w := range b {
+			if v == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// sameCommands returns true if the values in a and b run the same custom
+// launchers, RetroArch binaries and launch options.
+func sameCommands(a Values, b Values) bool {
+	commands := func(v Values) []byte {
+		type systemOptions struct {
+			System     string
+			BeforeExit string
+			LaunchOptions
+		}
+		type launcherOptions struct {
+			Launcher string
+			LaunchOptions
+		}
+
+		var sos []systemOptions
+		for _, sd := range v.Systems.Default {
+			sos = append(sos, systemOptions{sd.System, sd.BeforeExit, sd.LaunchOptions})
+		}
+		var los []launcherOptions
+		for _, ld := range v.Launchers.Default {
+			los = append(los, launcherOptions(ld))
+		}
+
+		data, _ := json.Marshal([]any{sos, los, v.Launchers.Custom, v.Launchers.RetroArch})
+		return data
+	}
+
+	return string(commands(a)) == string(commands(b))
+}
+
+// CheckProfileSwitch returns ErrProfileRestricted if a token or remote
+// client can't switch to the named profile. Profiles are used to narrow what
+// the config file allows, so an active profile can only be left by a local
+// client, and a profile can only be entered if it allows no more to be run
+// than the current values.
+func (c *Instance) CheckProfileSwitch(name string) error {
+	current := c.Profile()
+	if name == current {
+		return nil
+	} else if current != "" {
+		return fmt.Errorf("%w: %s is active", ErrProfileRestricted, current)
+	}
+
+	base := c.BaseValues()
+	data, err := readProfile(c.Path(), name, base)
+	if err != nil {
+		return err
+	}
+
+	vals, err := overlayValues(base, data)
+	if err != nil {
+		return err
+	}
+
+	cur := c.Values()
+	if !subset(vals.Launchers.AllowFile, cur.Launchers.AllowFile) ||
+		!subset(vals.ZapScript.AllowExecute, cur.ZapScript.AllowExecute) ||
+		!subset(vals.Service.AllowRun, cur.Service.AllowRun) ||
+		!sameCommands(vals, cur) {
+		return fmt.Errorf("%w: %s allows more than the current settings", ErrProfileRestricted, name)
+	}
+
+	return nil
+}
+
+// applyProfile updates the values in use from the config file values and the
+// active profile's file contents, which are read before taking the lock.
+// The lock must be held by the caller.
+func (c *Instance) applyProfile() {
+	if c.base.Profile == "" {
+		c.vals = c.base
+		return
+	}
+
+	vals, err := overlayValues(c.base, c.profileData)
+	if err != nil {
+		// the profile was checked when it was read, but don't fall back
+		// to the less restricted config file values if it somehow fails
+		log.Error().Err(err).Msgf("error applying profile, keeping current values: %s", c.base.Profile)
+		return
+	}
+
+	c.vals = vals
+}
+
+// overlayValues applies a profile file's contents over a set of values.
+// Tables are merged key by key and any other value, including arrays, is
+// replaced by the profile's.
+func overlayValues(base Values, data []byte) (Values, error) {
+	var overlay map[string]any
+	err := toml.Unmarshal(data, &overlay)
+	if err != nil {
+		return base, err
+	}
+
+	bd, err := toml.Marshal(&base)
+	if err != nil {
+		return base, err
+	}
+
+	var merged map[string]any
+	err = toml.Unmarshal(bd, &merged)
+	if err != nil {
+		return base, err
+	}
+
+	mergeTables(merged, overlay)
+
+	md, err := toml.Marshal(merged)
+	if err != nil {
+		return base, err
+	}
+
+	var vals Values
+	err = toml.Unmarshal(md, &vals)
+	if err != nil {
+		return base, err
+	}
+
+	// these can only be set in the config file
+	vals.ConfigSchema = base.ConfigSchema
+	vals.Profile = base.Profile
+	vals.Service.DeviceId = base.Service.DeviceId
+
+	compileRegexes(&vals)
+
+	return vals, nil
+}
+
+func mergeTables(dst map[string]any, src map[string]any) {
+	for k, v := range src {
+		st, ok := v.(map[string]any)
+		if !ok {
+			dst[k] = v
+			continue
+		}
+
+		dt, ok := dst[k].(map[string]any)
+		if !ok {
+			dst[k] = st
+			continue
+		}
+
+		mergeTables(dt, st)
+	}
+}
@@ -0,0 +1,556 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+)
+
+// Issue is a problem found while validating a config file. Warnings don't
+// stop the config from being loaded.
+type Issue struct {
+	File    string
+	Line    int
+	Key     string
+	Message string
+	Warning bool
+}
+
+func (i Issue) String() string {
+	level := "error"
+	if i.Warning {
+		level = "warning"
+	}
+
+	var parts []string
+	if i.File != "" && i.Line > 0 {
+		parts = append(parts, fmt.Sprintf("%s:%d", i.File, i.Line))
+	} else if i.File != "" {
+		parts = append(parts, i.File)
+	}
+
+	parts = append(parts, level)
+	if i.Key != "" {
+		parts = append(parts, i.Key)
+	}
+	parts = append(parts, i.Message)
+
+	return strings.Join(parts, ": ")
+}
+
+// ValidateOptions are the IDs known to the running platform, matched
+// case-insensitively. A nil list skips that check.
+type ValidateOptions struct {
+	Systems   []string
+	Launchers []string
+	Drivers   []string
+	// Launchers which apply the args, env and cwd launch options. Nil
+	// skips checking them.
+	CommandLaunchers []string
+}
+
+// HasErrors returns true if any of the issues aren't warnings.
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if !issue.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+// FilePath returns the location of the config file in the given config
+// directory, unless it's been overridden by the environment.
+func FilePath(configDir string) string {
+	cfgPath := os.Getenv(CfgEnv)
+	if cfgPath == "" {
+		cfgPath = filepath.Join(configDir, CfgFile)
+	}
+	return cfgPath
+}
+
+// ValidateFile reads and validates the config file at the given path.
+func ValidateFile(path string, opts ValidateOptions) ([]Issue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Validate(path, data, opts), nil
+}
+
+// ValidateValues checks config values which haven't been written to disk.
+// Issues have no file or line.
+func ValidateValues(vals Values, opts ValidateOptions) ([]Issue, error) {
+	data, err := toml.Marshal(&vals)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := Validate("", data, opts)
+	for i := range issues {
+		issues[i].Line = 0
+	}
+
+	return issues, nil
+}
+
+// keyLines maps the full path of every key in a TOML document to the line
+// it's defined on. Arrays of tables and array values are indexed like
+// readers.connect[0].driver and service.allow_run[1].
+func keyLines(data []byte) map[string]int {
+	lines := make(map[string]int)
+	counts := make(map[string]int)
+
+	var p unstable.Parser
+	p.Reset(data)
+
+	line := func(n *unstable.Node) int {
+		if n == nil || n.Raw.Length == 0 {
+			return 0
+		}
+		return p.Shape(n.Raw).Start.Line
+	}
+
+	keyPath := func(it unstable.Iterator) (string, *unstable.Node) {
+		var parts []string
+		var first *unstable.Node
+		for it.Next() {
+			if first == nil {
+				first = it.Node()
+			}
+			parts = append(parts, string(it.Node().Data))
+		}
+		return strings.Join(parts, "."), first
+	}
+
+	prefix := ""
+	for p.NextExpression() {
+		expr := p.Expression()
+
+		switch expr.Kind {
+		case unstable.Table:
+			key, first := keyPath(expr.Key())
+			prefix = key
+			lines[prefix] = line(first)
+		case unstable.ArrayTable:
+			key, first := keyPath(expr.Key())
+			prefix = fmt.Sprintf("%s[%d]", key, counts[key])
+			counts[key]++
+			lines[key] = line(first)
+			lines[prefix] = line(first)
+		case unstable.KeyValue:
+			key, first := keyPath(expr.Key())
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			lines[key] = line(first)
+
+			value := expr.Value()
+			if value.Kind == unstable.Array {
+				i := 0
+				it := value.Children()
+				for it.Next() {
+					if l := line(it.Node()); l > 0 {
+						lines[fmt.Sprintf("%s[%d]", key, i)] = l
+					}
+					i++
+				}
+			}
+		}
+	}
+
+	return lines
+}
+
+type validator struct {
+	file   string
+	lines  map[string]int
+	issues []Issue
+}
+
+// line finds the line of a key, falling back to its closest parent.
+func (v *validator) line(key string) int {
+	for key != "" {
+		if l, ok := v.lines[key]; ok {
+			return l
+		}
+
+		i := strings.LastIndexAny(key, ".[")
+		if i < 0 {
+			break
+		}
+		key = key[:i]
+	}
+	return 0
+}
+
+func (v *validator) add(key string, warning bool, format string, args ...any) {
+	v.issues = append(v.issues, Issue{
+		File:    v.file,
+		Line:    v.line(key),
+		Key:     key,
+		Message: fmt.Sprintf(format, args...),
+		Warning: warning,
+	})
+}
+
+func (v *validator) errorf(key string, format string, args ...any) {
+	v.add(key, false, format, args...)
+}
+
+func (v *validator) warnf(key string, format string, args ...any) {
+	v.add(key, true, format, args...)
+}
+
+func (v *validator) regex(key string, s string) {
+	_, err := regexp.Compile(s)
+	if err != nil {
+		v.errorf(key, "invalid regex: %s", err)
+	}
+}
+
+func (v *validator) known(key string, kind string, known []string, id string) {
+	if known == nil {
+		return
+	}
+
+	for _, k := range known {
+		if strings.EqualFold(k, id) {
+			return
+		}
+	}
+
+	v.errorf(key, "unknown %s: %s", kind, id)
+}
+
+// launchOptions checks the launch options of a launcher, or of a system if
+// launcher is empty, in which case they apply to any of its launchers.
+func (v *validator) launchOptions(key string, launcher string, o LaunchOptions, commandLaunchers []string) {
+	if o.ExitDelay != nil && *o.ExitDelay < 0 {
+		v.errorf(key+".exit_delay", "exit delay can't be negative")
+	}
+
+	if o.Cwd != "" {
+		if info, err := os.Stat(o.Cwd); err != nil || !info.IsDir() {
+			v.warnf(key+".cwd", "folder not found: %s", o.Cwd)
+		}
+	}
+
+	if commandLaunchers == nil || (len(o.Args) == 0 && len(o.Env) == 0 && o.Cwd == "") {
+		return
+	}
+
+	if launcher == "" {
+		if len(commandLaunchers) == 0 {
+			v.warnf(key, "args, env and cwd aren't supported by any launchers on this platform")
+		}
+		return
+	}
+
+	for _, id := range commandLaunchers {
+		if strings.EqualFold(id, launcher) {
+			return
+		}
+	}
+
+	v.warnf(key, "args, env and cwd aren't supported by launcher: %s", launcher)
+}
+
+// Validate checks a config file's contents against the config schema,
+// returning every problem found with the line it's on. Unknown keys are
+// reported as warnings.
+func Validate(file string, data []byte, opts ValidateOptions) []Issue {
+	v := validator{
+		file: file,
+	}
+
+	var vals Values
+	err := toml.NewDecoder(bytes.NewReader(data)).
+		DisallowUnknownFields().
+		Decode(&vals)
+
+	var decodeErr *toml.DecodeError
+	var strictErr *toml.StrictMissingError
+	if errors.As(err, &strictErr) {
+		for _, e := range strictErr.Errors {
+			row, _ := e.Position()
+			v.issues = append(v.issues, Issue{
+				File:    file,
+				Line:    row,
+				Key:     strings.Join(e.Key(), "."),
+				Message: "unknown key",
+				Warning: true,
+			})
+		}
+	} else if errors.As(err, &decodeErr) {
+		row, _ := decodeErr.Position()
+		v.issues = append(v.issues, Issue{
+			File:    file,
+			Line:    row,
+			Key:     strings.Join(decodeErr.Key(), "."),
+			Message: decodeErr.Error(),
+		})
+		return v.issues
+	} else if err != nil {
+		v.errorf("", "%s", err)
+		return v.issues
+	}
+
+	v.lines = keyLines(data)
+
+	// custom launchers defined in this file can be referenced elsewhere in it
+	if opts.Launchers != nil {
+		known := append([]string{}, opts.Launchers...)
+		for _, lc := range vals.Launchers.Custom {
+			known = append(known, lc.Id)
+		}
+		opts.Launchers = known
+	}
+
+	if vals.ConfigSchema != SchemaVersion {
+		v.errorf(
+			"config_schema",
+			"unsupported schema version %d, expecting %d",
+			vals.ConfigSchema,
+			SchemaVersion,
+		)
+	}
+
+	if vals.Profile != "" {
+		if !profileNameRe.MatchString(vals.Profile) {
+			v.errorf("profile", "invalid profile name: %s", vals.Profile)
+		} else if file != "" {
+			_, err := os.Stat(ProfilePath(file, vals.Profile))
+			if err != nil {
+				v.warnf("profile", "profile not found: %s", vals.Profile)
+			}
+		}
+	}
+
+	// audio
+	if vals.Audio.Volume != nil && (*vals.Audio.Volume < 0 || *vals.Audio.Volume > 100) {
+		v.errorf("audio.volume", "volume must be from 0 to 100: %d", *vals.Audio.Volume)
+	}
+
+	events := make([]string, 0, len(vals.Audio.Sounds))
+	for event := range vals.Audio.Sounds {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+
+	for _, event := range events {
+		key := "audio.sounds." + event
+		path := vals.Audio.Sounds[event]
+		if !slices.Contains(SoundEvents, event) {
+			v.warnf(key, "unknown sound event: %s", event)
+		} else if filepath.IsAbs(path) {
+			if _, err := os.Stat(path); err != nil {
+				v.warnf(key, "sound file not found: %s", path)
+			}
+		}
+	}
+
+	// notices
+	if vals.Notices.Duration < 0 {
+		v.errorf("notices.duration", "duration can't be negative")
+	}
+
+	// readers
+	switch vals.Readers.Scan.Mode {
+	case "", ScanModeTap, ScanModeHold:
+	default:
+		v.errorf(
+			"readers.scan.mode",
+			"unknown scan mode %q, expecting %q or %q",
+			vals.Readers.Scan.Mode,
+			ScanModeTap,
+			ScanModeHold,
+		)
+	}
+
+	if vals.Readers.Scan.ExitDelay < 0 {
+		v.errorf("readers.scan.exit_delay", "exit delay can't be negative")
+	}
+
+	for i, system := range vals.Readers.Scan.IgnoreSystem {
+		v.known(fmt.Sprintf("readers.scan.ignore_system[%d]", i), "system", opts.Systems, system)
+	}
+
+	for i, rc := range vals.Readers.Connect {
+		key := fmt.Sprintf("readers.connect[%d]", i)
+		if rc.Driver == "" {
+			v.errorf(key+".driver", "missing reader driver")
+		} else {
+			v.known(key+".driver", "reader driver", opts.Drivers, rc.Driver)
+		}
+	}
+
+	// systems
+	for i, sd := range vals.Systems.Default {
+		key := fmt.Sprintf("systems.default[%d]", i)
+		if sd.System == "" {
+			v.errorf(key+".system", "missing system")
+		} else {
+			v.known(key+".system", "system", opts.Systems, sd.System)
+		}
+
+		if sd.Launcher != "" {
+			v.known(key+".launcher", "launcher", opts.Launchers, sd.Launcher)
+		}
+
+		v.launchOptions(key, sd.Launcher, sd.LaunchOptions, opts.CommandLaunchers)
+	}
+
+	// launchers
+	for i, root := range vals.Launchers.IndexRoot {
+		if _, err := os.Stat(root); err != nil {
+			v.warnf(fmt.Sprintf("launchers.index_root[%d]", i), "folder not found: %s", root)
+		}
+	}
+
+	for i, allowFile := range vals.Launchers.AllowFile {
+		v.regex(fmt.Sprintf("launchers.allow_file[%d]", i), allowFile)
+	}
+
+	for i, ld := range vals.Launchers.Default {
+		key := fmt.Sprintf("launchers.default[%d]", i)
+		if ld.Launcher == "" {
+			v.errorf(key+".launcher", "missing launcher")
+		} else {
+			v.known(key+".launcher", "launcher", opts.Launchers, ld.Launcher)
+		}
+
+		v.launchOptions(key, ld.Launcher, ld.LaunchOptions, opts.CommandLaunchers)
+	}
+
+	customIds := make(map[string]bool)
+	for i, lc := range vals.Launchers.Custom {
+		key := fmt.Sprintf("launchers.custom[%d]", i)
+
+		id := strings.ToLower(lc.Id)
+		if lc.Id == "" {
+			v.errorf(key+".id", "missing launcher id")
+		} else if customIds[id] {
+			v.errorf(key+".id", "duplicate launcher id: %s", lc.Id)
+		}
+		customIds[id] = true
+
+		if lc.System != "" {
+			v.known(key+".system", "system", opts.Systems, lc.System)
+		}
+
+		for j, ext := range lc.Extensions {
+			if !strings.HasPrefix(ext, ".") {
+				v.warnf(fmt.Sprintf("%s.extensions[%d]", key, j), "extension should start with a dot: %s", ext)
+			}
+		}
+
+		if strings.TrimSpace(lc.Command) == "" {
+			v.errorf(key+".command", "missing command")
+		}
+	}
+
+	ra := vals.Launchers.RetroArch
+	if ra.CommandPort < 0 || ra.CommandPort > 65535 {
+		v.errorf("launchers.retroarch.command_port", "invalid port: %d", ra.CommandPort)
+	}
+
+	for i, rc := range ra.Core {
+		key := fmt.Sprintf("launchers.retroarch.core[%d]", i)
+		if rc.System == "" {
+			v.errorf(key+".system", "missing system")
+		} else {
+			v.known(key+".system", "system", opts.Systems, rc.System)
+		}
+
+		if rc.Core == "" {
+			v.errorf(key+".core", "missing core")
+		}
+	}
+
+	// zapscript
+	for i, allowExecute := range vals.ZapScript.AllowExecute {
+		v.regex(fmt.Sprintf("zapscript.allow_execute[%d]", i), allowExecute)
+	}
+
+	// service
+	if vals.Service.ApiPort < 1 || vals.Service.ApiPort > 65535 {
+		v.errorf("service.api_port", "invalid port: %d", vals.Service.ApiPort)
+	}
+
+	for i, allowRun := range vals.Service.AllowRun {
+		v.regex(fmt.Sprintf("service.allow_run[%d]", i), allowRun)
+	}
+
+	// history
+	if vals.History.RetentionDays < 0 {
+		v.errorf("history.retention_days", "retention days can't be negative")
+	}
+
+	if vals.History.MaxEntries < 0 {
+		v.errorf("history.max_entries", "max entries can't be negative")
+	}
+
+	// mqtt
+	if vals.Mqtt.Enabled && vals.Mqtt.Broker == "" {
+		v.errorf("mqtt.broker", "missing broker address")
+	} else if vals.Mqtt.Broker != "" {
+		u, err := url.Parse(vals.Mqtt.Broker)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			v.errorf("mqtt.broker", "invalid broker address, expecting scheme://host:port")
+		}
+	}
+
+	// update
+	if vals.Update.FeedUrl != "" {
+		u, err := url.Parse(vals.Update.FeedUrl)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.errorf("update.feed_url", "invalid feed URL, expecting http:// or https://")
+		}
+	}
+
+	if vals.Update.PublicKey != "" {
+		key, err := base64.StdEncoding.DecodeString(vals.Update.PublicKey)
+		if err != nil || len(key) != 32 {
+			v.errorf("update.public_key", "invalid public key, expecting a base64 encoded Ed25519 key")
+		}
+	} else if vals.Update.FeedUrl != "" {
+		v.warnf("update.public_key", "missing public key, updates can't be verified or installed")
+	}
+
+	// mappings
+	for i, m := range vals.Mappings.Entry {
+		key := fmt.Sprintf("mappings.entry[%d]", i)
+
+		switch m.TokenKey {
+		case "", "id", "uid", "value", "data":
+		default:
+			v.errorf(key+".token_key", "unknown token key %q, expecting id, value or data", m.TokenKey)
+		}
+
+		if m.MatchPattern == "" {
+			v.errorf(key+".match_pattern", "missing match pattern")
+		} else if len(m.MatchPattern) > 2 &&
+			strings.HasPrefix(m.MatchPattern, "/") &&
+			strings.HasSuffix(m.MatchPattern, "/") {
+			v.regex(key+".match_pattern", m.MatchPattern[1:len(m.MatchPattern)-1])
+		}
+
+		if m.ZapScript == "" {
+			v.errorf(key+".zapscript", "missing zapscript")
+		}
+	}
+
+	return v.issues
+}
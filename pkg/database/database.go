@@ -1,10 +1,8 @@
 package database
 
 import (
-	"encoding/json"
 	"os"
 	"path/filepath"
-	"time"
 
 	"github.com/ZaparooProject/zaparoo-core/pkg/config"
 	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
@@ -12,9 +10,14 @@ import (
 )
 
 const (
-	BucketHistory  = "history"
-	BucketMappings = "mappings"
-	BucketClients  = "clients"
+	BucketHistory           = "history"
+	BucketMappings          = "mappings"
+	BucketClients           = "clients"
+	BucketWebhooks          = "webhooks"
+	BucketWebhookDeliveries = "webhook_deliveries"
+	BucketPlaySessions      = "play_sessions"
+	BucketPlaylists         = "playlists"
+	BucketState             = "state"
 )
 
 func dbFile(pl platforms.Platform) string {
@@ -29,13 +32,13 @@ func DbExists(pl platforms.Platform) bool {
 
 // Open the db with the given options. If the database does not exist it
 // will be created and the buckets will be initialized.
-func open(pl platforms.Platform, options *bolt.Options) (*bolt.DB, error) {
-	err := os.MkdirAll(filepath.Dir(dbFile(pl)), 0755)
+func open(path string, options *bolt.Options) (*bolt.DB, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
 	if err != nil {
 		return nil, err
 	}
 
-	db, err := bolt.Open(dbFile(pl), 0600, options)
+	db, err := bolt.Open(path, 0600, options)
 	if err != nil {
 		return nil, err
 	}
@@ -45,6 +48,11 @@ func open(pl platforms.Platform, options *bolt.Options) (*bolt.DB, error) {
 			BucketHistory,
 			BucketMappings,
 			BucketClients,
+			BucketWebhooks,
+			BucketWebhookDeliveries,
+			BucketPlaySessions,
+			BucketPlaylists,
+			BucketState,
 		} {
 			_, err := txn.CreateBucketIfNotExists([]byte(bucket))
 			if err != nil {
@@ -52,7 +60,7 @@ func open(pl platforms.Platform, options *bolt.Options) (*bolt.DB, error) {
 			}
 		}
 
-		return nil
+		return migrateHistoryKeys(txn)
 	})
 	if err != nil {
 		return nil, err
@@ -66,7 +74,13 @@ type Database struct {
 }
 
 func Open(pl platforms.Platform) (*Database, error) {
-	db, err := open(pl, &bolt.Options{})
+	return OpenFile(dbFile(pl))
+}
+
+// OpenFile opens the db at the given path instead of the platform's data
+// directory.
+func OpenFile(path string) (*Database, error) {
+	db, err := open(path, &bolt.Options{})
 	if err != nil {
 		return nil, err
 	}
@@ -77,63 +91,3 @@ func Open(pl platforms.Platform) (*Database, error) {
 func (d *Database) Close() error {
 	return d.bdb.Close()
 }
-
-// TODO: reader source (physical reader vs web)
-// TODO: metadata
-type HistoryEntry struct {
-	Time    time.Time `json:"time"`
-	Type    string    `json:"type"`
-	UID     string    `json:"uid"`
-	Text    string    `json:"text"`
-	Data    string    `json:"data"`
-	Success bool      `json:"success"`
-}
-
-func HistoryKey(entry HistoryEntry) string {
-	// TODO: web has no uid, this could collide, use autoincrement instead
-	return entry.Time.Format(time.RFC3339) + "-" + entry.UID
-}
-
-func (d *Database) AddHistory(entry HistoryEntry) error {
-	return d.bdb.Update(func(txn *bolt.Tx) error {
-		b := txn.Bucket([]byte(BucketHistory))
-
-		data, err := json.Marshal(entry)
-		if err != nil {
-			return err
-		}
-
-		return b.Put([]byte(HistoryKey(entry)), data)
-	})
-}
-
-func (d *Database) GetHistory() ([]HistoryEntry, error) {
-	var entries []HistoryEntry
-	i := 0
-	maxResults := 25
-
-	err := d.bdb.View(func(txn *bolt.Tx) error {
-		b := txn.Bucket([]byte(BucketHistory))
-
-		c := b.Cursor()
-		for k, v := c.Last(); k != nil; k, v = c.Prev() {
-			if i >= maxResults {
-				break
-			}
-
-			var entry HistoryEntry
-			err := json.Unmarshal(v, &entry)
-			if err != nil {
-				return err
-			}
-
-			entries = append(entries, entry)
-
-			i++
-		}
-
-		return nil
-	})
-
-	return entries, err
-}
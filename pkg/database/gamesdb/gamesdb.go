@@ -2,6 +2,7 @@ package gamesdb
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -20,6 +21,10 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// ErrNotExist is returned when the games index is needed but media hasn't
+// been indexed yet.
+var ErrNotExist = errors.New("gamesdb does not exist")
+
 const (
 	BucketNames       = "names"
 	indexedSystemsKey = "meta:indexedSystems"
@@ -219,7 +224,7 @@ func NewNamesIndex(
 
 	update(status)
 	systemPaths := make(map[string][]string)
-	for _, v := range GetSystemPaths(platform, platform.RootDirs(cfg), systems) {
+	for _, v := range GetSystemPaths(cfg, platform, platform.RootDirs(cfg), systems) {
 		systemPaths[v.System.Id] = append(systemPaths[v.System.Id], v.Path)
 	}
 
@@ -255,7 +260,7 @@ func NewNamesIndex(
 
 		// for each system launcher in platform, run the results through its
 		// custom scan function if one exists
-		for _, l := range platform.Launchers() {
+		for _, l := range platform.Launchers(cfg) {
 			if l.SystemId == k && l.Scanner != nil {
 				log.Debug().Msgf("running %s scanner for system: %s", l.Id, systemId)
 				files, err = l.Scanner(cfg, systemId, files)
@@ -286,7 +291,7 @@ func NewNamesIndex(
 
 	// run each custom scanner at least once, even if there are no paths
 	// defined or results from regular index
-	for _, l := range platform.Launchers() {
+	for _, l := range platform.Launchers(cfg) {
 		systemId := l.SystemId
 		if !scanned[systemId] && l.Scanner != nil {
 			log.Debug().Msgf("running %s scanner for system: %s", l.Id, systemId)
@@ -316,7 +321,7 @@ func NewNamesIndex(
 
 	// launcher scanners with no system defined are run against every system
 	var anyScanners []platforms.Launcher
-	for _, l := range platform.Launchers() {
+	for _, l := range platform.Launchers(cfg) {
 		if l.SystemId == "" && l.Scanner != nil {
 			anyScanners = append(anyScanners, l)
 		}
@@ -395,7 +400,7 @@ func searchNamesGeneric(
 	test func(string, string) bool,
 ) ([]SearchResult, error) {
 	if !Exists(platform) {
-		return nil, fmt.Errorf("gamesdb does not exist")
+		return nil, ErrNotExist
 	}
 
 	db, err := open(platform, &bolt.Options{})
@@ -540,7 +545,7 @@ func SystemIndexed(platform platforms.Platform, system System) bool {
 // Return all systems indexed in the gamesdb
 func IndexedSystems(platform platforms.Platform) ([]string, error) {
 	if !Exists(platform) {
-		return nil, fmt.Errorf("gamesdb does not exist")
+		return nil, ErrNotExist
 	}
 
 	db, err := open(platform, &bolt.Options{})
@@ -565,7 +570,7 @@ func IndexedSystems(platform platforms.Platform) ([]string, error) {
 // Return a random game from specified systems.
 func RandomGame(platform platforms.Platform, systems []System) (SearchResult, error) {
 	if !Exists(platform) {
-		return SearchResult{}, fmt.Errorf("gamesdb does not exist")
+		return SearchResult{}, ErrNotExist
 	}
 
 	db, err := open(platform, &bolt.Options{})
@@ -44,12 +44,17 @@ func FindPath(path string) (string, error) {
 	return "", fmt.Errorf("file match not found: %s", path)
 }
 
-func GetSystemPaths(pl platforms.Platform, rootFolders []string, systems []System) []PathResult {
+func GetSystemPaths(
+	cfg *config.Instance,
+	pl platforms.Platform,
+	rootFolders []string,
+	systems []System,
+) []PathResult {
 	var matches []PathResult
 
 	for _, system := range systems {
 		var launchers []platforms.Launcher
-		for _, l := range pl.Launchers() {
+		for _, l := range pl.Launchers(cfg) {
 			if l.SystemId == system.Id {
 				launchers = append(launchers, l)
 			}
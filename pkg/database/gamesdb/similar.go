@@ -0,0 +1,110 @@
+package gamesdb
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+)
+
+// Names scoring below this aren't similar enough to suggest.
+const minSimilarity = 0.5
+
+// Tags like "(USA)" or "[!]" which don't help tell names apart.
+var reNameTags = regexp.MustCompile(`\s*[(\[][^)\]]*[)\]]`)
+
+// normalizeName lowercases a name and reduces it to words of letters and
+// numbers, without tags.
+func normalizeName(name string) string {
+	name = reNameTags.ReplaceAllString(name, "")
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, name)
+	return strings.Join(strings.Fields(name), " ")
+}
+
+func bigrams(s string) map[string]int {
+	grams := make(map[string]int)
+	rs := []rune(s)
+	for i := 0; i+1 < len(rs); i++ {
+		grams[string(rs[i:i+2])]++
+	}
+	return grams
+}
+
+// NameSimilarity scores how alike two game names are, from 0 to 1. Names
+// are compared by their letter pairs and a name containing the whole other
+// name scores highly, so "metroid" is similar to "Super Metroid (USA)".
+func NameSimilarity(a string, b string) float64 {
+	a, b = normalizeName(a), normalizeName(b)
+	if a == "" || b == "" {
+		return 0
+	} else if a == b {
+		return 1
+	}
+
+	score := 0.0
+	if strings.Contains(" "+b+" ", " "+a+" ") || strings.Contains(" "+a+" ", " "+b+" ") {
+		score = 0.9
+	}
+
+	ag, bg := bigrams(a), bigrams(b)
+	total, shared := 0, 0
+	for g, n := range ag {
+		total += n
+		shared += min(n, bg[g])
+	}
+	for _, n := range bg {
+		total += n
+	}
+
+	if total > 0 {
+		score = max(score, 2*float64(shared)/float64(total))
+	}
+
+	return score
+}
+
+// SimilarNames returns up to limit indexed names most like the query, for
+// suggesting when a search finds nothing. Glob wildcards in the query are
+// ignored.
+func SimilarNames(
+	platform platforms.Platform,
+	systems []System,
+	query string,
+	limit int,
+) ([]SearchResult, error) {
+	query = strings.NewReplacer("*", " ", "?", " ").Replace(query)
+	scores := make(map[string]float64)
+
+	res, err := searchNamesGeneric(platform, systems, query, func(query, keyName string) bool {
+		score := NameSimilarity(query, keyName)
+		if score < minSimilarity {
+			return false
+		}
+		scores[keyName] = score
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		si, sj := scores[res[i].Name], scores[res[j].Name]
+		if si != sj {
+			return si > sj
+		}
+		return res[i].Name < res[j].Name
+	})
+
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+
+	return res, nil
+}
@@ -0,0 +1,36 @@
+package gamesdb
+
+import "testing"
+
+func TestNameSimilarity(t *testing.T) {
+	similar := [][2]string{
+		{"metroid", "Super Metroid (USA)"},
+		{"super metroid", "Super Metroid (Japan, USA) (En,Ja)"},
+		{"sonic the hedgehog 2", "Sonic The Hedgehog 2 (World) (Rev A)"},
+		{"zelda link to the past", "Legend of Zelda, The - A Link to the Past (USA)"},
+		{"mario kart", "Super Mario Kart (USA)"},
+		{"super metroib", "Super Metroid (USA)"},
+	}
+
+	for _, names := range similar {
+		if score := NameSimilarity(names[0], names[1]); score < minSimilarity {
+			t.Errorf("%q and %q should be similar, got %.2f", names[0], names[1], score)
+		}
+	}
+
+	different := [][2]string{
+		{"metroid", "Street Fighter II (USA)"},
+		{"zelda", "Sonic The Hedgehog (World)"},
+		{"", "Super Metroid (USA)"},
+	}
+
+	for _, names := range different {
+		if score := NameSimilarity(names[0], names[1]); score >= minSimilarity {
+			t.Errorf("%q and %q shouldn't be similar, got %.2f", names[0], names[1], score)
+		}
+	}
+
+	if NameSimilarity("Super Metroid", "super metroid [!]") != 1 {
+		t.Error("names differing only by case and tags should match exactly")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
 	"github.com/ZaparooProject/zaparoo-core/pkg/utils"
 )
 
@@ -57,6 +59,35 @@ func AllSystems() []System {
 	return systems
 }
 
+// ConfigValidateOptions returns the system, launcher and reader driver IDs
+// supported by a platform, for validating config files.
+func ConfigValidateOptions(pl platforms.Platform, cfg *config.Instance) config.ValidateOptions {
+	opts := config.ValidateOptions{
+		Systems:          make([]string, 0),
+		Launchers:        make([]string, 0),
+		Drivers:          make([]string, 0),
+		CommandLaunchers: make([]string, 0),
+	}
+
+	for _, system := range AllSystems() {
+		opts.Systems = append(opts.Systems, system.Id)
+		opts.Systems = append(opts.Systems, system.Aliases...)
+	}
+
+	for _, l := range pl.Launchers(cfg) {
+		opts.Launchers = append(opts.Launchers, l.Id)
+		if l.CommandOptions {
+			opts.CommandLaunchers = append(opts.CommandLaunchers, l.Id)
+		}
+	}
+
+	for _, r := range pl.SupportedReaders(cfg) {
+		opts.Drivers = append(opts.Drivers, r.Ids()...)
+	}
+
+	return opts
+}
+
 // Consoles
 const (
 	System3DO               = "3DO"
@@ -201,6 +232,7 @@ const (
 	SystemChip8   = "Chip8"
 	SystemIOS     = "iOS"
 	SystemVideo   = "Video"
+	SystemAudio   = "Audio"
 )
 
 var Systems = map[string]System{
@@ -650,6 +682,11 @@ var Systems = map[string]System{
 		Id: SystemIOS,
 	},
 	SystemVideo: {
-		Id: SystemVideo,
+		Id:      SystemVideo,
+		Aliases: []string{"Movies", "TV"},
+	},
+	SystemAudio: {
+		Id:      SystemAudio,
+		Aliases: []string{"Music"},
 	},
 }
@@ -0,0 +1,292 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	bolt "go.etcd.io/bbolt"
+)
+
+// TODO: metadata
+type HistoryEntry struct {
+	Id      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	UID     string    `json:"uid"`
+	Text    string    `json:"text"`
+	Data    string    `json:"data"`
+	Source  string    `json:"source"`
+	Success bool      `json:"success"`
+	Profile string    `json:"profile,omitempty"`
+	// Why the token failed, if it did.
+	Error *HistoryError `json:"error,omitempty"`
+}
+
+// HistoryError is the stored copy of the launch error a token failed with.
+type HistoryError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// ZapScript command which failed, such as "launch.search".
+	Command string `json:"command,omitempty"`
+	Stage   string `json:"stage,omitempty"`
+	// Paths checked or systems searched before giving up.
+	Candidates []string `json:"candidates,omitempty"`
+	// Names from the games index similar to the one asked for.
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// HistoryQuery filters history entries. Zero values match everything.
+type HistoryQuery struct {
+	// Only return entries older than the entry with this ID, as returned
+	// for the next page of a previous query.
+	Cursor string
+	// Maximum number of entries to return, 0 for no limit.
+	Limit int
+	Since time.Time
+	Until time.Time
+	// Case-insensitive exact match.
+	UID string
+	// Case-insensitive substring match.
+	Text string
+	// Case-insensitive match of the whole source or of its name without
+	// the client address, so "API" matches "API:127.0.0.1".
+	Source  string
+	Success *bool
+	// Exact match, including the empty profile.
+	Profile *string
+}
+
+func sourceMatches(source string, query string) bool {
+	if strings.EqualFold(source, query) {
+		return true
+	}
+	name, _, _ := strings.Cut(source, ":")
+	return strings.EqualFold(name, query)
+}
+
+func (q HistoryQuery) matches(e HistoryEntry) bool {
+	if !q.Since.IsZero() && e.Time.Before(q.Since) {
+		return false
+	}
+
+	if !q.Until.IsZero() && e.Time.After(q.Until) {
+		return false
+	}
+
+	if q.UID != "" && !strings.EqualFold(e.UID, q.UID) {
+		return false
+	}
+
+	if q.Text != "" && !strings.Contains(strings.ToLower(e.Text), strings.ToLower(q.Text)) {
+		return false
+	}
+
+	if q.Source != "" && !sourceMatches(e.Source, q.Source) {
+		return false
+	}
+
+	if q.Success != nil && e.Success != *q.Success {
+		return false
+	}
+
+	if q.Profile != nil && e.Profile != *q.Profile {
+		return false
+	}
+
+	return true
+}
+
+func historyKey(id uint64) []byte {
+	// zero padded so entries sort in the order they were added
+	return []byte(fmt.Sprintf("%020d", id))
+}
+
+func isHistoryKey(k []byte) bool {
+	if len(k) != 20 {
+		return false
+	}
+	_, err := strconv.ParseUint(string(k), 10, 64)
+	return err == nil
+}
+
+// Entries used to be keyed by scan time and UID, which could collide for
+// tokens without a UID. Rewrite any old entries with sequential keys.
+func migrateHistoryKeys(txn *bolt.Tx) error {
+	b := txn.Bucket([]byte(BucketHistory))
+
+	var old [][]byte
+	var entries []HistoryEntry
+	err := b.ForEach(func(k, v []byte) error {
+		if isHistoryKey(k) {
+			return nil
+		}
+
+		var e HistoryEntry
+		err := json.Unmarshal(v, &e)
+		if err != nil {
+			return err
+		}
+
+		old = append(old, append([]byte{}, k...))
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil || len(old) == 0 {
+		return err
+	}
+
+	log.Info().Msgf("migrating %d history entries", len(old))
+
+	for _, k := range old {
+		err := b.Delete(k)
+		if err != nil {
+			return err
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+
+	for _, e := range entries {
+		err := putHistory(b, e)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func putHistory(b *bolt.Bucket, entry HistoryEntry) error {
+	seq, err := b.NextSequence()
+	if err != nil {
+		return err
+	}
+	entry.Id = strconv.FormatUint(seq, 10)
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return b.Put(historyKey(seq), data)
+}
+
+func (d *Database) AddHistory(entry HistoryEntry) error {
+	return d.bdb.Update(func(txn *bolt.Tx) error {
+		return putHistory(txn.Bucket([]byte(BucketHistory)), entry)
+	})
+}
+
+// GetHistory returns entries matching the query, newest first. If more
+// entries are available, the cursor for the next page is also returned.
+func (d *Database) GetHistory(q HistoryQuery) ([]HistoryEntry, string, error) {
+	var entries = make([]HistoryEntry, 0)
+	next := ""
+
+	var start []byte
+	if q.Cursor != "" {
+		id, err := strconv.ParseUint(q.Cursor, 10, 64)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid history cursor: %s", q.Cursor)
+		}
+		start = historyKey(id)
+	}
+
+	err := d.bdb.View(func(txn *bolt.Tx) error {
+		b := txn.Bucket([]byte(BucketHistory))
+		c := b.Cursor()
+
+		var k, v []byte
+		if start == nil {
+			k, v = c.Last()
+		} else {
+			// seek lands on the cursor entry or the one after it
+			k, v = c.Seek(start)
+			if k == nil {
+				k, v = c.Last()
+			}
+			for k != nil && string(k) >= string(start) {
+				k, v = c.Prev()
+			}
+		}
+
+		for ; k != nil; k, v = c.Prev() {
+			var entry HistoryEntry
+			err := json.Unmarshal(v, &entry)
+			if err != nil {
+				return err
+			}
+
+			if !q.matches(entry) {
+				continue
+			}
+
+			if q.Limit > 0 && len(entries) >= q.Limit {
+				next = entries[len(entries)-1].Id
+				break
+			}
+
+			entries = append(entries, entry)
+		}
+
+		return nil
+	})
+
+	return entries, next, err
+}
+
+// PruneHistory deletes entries older than before, if set, and then the
+// oldest entries over maxEntries, if set. Returns the number deleted.
+func (d *Database) PruneHistory(before time.Time, maxEntries int) (int, error) {
+	deleted := 0
+
+	err := d.bdb.Update(func(txn *bolt.Tx) error {
+		b := txn.Bucket([]byte(BucketHistory))
+
+		var keys [][]byte
+		var remove [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			if !before.IsZero() {
+				var entry HistoryEntry
+				err := json.Unmarshal(v, &entry)
+				if err != nil {
+					return err
+				}
+
+				if entry.Time.Before(before) {
+					remove = append(remove, append([]byte{}, k...))
+					return nil
+				}
+			}
+
+			keys = append(keys, append([]byte{}, k...))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if maxEntries > 0 && len(keys) > maxEntries {
+			remove = append(remove, keys[:len(keys)-maxEntries]...)
+		}
+
+		for _, k := range remove {
+			err := b.Delete(k)
+			if err != nil {
+				return err
+			}
+		}
+
+		deleted = len(remove)
+		return nil
+	})
+
+	return deleted, err
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistorySourceFilter(t *testing.T) {
+	db := openTestDb(t)
+	now := time.Now()
+
+	for i, source := range []string{"API:127.0.0.1", "REST:192.168.1.2", "MQTT", "APIX"} {
+		err := db.AddHistory(HistoryEntry{
+			Time:   now.Add(time.Duration(i) * time.Second),
+			Text:   source,
+			Source: source,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		source string
+		want   []string
+	}{
+		{"API", []string{"API:127.0.0.1"}},
+		{"api", []string{"API:127.0.0.1"}},
+		{"rest", []string{"REST:192.168.1.2"}},
+		{"REST:192.168.1.2", []string{"REST:192.168.1.2"}},
+		{"REST:10.0.0.1", nil},
+		{"MQTT", []string{"MQTT"}},
+		{"", []string{"APIX", "MQTT", "REST:192.168.1.2", "API:127.0.0.1"}},
+	}
+
+	for _, tt := range tests {
+		entries, _, err := db.GetHistory(HistoryQuery{Source: tt.source})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, e := range entries {
+			got = append(got, e.Source)
+		}
+
+		if len(got) != len(tt.want) {
+			t.Errorf("source %q: got %v, want %v", tt.source, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("source %q: got %v, want %v", tt.source, got, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -43,6 +43,8 @@ type Mapping struct {
 	Match    string `json:"match"`
 	Pattern  string `json:"pattern"`
 	Override string `json:"override"`
+	// Profile the mapping belongs to, empty when no profile is active.
+	Profile string `json:"profile,omitempty"`
 }
 
 func mappingKey(id string) []byte {
@@ -184,12 +186,29 @@ func (d *Database) GetAllMappings() ([]Mapping, error) {
 	return ms, err
 }
 
-func (d *Database) GetEnabledMappings() ([]Mapping, error) {
+// GetProfileMappings returns all mappings belonging to a profile.
+func (d *Database) GetProfileMappings(profile string) ([]Mapping, error) {
 	ms, err := d.GetAllMappings()
 	if err != nil {
 		return nil, err
 	}
 
+	var pms = make([]Mapping, 0)
+	for _, m := range ms {
+		if m.Profile == profile {
+			pms = append(pms, m)
+		}
+	}
+
+	return pms, nil
+}
+
+func (d *Database) GetEnabledMappings(profile string) ([]Mapping, error) {
+	ms, err := d.GetProfileMappings(profile)
+	if err != nil {
+		return nil, err
+	}
+
 	var enabled = make([]Mapping, 0)
 	for _, m := range ms {
 		if m.Enabled {
@@ -0,0 +1,280 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Key in the state bucket of the playlist which was active when the
+// service last ran.
+const activePlaylistKey = "active_playlist"
+
+type PlaylistItem struct {
+	Name      string `json:"name"`
+	ZapScript string `json:"zapscript"`
+}
+
+type Playlist struct {
+	Id    string         `json:"id"`
+	Added int64          `json:"added"`
+	Name  string         `json:"name"`
+	Items []PlaylistItem `json:"items"`
+	PlaylistOptions
+}
+
+// Default play options of a playlist. Repeat is all, one or none and
+// Advance is in minutes.
+type PlaylistOptions struct {
+	Shuffle       bool    `json:"shuffle"`
+	Repeat        string  `json:"repeat"`
+	Advance       float64 `json:"advance"`
+	AdvanceOnStop bool    `json:"advanceOnStop"`
+}
+
+// Snapshot of the active playlist and its position.
+type ActivePlaylist struct {
+	Name  string         `json:"name"`
+	Items []PlaylistItem `json:"items"`
+	Index int            `json:"index"`
+	Order []int          `json:"order"`
+	PlaylistOptions
+}
+
+func playlistKey(id string) []byte {
+	return []byte(fmt.Sprintf("playlists:%s", id))
+}
+
+func validatePlaylist(p Playlist) error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("missing playlist name")
+	}
+
+	switch p.Repeat {
+	case "", "all", "one", "none":
+	default:
+		return fmt.Errorf("invalid repeat mode: %s", p.Repeat)
+	}
+
+	if p.Advance < 0 {
+		return errors.New("invalid advance minutes")
+	}
+
+	for i, item := range p.Items {
+		if strings.TrimSpace(item.ZapScript) == "" {
+			return fmt.Errorf("playlist item %d has no zapscript", i+1)
+		}
+	}
+
+	return nil
+}
+
+// Names are matched case-insensitively and must be unique.
+func findPlaylist(b *bolt.Bucket, name string) (Playlist, bool, error) {
+	c := b.Cursor()
+	prefix := []byte("playlists:")
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		var p Playlist
+		err := json.Unmarshal(v, &p)
+		if err != nil {
+			return p, false, err
+		}
+
+		if strings.EqualFold(strings.TrimSpace(p.Name), strings.TrimSpace(name)) {
+			p.Id = strings.TrimPrefix(string(k), string(prefix))
+			return p, true, nil
+		}
+	}
+
+	return Playlist{}, false, nil
+}
+
+func (d *Database) AddPlaylist(p Playlist) error {
+	err := validatePlaylist(p)
+	if err != nil {
+		return err
+	}
+
+	p.Added = time.Now().Unix()
+
+	return d.bdb.Update(func(txn *bolt.Tx) error {
+		b := txn.Bucket([]byte(BucketPlaylists))
+
+		_, exists, err := findPlaylist(b, p.Name)
+		if err != nil {
+			return err
+		} else if exists {
+			return fmt.Errorf("playlist already exists: %s", p.Name)
+		}
+
+		id, _ := b.NextSequence()
+		p.Id = strconv.Itoa(int(id))
+
+		data, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+
+		return b.Put(playlistKey(p.Id), data)
+	})
+}
+
+func (d *Database) GetPlaylist(id string) (Playlist, error) {
+	var p Playlist
+
+	err := d.bdb.View(func(txn *bolt.Tx) error {
+		b := txn.Bucket([]byte(BucketPlaylists))
+
+		v := b.Get(playlistKey(id))
+		if v == nil {
+			return fmt.Errorf("playlist not found: %s", id)
+		}
+
+		return json.Unmarshal(v, &p)
+	})
+
+	p.Id = id
+
+	return p, err
+}
+
+func (d *Database) GetPlaylistByName(name string) (Playlist, error) {
+	var p Playlist
+
+	err := d.bdb.View(func(txn *bolt.Tx) error {
+		var exists bool
+		var err error
+		p, exists, err = findPlaylist(txn.Bucket([]byte(BucketPlaylists)), name)
+		if err != nil {
+			return err
+		} else if !exists {
+			return fmt.Errorf("playlist not found: %s", name)
+		}
+		return nil
+	})
+
+	return p, err
+}
+
+func (d *Database) UpdatePlaylist(id string, p Playlist) error {
+	err := validatePlaylist(p)
+	if err != nil {
+		return err
+	}
+
+	p.Id = id
+
+	return d.bdb.Update(func(txn *bolt.Tx) error {
+		b := txn.Bucket([]byte(BucketPlaylists))
+
+		if b.Get(playlistKey(id)) == nil {
+			return fmt.Errorf("playlist not found: %s", id)
+		}
+
+		other, exists, err := findPlaylist(b, p.Name)
+		if err != nil {
+			return err
+		} else if exists && other.Id != id {
+			return fmt.Errorf("playlist already exists: %s", p.Name)
+		}
+
+		data, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+
+		return b.Put(playlistKey(id), data)
+	})
+}
+
+func (d *Database) DeletePlaylist(id string) error {
+	return d.bdb.Update(func(txn *bolt.Tx) error {
+		b := txn.Bucket([]byte(BucketPlaylists))
+		return b.Delete(playlistKey(id))
+	})
+}
+
+func (d *Database) GetAllPlaylists() ([]Playlist, error) {
+	var ps = make([]Playlist, 0)
+
+	err := d.bdb.View(func(txn *bolt.Tx) error {
+		b := txn.Bucket([]byte(BucketPlaylists))
+
+		c := b.Cursor()
+		prefix := []byte("playlists:")
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var p Playlist
+			err := json.Unmarshal(v, &p)
+			if err != nil {
+				return err
+			}
+
+			p.Id = strings.TrimPrefix(string(k), string(prefix))
+
+			ps = append(ps, p)
+		}
+
+		return nil
+	})
+
+	return ps, err
+}
+
+// SetActivePlaylist stores the active playlist so it can be restored after a
+// restart. A nil playlist clears it.
+func (d *Database) SetActivePlaylist(ap *ActivePlaylist) error {
+	return d.bdb.Update(func(txn *bolt.Tx) error {
+		b := txn.Bucket([]byte(BucketState))
+
+		if ap == nil {
+			return b.Delete([]byte(activePlaylistKey))
+		}
+
+		data, err := json.Marshal(ap)
+		if err != nil {
+			return err
+		}
+
+		return b.Put([]byte(activePlaylistKey), data)
+	})
+}
+
+// GetActivePlaylist returns the stored active playlist, or nil if there
+// isn't one or it's no longer valid.
+func (d *Database) GetActivePlaylist() (*ActivePlaylist, error) {
+	var ap *ActivePlaylist
+
+	err := d.bdb.View(func(txn *bolt.Tx) error {
+		b := txn.Bucket([]byte(BucketState))
+
+		v := b.Get([]byte(activePlaylistKey))
+		if v == nil {
+			return nil
+		}
+
+		var stored ActivePlaylist
+		err := json.Unmarshal(v, &stored)
+		if err != nil {
+			return err
+		}
+
+		if len(stored.Items) == 0 || stored.Index < 0 || stored.Index >= len(stored.Items) {
+			return nil
+		}
+
+		if stored.Order != nil && len(stored.Order) != len(stored.Items) {
+			stored.Order = nil
+		}
+
+		ap = &stored
+		return nil
+	})
+
+	return ap, err
+}
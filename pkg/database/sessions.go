@@ -0,0 +1,165 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// PlaySession is a single period of media running, from its started
+// notification to its stopped notification.
+type PlaySession struct {
+	Id         string    `json:"id"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Ended      bool      `json:"ended"`
+	SystemId   string    `json:"systemId"`
+	SystemName string    `json:"systemName"`
+	MediaName  string    `json:"mediaName"`
+	MediaPath  string    `json:"mediaPath"`
+	LauncherId string    `json:"launcherId"`
+	TokenType  string    `json:"tokenType"`
+	TokenUID   string    `json:"tokenUid"`
+	TokenText  string    `json:"tokenText"`
+}
+
+// Duration of the session. Sessions still running are counted up to their
+// last recorded end time.
+func (s PlaySession) Duration() time.Duration {
+	if s.End.Before(s.Start) {
+		return 0
+	}
+	return s.End.Sub(s.Start)
+}
+
+func sessionKey(id uint64) []byte {
+	// zero padded so sessions sort in the order they were started
+	return []byte(fmt.Sprintf("%020d", id))
+}
+
+func parseSessionId(id string) (uint64, error) {
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid session id: %s", id)
+	}
+	return n, nil
+}
+
+// AddPlaySession stores a new session and returns its ID.
+func (d *Database) AddPlaySession(s PlaySession) (string, error) {
+	var id string
+
+	err := d.bdb.Update(func(txn *bolt.Tx) error {
+		b := txn.Bucket([]byte(BucketPlaySessions))
+
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		s.Id = strconv.FormatUint(seq, 10)
+
+		data, err := json.Marshal(s)
+		if err != nil {
+			return err
+		}
+
+		id = s.Id
+		return b.Put(sessionKey(seq), data)
+	})
+
+	return id, err
+}
+
+func (d *Database) UpdatePlaySession(s PlaySession) error {
+	seq, err := parseSessionId(s.Id)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	return d.bdb.Update(func(txn *bolt.Tx) error {
+		b := txn.Bucket([]byte(BucketPlaySessions))
+		if b.Get(sessionKey(seq)) == nil {
+			return fmt.Errorf("session not found: %s", s.Id)
+		}
+		return b.Put(sessionKey(seq), data)
+	})
+}
+
+// GetPlaySessions returns all sessions started at or after since, newest
+// first. A zero since returns every session.
+func (d *Database) GetPlaySessions(since time.Time) ([]PlaySession, error) {
+	var ss = make([]PlaySession, 0)
+
+	err := d.bdb.View(func(txn *bolt.Tx) error {
+		b := txn.Bucket([]byte(BucketPlaySessions))
+
+		c := b.Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var s PlaySession
+			err := json.Unmarshal(v, &s)
+			if err != nil {
+				return err
+			}
+
+			if !since.IsZero() && s.Start.Before(since) {
+				break
+			}
+
+			ss = append(ss, s)
+		}
+
+		return nil
+	})
+
+	return ss, err
+}
+
+// CloseOpenPlaySessions marks any sessions left running, e.g. by the service
+// being killed, as ended at their last recorded end time.
+func (d *Database) CloseOpenPlaySessions() error {
+	return d.bdb.Update(func(txn *bolt.Tx) error {
+		b := txn.Bucket([]byte(BucketPlaySessions))
+
+		updated := make(map[string][]byte)
+		err := b.ForEach(func(k, v []byte) error {
+			var s PlaySession
+			err := json.Unmarshal(v, &s)
+			if err != nil {
+				return err
+			}
+
+			if s.Ended {
+				return nil
+			}
+
+			s.Ended = true
+			data, err := json.Marshal(s)
+			if err != nil {
+				return err
+			}
+			updated[string(k)] = data
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for k, v := range updated {
+			err := b.Put([]byte(k), v)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPlaySessionDuration(t *testing.T) {
+	start := time.Now()
+
+	s := PlaySession{Start: start, End: start.Add(90 * time.Second)}
+	if s.Duration() != 90*time.Second {
+		t.Errorf("unexpected duration: %s", s.Duration())
+	}
+
+	s = PlaySession{Start: start, End: start.Add(-time.Second)}
+	if s.Duration() != 0 {
+		t.Errorf("end before start should be 0: %s", s.Duration())
+	}
+}
+
+func TestPlaySessions(t *testing.T) {
+	db := openTestDb(t)
+	start := time.Now().Add(-time.Hour)
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		id, err := db.AddPlaySession(PlaySession{
+			Start:    start.Add(time.Duration(i) * 10 * time.Minute),
+			End:      start.Add(time.Duration(i) * 10 * time.Minute),
+			SystemId: "NES",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	ss, err := db.GetPlaySessions(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ss) != 3 || ss[0].Id != ids[2] || ss[2].Id != ids[0] {
+		t.Fatalf("sessions not newest first: %+v", ss)
+	}
+
+	ss, err = db.GetPlaySessions(start.Add(5 * time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ss) != 2 {
+		t.Errorf("got %d sessions since, want 2", len(ss))
+	}
+
+	s := ss[0]
+	s.End = s.Start.Add(5 * time.Minute)
+	s.Ended = true
+	err = db.UpdatePlaySession(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.UpdatePlaySession(PlaySession{Id: "999"}); err == nil {
+		t.Error("expected error updating missing session")
+	}
+	if err := db.UpdatePlaySession(PlaySession{Id: "bad"}); err == nil {
+		t.Error("expected error updating invalid session id")
+	}
+
+	err = db.CloseOpenPlaySessions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ss, err = db.GetPlaySessions(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range ss {
+		if !s.Ended {
+			t.Errorf("session %s left open", s.Id)
+		}
+	}
+
+	if ss[0].Duration() != 5*time.Minute {
+		t.Errorf("updated end time lost: %s", ss[0].Duration())
+	}
+	if ss[1].Duration() != 0 {
+		t.Errorf("closed session end time changed: %s", ss[1].Duration())
+	}
+}
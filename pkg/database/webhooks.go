@@ -0,0 +1,280 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Number of delivery log entries kept for each webhook.
+const MaxWebhookDeliveries = 100
+
+type Webhook struct {
+	Id      string   `json:"id"`
+	Added   int64    `json:"added"`
+	Label   string   `json:"label"`
+	Enabled bool     `json:"enabled"`
+	Url     string   `json:"url"`
+	Secret  string   `json:"secret"`
+	Events  []string `json:"events"`
+}
+
+type WebhookDelivery struct {
+	Id         string    `json:"id"`
+	WebhookId  string    `json:"webhookId"`
+	Time       time.Time `json:"time"`
+	Event      string    `json:"event"`
+	Attempts   int       `json:"attempts"`
+	Success    bool      `json:"success"`
+	StatusCode int       `json:"statusCode"`
+	Error      string    `json:"error"`
+}
+
+func webhookKey(id string) []byte {
+	return []byte(fmt.Sprintf("webhooks:%s", id))
+}
+
+func deliveriesPrefix(webhookId string) []byte {
+	return []byte(fmt.Sprintf("deliveries:%s:", webhookId))
+}
+
+func deliveryKey(webhookId string, seq uint64) []byte {
+	// zero padded so deliveries sort in order they were added
+	return []byte(fmt.Sprintf("deliveries:%s:%020d", webhookId, seq))
+}
+
+// ValidateWebhookUrl checks the given URL is an absolute HTTP(S) address.
+func ValidateWebhookUrl(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return fmt.Errorf("invalid url: %s", s)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid url scheme: %s", u.Scheme)
+	}
+
+	if u.Host == "" {
+		return fmt.Errorf("missing url host: %s", s)
+	}
+
+	return nil
+}
+
+// Subscribed returns true if the webhook should receive the given event.
+// A webhook with no events set is subscribed to all of them.
+func (w Webhook) Subscribed(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+
+	for _, e := range w.Events {
+		if strings.EqualFold(e, event) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AddWebhook stores a new webhook and returns its ID.
+func (d *Database) AddWebhook(w Webhook) (string, error) {
+	err := ValidateWebhookUrl(w.Url)
+	if err != nil {
+		return "", err
+	}
+
+	w.Added = time.Now().Unix()
+
+	wd, err := json.Marshal(w)
+	if err != nil {
+		return "", err
+	}
+
+	var id string
+	err = d.bdb.Update(func(txn *bolt.Tx) error {
+		b := txn.Bucket([]byte(BucketWebhooks))
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		id = strconv.FormatUint(seq, 10)
+		return b.Put(webhookKey(id), wd)
+	})
+
+	return id, err
+}
+
+func (d *Database) GetWebhook(id string) (Webhook, error) {
+	var w Webhook
+
+	err := d.bdb.View(func(txn *bolt.Tx) error {
+		b := txn.Bucket([]byte(BucketWebhooks))
+
+		v := b.Get(webhookKey(id))
+		if v == nil {
+			return fmt.Errorf("webhook not found: %s", id)
+		}
+
+		return json.Unmarshal(v, &w)
+	})
+
+	w.Id = id
+
+	return w, err
+}
+
+// DeleteWebhook removes a webhook and its delivery log.
+func (d *Database) DeleteWebhook(id string) error {
+	return d.bdb.Update(func(txn *bolt.Tx) error {
+		b := txn.Bucket([]byte(BucketWebhooks))
+		err := b.Delete(webhookKey(id))
+		if err != nil {
+			return err
+		}
+
+		db := txn.Bucket([]byte(BucketWebhookDeliveries))
+		c := db.Cursor()
+		prefix := deliveriesPrefix(id)
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Seek(prefix) {
+			err := db.Delete(k)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (d *Database) UpdateWebhook(id string, w Webhook) error {
+	err := ValidateWebhookUrl(w.Url)
+	if err != nil {
+		return err
+	}
+
+	wd, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+
+	return d.bdb.Update(func(txn *bolt.Tx) error {
+		b := txn.Bucket([]byte(BucketWebhooks))
+		if b.Get(webhookKey(id)) == nil {
+			return fmt.Errorf("webhook not found: %s", id)
+		}
+		return b.Put(webhookKey(id), wd)
+	})
+}
+
+func (d *Database) GetAllWebhooks() ([]Webhook, error) {
+	var ws = make([]Webhook, 0)
+
+	err := d.bdb.View(func(txn *bolt.Tx) error {
+		b := txn.Bucket([]byte(BucketWebhooks))
+
+		c := b.Cursor()
+		prefix := []byte("webhooks:")
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var w Webhook
+			err := json.Unmarshal(v, &w)
+			if err != nil {
+				return err
+			}
+
+			w.Id = strings.TrimPrefix(string(k), string(prefix))
+
+			ws = append(ws, w)
+		}
+
+		return nil
+	})
+
+	return ws, err
+}
+
+func (d *Database) GetEnabledWebhooks() ([]Webhook, error) {
+	ws, err := d.GetAllWebhooks()
+	if err != nil {
+		return nil, err
+	}
+
+	var enabled = make([]Webhook, 0)
+	for _, w := range ws {
+		if w.Enabled {
+			enabled = append(enabled, w)
+		}
+	}
+
+	return enabled, nil
+}
+
+// AddWebhookDelivery records the outcome of a webhook delivery, pruning the
+// oldest entries so only the last MaxWebhookDeliveries are kept.
+func (d *Database) AddWebhookDelivery(wd WebhookDelivery) error {
+	return d.bdb.Update(func(txn *bolt.Tx) error {
+		b := txn.Bucket([]byte(BucketWebhookDeliveries))
+
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		wd.Id = strconv.FormatUint(seq, 10)
+
+		data, err := json.Marshal(wd)
+		if err != nil {
+			return err
+		}
+
+		err = b.Put(deliveryKey(wd.WebhookId, seq), data)
+		if err != nil {
+			return err
+		}
+
+		var keys [][]byte
+		c := b.Cursor()
+		prefix := deliveriesPrefix(wd.WebhookId)
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			keys = append(keys, append([]byte{}, k...))
+		}
+
+		for i := 0; i < len(keys)-MaxWebhookDeliveries; i++ {
+			err := b.Delete(keys[i])
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetWebhookDeliveries returns the delivery log for a webhook, newest first.
+func (d *Database) GetWebhookDeliveries(webhookId string) ([]WebhookDelivery, error) {
+	var wds = make([]WebhookDelivery, 0)
+
+	err := d.bdb.View(func(txn *bolt.Tx) error {
+		b := txn.Bucket([]byte(BucketWebhookDeliveries))
+
+		c := b.Cursor()
+		prefix := deliveriesPrefix(webhookId)
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var wd WebhookDelivery
+			err := json.Unmarshal(v, &wd)
+			if err != nil {
+				return err
+			}
+			wds = append([]WebhookDelivery{wd}, wds...)
+		}
+
+		return nil
+	})
+
+	return wds, err
+}
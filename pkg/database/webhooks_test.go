@@ -0,0 +1,189 @@
+package database
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func openTestDb(t *testing.T) *Database {
+	db, err := OpenFile(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+func TestValidateWebhookUrl(t *testing.T) {
+	for _, u := range []string{"http://localhost:8080/hook", "https://example.com"} {
+		if err := ValidateWebhookUrl(u); err != nil {
+			t.Errorf("%s: %s", u, err)
+		}
+	}
+
+	for _, u := range []string{"", "example.com", "ftp://example.com", "http://"} {
+		if err := ValidateWebhookUrl(u); err == nil {
+			t.Errorf("%s: expected error", u)
+		}
+	}
+}
+
+func TestWebhookSubscribed(t *testing.T) {
+	all := Webhook{}
+	if !all.Subscribed("tokens.added") {
+		t.Error("webhook without events should get every event")
+	}
+
+	some := Webhook{Events: []string{"media.started"}}
+	if !some.Subscribed("Media.Started") || some.Subscribed("tokens.added") {
+		t.Error("webhook events not matched")
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	db := openTestDb(t)
+
+	_, err := db.AddWebhook(Webhook{Url: "not a url"})
+	if err == nil {
+		t.Error("expected invalid url error")
+	}
+
+	id, err := db.AddWebhook(Webhook{
+		Label:   "first",
+		Enabled: true,
+		Url:     "http://localhost/first",
+		Secret:  "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id2, err := db.AddWebhook(Webhook{
+		Label: "second",
+		Url:   "http://localhost/second",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id == id2 {
+		t.Fatalf("webhooks have the same id: %s", id)
+	}
+
+	w, err := db.GetWebhook(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Id != id || w.Label != "first" || w.Secret != "secret" || w.Added == 0 {
+		t.Errorf("unexpected webhook: %+v", w)
+	}
+
+	ws, err := db.GetAllWebhooks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ws) != 2 {
+		t.Errorf("got %d webhooks, want 2", len(ws))
+	}
+
+	enabled, err := db.GetEnabledWebhooks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(enabled) != 1 || enabled[0].Id != id {
+		t.Errorf("unexpected enabled webhooks: %+v", enabled)
+	}
+
+	w.Label = "updated"
+	err = db.UpdateWebhook(id, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err = db.GetWebhook(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Label != "updated" {
+		t.Errorf("webhook not updated: %+v", w)
+	}
+
+	if err := db.UpdateWebhook("999", w); err == nil {
+		t.Error("expected error updating missing webhook")
+	}
+
+	err = db.DeleteWebhook(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.GetWebhook(id); err == nil {
+		t.Error("webhook not deleted")
+	}
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	db := openTestDb(t)
+
+	id, err := db.AddWebhook(Webhook{Url: "http://localhost/hook"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// deliveries of another webhook whose id shares a prefix aren't mixed in
+	other := id + "0"
+
+	start := time.Now()
+	total := MaxWebhookDeliveries + 5
+	for i := 0; i < total; i++ {
+		for _, wid := range []string{id, other} {
+			err := db.AddWebhookDelivery(WebhookDelivery{
+				WebhookId: wid,
+				Time:      start.Add(time.Duration(i) * time.Second),
+				Event:     strconv.Itoa(i),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	wds, err := db.GetWebhookDeliveries(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(wds) != MaxWebhookDeliveries {
+		t.Fatalf("got %d deliveries, want %d", len(wds), MaxWebhookDeliveries)
+	}
+
+	if wds[0].Event != strconv.Itoa(total-1) {
+		t.Errorf("newest delivery not first: %s", wds[0].Event)
+	}
+	if wds[len(wds)-1].Event != strconv.Itoa(total-MaxWebhookDeliveries) {
+		t.Errorf("oldest deliveries not pruned: %s", wds[len(wds)-1].Event)
+	}
+
+	err = db.DeleteWebhook(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wds, err = db.GetWebhookDeliveries(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wds) != 0 {
+		t.Errorf("deliveries not deleted with webhook: %d", len(wds))
+	}
+
+	wds, err = db.GetWebhookDeliveries(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wds) != MaxWebhookDeliveries {
+		t.Errorf("other webhook's deliveries changed: %d", len(wds))
+	}
+}
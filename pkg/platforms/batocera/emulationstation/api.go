@@ -0,0 +1,126 @@
+package emulationstation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultUrl is the address of the HTTP API EmulationStation runs locally.
+const DefaultUrl = "http://localhost:1234"
+
+const requestTimeout = 2 * time.Second
+
+// Game is a game as reported by the API.
+type Game struct {
+	Id         string `json:"id"`
+	Path       string `json:"path"`
+	Name       string `json:"name"`
+	SystemName string `json:"systemName"`
+}
+
+type Client struct {
+	url  string
+	http *http.Client
+}
+
+func NewClient(url string) *Client {
+	return &Client{
+		url: strings.TrimSuffix(url, "/"),
+		http: &http.Client{
+			Timeout: requestTimeout,
+		},
+	}
+}
+
+func (c *Client) do(method string, path string, body string) (int, []byte, error) {
+	req, err := http.NewRequest(method, c.url+path, strings.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, nil, err
+	}
+
+	return resp.StatusCode, data, nil
+}
+
+// RunningGame returns the game EmulationStation has running, if any.
+func (c *Client) RunningGame() (Game, bool, error) {
+	var game Game
+
+	status, data, err := c.do(http.MethodGet, "/runningGame", "")
+	if err != nil {
+		return game, false, err
+	}
+
+	// no game running is reported with a different success status
+	if status != http.StatusOK {
+		return game, false, nil
+	}
+
+	err = json.Unmarshal(data, &game)
+	if err != nil {
+		return game, false, err
+	}
+
+	return game, game.Path != "", nil
+}
+
+// Launch asks EmulationStation to launch a game file, using the emulator
+// configured for its system.
+func (c *Client) Launch(path string) error {
+	status, data, err := c.do(http.MethodPost, "/launch", path)
+	if err != nil {
+		return err
+	}
+
+	if status != http.StatusOK {
+		return fmt.Errorf("error launching game: %d %s", status, strings.TrimSpace(string(data)))
+	}
+
+	return nil
+}
+
+// KillEmulator stops the running game and returns to the EmulationStation
+// menu.
+func (c *Client) KillEmulator() error {
+	status, data, err := c.do(http.MethodGet, "/emukill", "")
+	if err != nil {
+		return err
+	}
+
+	if status != http.StatusOK {
+		return fmt.Errorf("error killing emulator: %d %s", status, strings.TrimSpace(string(data)))
+	}
+
+	return nil
+}
+
+// Notify shows a popup message in EmulationStation. It's shown over the
+// menu, not over a running game.
+func (c *Client) Notify(text string) error {
+	status, data, err := c.do(http.MethodPost, "/notify", text)
+	if err != nil {
+		return err
+	}
+
+	if status != http.StatusOK {
+		return fmt.Errorf("error showing notification: %d %s", status, strings.TrimSpace(string(data)))
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package emulationstation
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// Stands in for the EmulationStation API, with a game running once one has
+// been launched.
+func newTestServer(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	running := ""
+	notice := ""
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/runningGame", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if running == "" {
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"msg":"NO GAME RUNNING"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"1","path":"` + running +
+			`","name":"Sonic","systemName":"megadrive"}`))
+	})
+	mux.HandleFunc("/launch", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || len(body) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		running = string(body)
+		mu.Unlock()
+		_, _ = w.Write([]byte("OK"))
+	})
+	mux.HandleFunc("/emukill", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		running = ""
+		mu.Unlock()
+		_, _ = w.Write([]byte("OK"))
+	})
+
+	mux.HandleFunc("/notify", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || len(body) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		notice = string(body)
+		mu.Unlock()
+		_, _ = w.Write([]byte("OK"))
+	})
+	mux.HandleFunc("/notice", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = w.Write([]byte(notice))
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestClient(t *testing.T) {
+	c := NewClient(newTestServer(t).URL + "/")
+
+	_, running, err := c.RunningGame()
+	if err != nil {
+		t.Fatal(err)
+	} else if running {
+		t.Fatal("expected no game running")
+	}
+
+	path := "/userdata/roms/megadrive/sonic.md"
+	err = c.Launch(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	game, running, err := c.RunningGame()
+	if err != nil {
+		t.Fatal(err)
+	} else if !running {
+		t.Fatal("expected game running")
+	}
+
+	want := Game{Id: "1", Path: path, Name: "Sonic", SystemName: "megadrive"}
+	if game != want {
+		t.Errorf("got %+v, want %+v", game, want)
+	}
+
+	err = c.KillEmulator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, running, err = c.RunningGame()
+	if err != nil {
+		t.Fatal(err)
+	} else if running {
+		t.Error("expected no game running after kill")
+	}
+
+	err = c.Launch("")
+	if err == nil {
+		t.Error("expected error for failed launch")
+	}
+
+	err = c.Notify("Launching Sonic")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, notice, err := c.do(http.MethodGet, "/notice", "")
+	if err != nil {
+		t.Fatal(err)
+	} else if string(notice) != "Launching Sonic" {
+		t.Errorf("got notice %q, want %q", notice, "Launching Sonic")
+	}
+
+	err = c.Notify("")
+	if err == nil {
+		t.Error("expected error for empty notice")
+	}
+}
@@ -0,0 +1,121 @@
+// Package emulationstation reads the system configuration of Batocera's
+// EmulationStation frontend and talks to its local HTTP API.
+package emulationstation
+
+import (
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// SystemsFile is the system configuration shipped with Batocera.
+	SystemsFile = "/usr/share/emulationstation/es_systems.cfg"
+	// UserSystemsGlob matches extra system configuration files added by the
+	// user, which can also replace shipped systems with the same name.
+	UserSystemsGlob = "/userdata/system/configs/emulationstation/es_systems_*.cfg"
+)
+
+type System struct {
+	// Short name of the system, which is also the name of its ROM folder.
+	Name       string
+	FullName   string
+	Path       string
+	Extensions []string
+	Platforms  []string
+}
+
+type xmlSystemList struct {
+	Systems []struct {
+		Name      string `xml:"name"`
+		FullName  string `xml:"fullname"`
+		Path      string `xml:"path"`
+		Extension string `xml:"extension"`
+		Platform  string `xml:"platform"`
+	} `xml:"system"`
+}
+
+// ParseSystems reads the systems from an es_systems.cfg file. Extensions
+// are lowercased and deduplicated.
+func ParseSystems(data []byte) ([]System, error) {
+	var list xmlSystemList
+	err := xml.Unmarshal(data, &list)
+	if err != nil {
+		return nil, err
+	}
+
+	systems := make([]System, 0, len(list.Systems))
+	for _, s := range list.Systems {
+		if s.Name == "" {
+			continue
+		}
+
+		system := System{
+			Name:     s.Name,
+			FullName: s.FullName,
+			Path:     s.Path,
+		}
+
+		seen := make(map[string]bool)
+		for _, ext := range strings.Fields(s.Extension) {
+			ext = strings.ToLower(ext)
+			if !seen[ext] {
+				seen[ext] = true
+				system.Extensions = append(system.Extensions, ext)
+			}
+		}
+
+		for _, p := range strings.FieldsFunc(s.Platform, func(r rune) bool {
+			return r == ',' || r == ' '
+		}) {
+			system.Platforms = append(system.Platforms, p)
+		}
+
+		systems = append(systems, system)
+	}
+
+	return systems, nil
+}
+
+// ReadSystems reads and combines the systems from each file in order.
+// Systems in later files replace earlier ones with the same name. Missing
+// files are skipped.
+func ReadSystems(paths ...string) ([]System, error) {
+	var systems []System
+	index := make(map[string]int)
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		ss, err := ParseSystems(data)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range ss {
+			if i, ok := index[s.Name]; ok {
+				systems[i] = s
+				continue
+			}
+			index[s.Name] = len(systems)
+			systems = append(systems, s)
+		}
+	}
+
+	return systems, nil
+}
+
+// SystemFiles returns the shipped system configuration file followed by any
+// added by the user.
+func SystemFiles() []string {
+	files := []string{SystemsFile}
+	user, _ := filepath.Glob(UserSystemsGlob)
+	return append(files, user...)
+}
@@ -0,0 +1,73 @@
+package emulationstation
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testSystems = `<?xml version="1.0"?>
+<systemList>
+	<system>
+		<name>megadrive</name>
+		<fullname>Mega Drive</fullname>
+		<path>/userdata/roms/megadrive</path>
+		<extension>.bin .gen .md .BIN .zip</extension>
+		<platform>megadrive, genesis</platform>
+	</system>
+	<system>
+		<name>snes</name>
+		<fullname>Super Nintendo</fullname>
+		<path>/userdata/roms/snes</path>
+		<extension>.sfc .smc</extension>
+	</system>
+	<system>
+		<fullname>No Name</fullname>
+	</system>
+</systemList>
+`
+
+func TestReadSystems(t *testing.T) {
+	dir := t.TempDir()
+
+	shipped := filepath.Join(dir, "es_systems.cfg")
+	err := os.WriteFile(shipped, []byte(testSystems), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := filepath.Join(dir, "es_systems_snes.cfg")
+	err = os.WriteFile(user, []byte(`<systemList><system>
+		<name>snes</name>
+		<path>/userdata/roms/snes-hacks</path>
+		<extension>.sfc</extension>
+	</system></systemList>`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	systems, err := ReadSystems(shipped, user, filepath.Join(dir, "missing.cfg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []System{
+		{
+			Name:       "megadrive",
+			FullName:   "Mega Drive",
+			Path:       "/userdata/roms/megadrive",
+			Extensions: []string{".bin", ".gen", ".md", ".zip"},
+			Platforms:  []string{"megadrive", "genesis"},
+		},
+		{
+			Name:       "snes",
+			Path:       "/userdata/roms/snes-hacks",
+			Extensions: []string{".sfc"},
+		},
+	}
+
+	if !reflect.DeepEqual(systems, want) {
+		t.Errorf("got %+v, want %+v", systems, want)
+	}
+}
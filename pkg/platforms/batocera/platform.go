@@ -2,25 +2,63 @@ package batocera
 
 import (
 	"errors"
-	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
-	"github.com/ZaparooProject/zaparoo-core/pkg/config"
-	"github.com/ZaparooProject/zaparoo-core/pkg/service/tokens"
-	"github.com/ZaparooProject/zaparoo-core/pkg/utils"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/ZaparooProject/zaparoo-core/pkg/database/gamesdb"
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
 	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms/batocera/emulationstation"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms/proctracker"
 	"github.com/ZaparooProject/zaparoo-core/pkg/readers"
 	"github.com/ZaparooProject/zaparoo-core/pkg/readers/file"
 	"github.com/ZaparooProject/zaparoo-core/pkg/readers/libnfc"
 	"github.com/ZaparooProject/zaparoo-core/pkg/readers/simple_serial"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/tokens"
+	"github.com/ZaparooProject/zaparoo-core/pkg/utils"
 	"github.com/rs/zerolog/log"
 )
 
+const romsDir = "/userdata/roms"
+
+// How often EmulationStation is asked what game is running.
+const esPollInterval = time.Second
+
 type Platform struct {
+	tr proctracker.Tracker
+	es *emulationstation.Client
+
+	systemsOnce sync.Once
+	systems     []emulationstation.System
+
+	mu     sync.RWMutex
+	ns     chan<- models.Notification
+	esGame *proctracker.Media
+	stop   chan struct{}
+}
+
+func (p *Platform) client() *emulationstation.Client {
+	if p.es == nil {
+		p.es = emulationstation.NewClient(emulationstation.DefaultUrl)
+	}
+	return p.es
+}
+
+// esSystems returns the systems configured in EmulationStation. They're
+// read once, a restart is required to pick up new systems.
+func (p *Platform) esSystems() []emulationstation.System {
+	p.systemsOnce.Do(func() {
+		systems, err := emulationstation.ReadSystems(emulationstation.SystemFiles()...)
+		if err != nil {
+			log.Error().Err(err).Msg("error reading es_systems")
+		}
+		p.systems = systems
+	})
+	return p.systems
 }
 
 func (p *Platform) Id() string {
@@ -35,18 +73,160 @@ func (p *Platform) SupportedReaders(cfg *config.Instance) []readers.Reader {
 	}
 }
 
-func (p *Platform) StartPre(_ *config.Instance) error {
+func (p *Platform) StartPre(cfg *config.Instance) error {
+	p.client()
+
+	// launchers used to be named after their system ID
+	vals := cfg.BaseValues()
+	if migrateLauncherIds(&vals, p.esSystems()) {
+		cfg.SetValues(vals)
+		err := cfg.Save()
+		if err != nil {
+			log.Error().Err(err).Msg("error saving renamed launchers")
+		}
+	}
+
 	return nil
 }
 
-func (p *Platform) StartPost(_ *config.Instance, _ chan<- models.Notification) error {
+func (p *Platform) StartPost(_ *config.Instance, ns chan<- models.Notification) error {
+	p.tr.SetNotifications(ns)
+
+	p.mu.Lock()
+	p.ns = ns
+	p.stop = make(chan struct{})
+	p.mu.Unlock()
+
+	go p.watchEs(p.stop)
+
 	return nil
 }
 
 func (p *Platform) Stop() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
 	return nil
 }
 
+func (p *Platform) notify(n models.Notification) {
+	p.mu.RLock()
+	ns := p.ns
+	p.mu.RUnlock()
+	if ns != nil {
+		ns <- n
+	}
+}
+
+// Games launched from the EmulationStation menu or through its API aren't
+// child processes of the service, so the running game is polled from ES.
+func (p *Platform) watchEs(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(esPollInterval):
+		}
+
+		// tracked processes send their own notifications
+		if _, ok := p.tr.Active(); ok {
+			p.setEsGame(nil)
+			continue
+		}
+
+		game, running, err := p.client().RunningGame()
+		if err != nil {
+			// ES isn't running or is restarting
+			log.Debug().Err(err).Msg("error getting running game from es")
+			continue
+		}
+
+		if !running {
+			p.setEsGame(nil)
+			continue
+		}
+
+		m := p.esMedia(game)
+		p.setEsGame(&m)
+	}
+}
+
+func (p *Platform) esMedia(game emulationstation.Game) proctracker.Media {
+	name := game.SystemName
+	if name == "" {
+		name = esSystemFromPath(game.Path)
+	}
+
+	systemId, _ := esSystemId(name)
+	m := proctracker.NewMedia(name, systemId, game.Path)
+	if game.Name != "" {
+		m.Name = game.Name
+	}
+
+	return m
+}
+
+// esSystemFromPath returns the system name from the ROM folder of a path.
+func esSystemFromPath(path string) string {
+	rel := strings.TrimPrefix(path, romsDir+"/")
+	if rel == path {
+		return ""
+	}
+	return strings.Split(rel, "/")[0]
+}
+
+func (p *Platform) setEsGame(m *proctracker.Media) {
+	p.mu.Lock()
+	prev := p.esGame
+	if prev == nil && m == nil {
+		p.mu.Unlock()
+		return
+	} else if prev != nil && m != nil && prev.Path == m.Path {
+		p.mu.Unlock()
+		return
+	}
+	p.esGame = m
+	p.mu.Unlock()
+
+	if prev != nil {
+		p.notify(models.Notification{
+			Method: models.NotificationStopped,
+		})
+	}
+
+	if m != nil {
+		log.Info().Msgf("es running game: %s", m.Path)
+		p.notify(models.Notification{
+			Method: models.NotificationStarted,
+			Params: models.MediaStartedParams{
+				SystemId:   m.SystemId,
+				SystemName: m.SystemName,
+				MediaPath:  m.Path,
+				MediaName:  m.Name,
+			},
+		})
+	}
+}
+
+// active returns the running media, preferring a tracked process over the
+// game reported by ES.
+func (p *Platform) active() proctracker.Media {
+	if m, ok := p.tr.Active(); ok {
+		return m
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.esGame != nil {
+		return *p.esGame
+	}
+
+	return proctracker.Media{}
+}
+
 func (p *Platform) AfterScanHook(token tokens.Token) error {
 	return nil
 }
@@ -57,7 +237,7 @@ func (p *Platform) ReadersUpdateHook(readers map[string]*readers.Reader) error {
 
 func (p *Platform) RootDirs(cfg *config.Instance) []string {
 	return []string{
-		"/userdata/roms",
+		romsDir,
 	}
 }
 
@@ -85,81 +265,68 @@ func (p *Platform) NormalizePath(cfg *config.Instance, path string) string {
 	return path
 }
 
+// LaunchMenu stops any running game and returns to the EmulationStation
+// menu.
 func LaunchMenu() error {
-	return nil
+	return emulationstation.NewClient(emulationstation.DefaultUrl).KillEmulator()
 }
 
 func (p *Platform) KillLauncher() error {
-	return nil
-}
+	if _, ok := p.tr.Active(); ok {
+		return p.tr.Kill()
+	}
 
-func (p *Platform) GetActiveLauncher() string {
-	return ""
+	err := p.client().KillEmulator()
+	if err != nil {
+		return err
+	}
+	p.setEsGame(nil)
+
+	return nil
 }
 
-func (p *Platform) PlayFailSound(cfg *config.Instance) {
+// Custom launchers are tracked like the built-in ones.
+func (p *Platform) startCustom(cmd *exec.Cmd, l platforms.Launcher, path string) error {
+	return p.tr.Start(cmd, proctracker.NewMedia(l.Id, l.SystemId, path))
 }
 
-func (p *Platform) PlaySuccessSound(cfg *config.Instance) {
+func (p *Platform) GetActiveLauncher() string {
+	return p.active().LauncherId
 }
 
 func (p *Platform) ActiveSystem() string {
-	return ""
+	return p.active().SystemId
 }
 
 func (p *Platform) ActiveGame() string {
-	return ""
+	return p.active().Path
 }
 
 func (p *Platform) ActiveGameName() string {
-	return ""
+	return p.active().Name
 }
 
 func (p *Platform) ActiveGamePath() string {
-	return ""
+	return p.active().Path
 }
 
-func (p *Platform) LaunchSystem(cfg *config.Instance, id string) error {
-	log.Info().Msgf("launching system: %s", id)
-	return nil
+func (p *Platform) LaunchSystem(_ *config.Instance, id string) error {
+	return errors.New("launching systems is not supported on batocera: " + id)
 }
 
 func (p *Platform) LaunchFile(cfg *config.Instance, path string) error {
-	log.Info().Msgf("launching file: %s", path)
-
-	relPath := path
-	for _, rf := range p.RootDirs(cfg) {
-		if strings.HasPrefix(relPath, rf+"/") {
-			relPath = strings.TrimPrefix(relPath, rf+"/")
-			break
-		}
-	}
-	log.Info().Msgf("relative path: %s", relPath)
-
-	root := strings.Split(relPath, "/")[0]
-	log.Info().Msgf("root: %s", root)
-
-	systemId := ""
-	for _, launcher := range p.Launchers() {
-		for _, folder := range launcher.Folders {
-			if folder == root {
-				systemId = launcher.SystemId
-				break
-			}
-		}
+	launchers := utils.PathToLaunchers(cfg, p, path)
+	if len(launchers) == 0 {
+		return errors.New("no launcher found for path: " + path)
 	}
+	launcher := launchers[0]
 
-	if systemId == "" {
-		log.Error().Msgf("system not found for path: %s", path)
+	if launcher.AllowListOnly && !cfg.IsLauncherFileAllowed(path) {
+		return errors.New("file not allowed: " + path)
 	}
 
-	for _, launcher := range p.Launchers() {
-		if launcher.SystemId == systemId {
-			return launcher.Launch(cfg, path)
-		}
-	}
-
-	return errors.New("launcher not found")
+	log.Info().Msgf("launching file with %s: %s", launcher.Id, path)
+	return launcher.Launch(cfg, path)
 }
 
 func (p *Platform) KeyboardInput(input string) error {
@@ -182,18 +349,79 @@ func (p *Platform) LookupMapping(_ tokens.Token) (string, bool) {
 	return "", false
 }
 
-func (p *Platform) Launchers() []platforms.Launcher {
-	return []platforms.Launcher{
-		{
-			SystemId:   gamesdb.SystemGenesis,
-			Folders:    []string{"megadrive"},
-			Extensions: []string{".bin", ".gen", ".md", ".sg", ".smd", ".zip", ".7z"},
-			Launch: func(cfg *config.Instance, path string) error {
-				cmd := exec.Command("emulatorlauncher", "-system", "megadrive", "-rom", path)
+// Launchers returns the custom launchers from the config followed by one
+// launcher for each system configured in EmulationStation. Their IDs are
+// the ES system names, like "megadrive", and not the system IDs they were
+// named after in older versions. Old IDs in the config file are renamed
+// by StartPre, but not ones used in tokens.
+func (p *Platform) Launchers(cfg *config.Instance) []platforms.Launcher {
+	var launchers []platforms.Launcher
+
+	for _, system := range p.esSystems() {
+		systemId, ok := esSystemId(system.Name)
+		if !ok {
+			log.Debug().Msgf("unknown es system: %s", system.Name)
+			continue
+		}
+
+		var folders []string
+		rel, err := filepath.Rel(romsDir, system.Path)
+		if err == nil && !strings.HasPrefix(rel, "..") {
+			folders = append(folders, rel)
+		}
+
+		launchers = append(launchers, p.esLauncher(system.Name, systemId, folders, system.Extensions))
+	}
+
+	return append(platforms.CustomLaunchers(cfg, p.startCustom), launchers...)
+}
+
+// esLauncher launches games through the ES API so they use the emulator
+// configured for the system, falling back to running emulatorlauncher
+// directly if ES isn't available. The ES API can't pass launch options, so
+// emulatorlauncher is also run directly if args, env or cwd are set.
+func (p *Platform) esLauncher(
+	name string,
+	systemId string,
+	folders []string,
+	extensions []string,
+) platforms.Launcher {
+	return platforms.Launcher{
+		Id:         name,
+		SystemId:   systemId,
+		Folders:    folders,
+		Extensions: extensions,
+		Launch: func(cfg *config.Instance, path string) error {
+			opts := cfg.LookupLaunchOptions(name, systemId)
+			if len(opts.Args) == 0 && len(opts.Env) == 0 && opts.Cwd == "" {
+				err := p.client().Launch(path)
+				if err == nil {
+					return nil
+				}
+				log.Warn().Err(err).Msg("error launching through es, running emulatorlauncher")
+			}
+
+			cmd := platforms.LaunchCommand(
+				cfg,
+				name,
+				systemId,
+				"emulatorlauncher", "-system", name, "-rom", path,
+			)
+			if cmd.Env == nil {
 				cmd.Env = os.Environ()
-				cmd.Env = append(cmd.Env, "DISPLAY=:0.0")
-				return cmd.Start()
-			},
+			}
+			cmd.Env = append(cmd.Env, "DISPLAY=:0.0")
+			return p.tr.Start(cmd, proctracker.NewMedia(name, systemId, path))
 		},
+		CommandOptions: true,
+	}
+}
+
+// ShowNotice shows the notice as an EmulationStation popup. EmulationStation
+// isn't drawn while a game is running, so notices are only shown in the menu.
+func (p *Platform) ShowNotice(_ *config.Instance, n platforms.Notice) error {
+	if p.GetActiveLauncher() != "" {
+		return platforms.ErrNoticeUnsupported
 	}
+	return p.client().Notify(n.Text)
 }
@@ -0,0 +1,129 @@
+package batocera
+
+import (
+	"strings"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/database/gamesdb"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms/batocera/emulationstation"
+	"github.com/rs/zerolog/log"
+)
+
+// Batocera system names which don't match a gamesdb system ID or alias.
+// Anything not listed here is looked up in gamesdb by name.
+var esSystemIds = map[string]string{
+	"advision":     gamesdb.SystemAdventureVision,
+	"amiga1200":    gamesdb.SystemAmiga,
+	"amiga500":     gamesdb.SystemAmiga,
+	"amstradcpc":   gamesdb.SystemAmstrad,
+	"apple2":       gamesdb.SystemAppleII,
+	"bbc":          gamesdb.SystemBBCMicro,
+	"coco":         gamesdb.SystemCoCo2,
+	"crvision":     gamesdb.SystemCreatiVision,
+	"fbneo":        gamesdb.SystemArcade,
+	"gameandwatch": gamesdb.SystemGameNWatch,
+	"gamecube":     gamesdb.SystemGameCube,
+	"gb":           gamesdb.SystemGameboy,
+	"gbc":          gamesdb.SystemGameboyColor,
+	"lynx":         gamesdb.SystemAtariLynx,
+	"mame":         gamesdb.SystemArcade,
+	"megadrive":    gamesdb.SystemGenesis,
+	"msx1":         gamesdb.SystemMSX,
+	"msx2":         gamesdb.SystemMSX,
+	"n64":          gamesdb.SystemNintendo64,
+	"neocd":        gamesdb.SystemNeoGeoCD,
+	"ngp":          gamesdb.SystemNeoGeoPocket,
+	"ngpc":         gamesdb.SystemNeoGeoPocketColor,
+	"o2em":         gamesdb.SystemOdyssey2,
+	"oricatmos":    gamesdb.SystemOric,
+	"pcengine":     gamesdb.SystemTurboGrafx16,
+	"pcenginecd":   gamesdb.SystemTurboGrafx16CD,
+	"pet":          gamesdb.SystemPET2001,
+	"pv1000":       gamesdb.SystemCasioPV1000,
+	"segacd":       gamesdb.SystemMegaCD,
+	"ti99":         gamesdb.SystemTI994A,
+	"tutor":        gamesdb.SystemTomyTutor,
+	"wswan":        gamesdb.SystemWonderSwan,
+	"wswanc":       gamesdb.SystemWonderSwanColor,
+	"xegs":         gamesdb.SystemAtariXEGS,
+	"zxspectrum":   gamesdb.SystemZXSpectrum,
+}
+
+// esSystemId returns the gamesdb system ID for a Batocera system name.
+func esSystemId(name string) (string, bool) {
+	if id, ok := esSystemIds[name]; ok {
+		return id, true
+	}
+
+	system, err := gamesdb.LookupSystem(name)
+	if err != nil {
+		return "", false
+	}
+
+	return system.Id, true
+}
+
+// renamedLauncher returns the launcher ID for a launcher which was named
+// after its system ID, before launchers were generated from es_systems and
+// named after the ES system. It's only renamed if a single ES system has
+// that system ID.
+func renamedLauncher(id string, systems []emulationstation.System) (string, bool) {
+	var names []string
+	for _, system := range systems {
+		if system.Name == id {
+			return "", false
+		}
+
+		systemId, ok := esSystemId(system.Name)
+		if ok && strings.EqualFold(systemId, id) {
+			names = append(names, system.Name)
+		}
+	}
+
+	if len(names) > 1 {
+		log.Warn().Msgf(
+			"launcher %s must be renamed to one of: %s",
+			id, strings.Join(names, ", "),
+		)
+		return "", false
+	} else if len(names) == 0 {
+		return "", false
+	}
+
+	return names[0], true
+}
+
+// migrateLauncherIds renames launchers in the config values from their old
+// system ID to the ES system name. Custom launchers keep their ID. Returns
+// true if any launchers were renamed.
+func migrateLauncherIds(vals *config.Values, systems []emulationstation.System) bool {
+	custom := func(id string) bool {
+		for _, lc := range vals.Launchers.Custom {
+			if strings.EqualFold(lc.Id, id) {
+				return true
+			}
+		}
+		return false
+	}
+
+	migrated := false
+	rename := func(id *string) {
+		if *id == "" || custom(*id) {
+			return
+		}
+		if name, ok := renamedLauncher(*id, systems); ok {
+			log.Info().Msgf("renaming launcher %s to %s", *id, name)
+			*id = name
+			migrated = true
+		}
+	}
+
+	for i := range vals.Systems.Default {
+		rename(&vals.Systems.Default[i].Launcher)
+	}
+	for i := range vals.Launchers.Default {
+		rename(&vals.Launchers.Default[i].Launcher)
+	}
+
+	return migrated
+}
@@ -0,0 +1,66 @@
+package batocera
+
+import (
+	"testing"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/database/gamesdb"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms/batocera/emulationstation"
+)
+
+var testSystems = []emulationstation.System{
+	{Name: "megadrive"},
+	{Name: "snes"},
+	{Name: "mame"},
+	{Name: "fbneo"},
+}
+
+func TestMigrateLauncherIds(t *testing.T) {
+	vals := config.Values{}
+	vals.Systems.Default = []config.SystemsDefault{
+		{System: gamesdb.SystemGenesis, Launcher: gamesdb.SystemGenesis},
+		{System: gamesdb.SystemSNES},
+	}
+	vals.Launchers.Default = []config.LaunchersDefault{
+		{Launcher: gamesdb.SystemSNES},
+		{Launcher: "megadrive"},
+		// mame and fbneo are both arcade
+		{Launcher: gamesdb.SystemArcade},
+		{Launcher: "Custom"},
+	}
+
+	if !migrateLauncherIds(&vals, testSystems) {
+		t.Fatal("expected launchers to be renamed")
+	}
+
+	if got := vals.Systems.Default[0].Launcher; got != "megadrive" {
+		t.Errorf("system launcher not renamed: %s", got)
+	}
+	if got := vals.Systems.Default[1].Launcher; got != "" {
+		t.Errorf("empty system launcher set: %s", got)
+	}
+
+	want := []string{"snes", "megadrive", gamesdb.SystemArcade, "Custom"}
+	for i, ld := range vals.Launchers.Default {
+		if ld.Launcher != want[i] {
+			t.Errorf("launcher %d = %s, want %s", i, ld.Launcher, want[i])
+		}
+	}
+}
+
+func TestMigrateLauncherIdsCustom(t *testing.T) {
+	vals := config.Values{}
+	vals.Launchers.Custom = []config.LaunchersCustom{
+		{Id: gamesdb.SystemGenesis, System: gamesdb.SystemGenesis},
+	}
+	vals.Launchers.Default = []config.LaunchersDefault{
+		{Launcher: gamesdb.SystemGenesis},
+	}
+
+	if migrateLauncherIds(&vals, testSystems) {
+		t.Error("custom launcher renamed")
+	}
+	if got := vals.Launchers.Default[0].Launcher; got != gamesdb.SystemGenesis {
+		t.Errorf("custom launcher renamed to %s", got)
+	}
+}
@@ -0,0 +1,120 @@
+package platforms
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+)
+
+// StartFunc runs the command of a custom launcher for the given path. It
+// lets platforms track the process, if they're able to.
+type StartFunc func(cmd *exec.Cmd, l Launcher, path string) error
+
+// SplitCommand splits a command line into fields on spaces. Single or double
+// quotes group a field containing spaces and are removed.
+func SplitCommand(s string) []string {
+	var fields []string
+	sb := &strings.Builder{}
+	inField := false
+	var quote rune
+
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+			inField = true
+		case quote == 0 && r == ' ':
+			if inField {
+				fields = append(fields, sb.String())
+				sb.Reset()
+				inField = false
+			}
+		default:
+			sb.WriteRune(r)
+			inField = true
+		}
+	}
+
+	if inField {
+		fields = append(fields, sb.String())
+	}
+
+	return fields
+}
+
+// CustomCommand fills in a custom launcher's command template for the given
+// media path. Placeholders are replaced after splitting so paths containing
+// spaces stay a single argument.
+func CustomCommand(template string, path string) []string {
+	name := filepath.Base(path)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+
+	fields := SplitCommand(template)
+	for i, f := range fields {
+		f = strings.ReplaceAll(f, "{path}", path)
+		f = strings.ReplaceAll(f, "{name}", name)
+		fields[i] = f
+	}
+
+	return fields
+}
+
+// CustomLaunchers returns the launchers defined in the user's config. If
+// start is nil, commands are run without being tracked. The command is run
+// with the media path as an argument, so the path must be in the launchers
+// allow list like other launchers which run commands.
+func CustomLaunchers(cfg *config.Instance, start StartFunc) []Launcher {
+	if cfg == nil {
+		return nil
+	}
+
+	var launchers []Launcher
+	for _, lc := range cfg.CustomLaunchers() {
+		l := Launcher{
+			Id:             lc.Id,
+			SystemId:       lc.System,
+			Folders:        lc.Folders,
+			Extensions:     lc.Extensions,
+			Schemes:        lc.Schemes,
+			AllowListOnly:  true,
+			CommandOptions: true,
+		}
+
+		command := lc.Command
+		l.Launch = func(cfg *config.Instance, path string) error {
+			// not all platforms check the allow list before launching
+			if !cfg.IsLauncherFileAllowed(path) {
+				return errors.New("file not allowed: " + path)
+			}
+
+			fields := CustomCommand(command, path)
+			if len(fields) == 0 {
+				return errors.New("custom launcher command is empty: " + l.Id)
+			}
+
+			cmd := LaunchCommand(cfg, l.Id, l.SystemId, fields[0], fields[1:]...)
+			if start != nil {
+				return start(cmd, l, path)
+			}
+
+			err := cmd.Start()
+			if err != nil {
+				return err
+			}
+			go func() {
+				_ = cmd.Wait()
+			}()
+
+			return nil
+		}
+
+		launchers = append(launchers, l)
+	}
+
+	return launchers
+}
@@ -0,0 +1,89 @@
+package platforms
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+)
+
+func TestCustomCommand(t *testing.T) {
+	tests := []struct {
+		template string
+		path     string
+		want     []string
+	}{
+		{
+			template: "retroarch -L /cores/snes9x.so {path}",
+			path:     "/roms/snes/Super Game.sfc",
+			want:     []string{"retroarch", "-L", "/cores/snes9x.so", "/roms/snes/Super Game.sfc"},
+		},
+		{
+			template: `"/opt/my emu/run"  --title '{name}' --file={path}`,
+			path:     "/roms/a.bin",
+			want:     []string{"/opt/my emu/run", "--title", "a", "--file=/roms/a.bin"},
+		},
+		{
+			template: `run ""`,
+			path:     "x",
+			want:     []string{"run", ""},
+		},
+		{
+			template: "  ",
+			path:     "x",
+			want:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		got := CustomCommand(tt.template, tt.path)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestCustomLauncherAllowList(t *testing.T) {
+	defaults := config.BaseDefaults
+	defaults.Launchers = config.Launchers{
+		AllowFile: []string{"^/roms/allowed/"},
+		Custom: []config.LaunchersCustom{
+			{Id: "Test", Extensions: []string{".bin"}, Command: "emu {path}"},
+		},
+	}
+
+	cfg, err := config.NewConfig(t.TempDir(), defaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var started []string
+	start := func(cmd *exec.Cmd, _ Launcher, _ string) error {
+		started = append(started, cmd.Args[1])
+		return nil
+	}
+
+	ls := CustomLaunchers(cfg, start)
+	if len(ls) != 1 {
+		t.Fatalf("expected 1 launcher, got %d", len(ls))
+	}
+
+	if !ls[0].AllowListOnly {
+		t.Error("custom launcher isn't allow list only")
+	}
+
+	err = ls[0].Launch(cfg, "/roms/other/game.bin")
+	if err == nil {
+		t.Error("launched file not in allow list")
+	}
+
+	err = ls[0].Launch(cfg, "/roms/allowed/game.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(started, []string{"/roms/allowed/game.bin"}) {
+		t.Errorf("unexpected launches: %v", started)
+	}
+}
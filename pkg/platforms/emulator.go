@@ -0,0 +1,46 @@
+package platforms
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+)
+
+// EmulatorOp is an operation on the running emulator.
+type EmulatorOp string
+
+const (
+	EmulatorSaveState  EmulatorOp = "savestate"
+	EmulatorLoadState  EmulatorOp = "loadstate"
+	EmulatorReset      EmulatorOp = "reset"
+	EmulatorPause      EmulatorOp = "pause"
+	EmulatorScreenshot EmulatorOp = "screenshot"
+)
+
+var ErrEmulatorUnsupported = errors.New("emulator operation not supported")
+
+// EmulatorController is optionally implemented by platforms which can
+// control the running emulator themselves, rather than through the active
+// launcher. Slot is only used for save states and is 0 if not set.
+type EmulatorController interface {
+	EmulatorControl(cfg *config.Instance, op EmulatorOp, slot int) error
+}
+
+// ControlEmulator runs an operation on the running emulator using the active
+// launcher's control function, falling back to the platform's.
+func ControlEmulator(pl Platform, cfg *config.Instance, op EmulatorOp, slot int) error {
+	if id := pl.GetActiveLauncher(); id != "" {
+		for _, l := range pl.Launchers(cfg) {
+			if l.Id == id && l.Control != nil {
+				return l.Control(cfg, op, slot)
+			}
+		}
+	}
+
+	if ec, ok := pl.(EmulatorController); ok {
+		return ec.EmulatorControl(cfg, op, slot)
+	}
+
+	return fmt.Errorf("%w on %s: %s", ErrEmulatorUnsupported, pl.Id(), op)
+}
@@ -0,0 +1,64 @@
+package linux
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+)
+
+// gvariantString quotes a string as a GVariant text literal, for gdbus.
+func gvariantString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+// ShowNotice sends the notice to the desktop's notification server over
+// D-Bus, using notify-send or, if it's not installed, gdbus.
+func (p *Platform) ShowNotice(_ *config.Instance, n platforms.Notice) error {
+	ms := n.Duration.Milliseconds()
+
+	if _, err := exec.LookPath("notify-send"); err == nil {
+		urgency := "normal"
+		if n.Error {
+			urgency = "critical"
+		}
+
+		return exec.Command(
+			"notify-send",
+			"--app-name="+platforms.NoticeTitle,
+			"--urgency="+urgency,
+			"--expire-time="+strconv.FormatInt(ms, 10),
+			platforms.NoticeTitle,
+			n.Text,
+		).Run()
+	}
+
+	if _, err := exec.LookPath("gdbus"); err != nil {
+		return platforms.ErrNoticeUnsupported
+	}
+
+	urgency := 1
+	if n.Error {
+		urgency = 2
+	}
+
+	// Notify(app_name, replaces_id, app_icon, summary, body, actions,
+	// hints, expire_timeout)
+	return exec.Command(
+		"gdbus", "call", "--session",
+		"--dest", "org.freedesktop.Notifications",
+		"--object-path", "/org/freedesktop/Notifications",
+		"--method", "org.freedesktop.Notifications.Notify",
+		gvariantString(platforms.NoticeTitle),
+		"0",
+		"''",
+		gvariantString(platforms.NoticeTitle),
+		gvariantString(n.Text),
+		"[]",
+		"{'urgency': <byte "+strconv.Itoa(urgency)+">}",
+		strconv.FormatInt(ms, 10),
+	).Run()
+}
@@ -0,0 +1,344 @@
+// Package linux is the platform for a generic Linux desktop or single board
+// computer, such as a Raspberry Pi running RetroPie. Other Linux based
+// platforms can embed its Platform and override only what's different.
+package linux
+
+import (
+	"errors"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/database/gamesdb"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms/mpv"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms/proctracker"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms/retroarch"
+	"github.com/ZaparooProject/zaparoo-core/pkg/readers"
+	"github.com/ZaparooProject/zaparoo-core/pkg/readers/acr122_pcsc"
+	"github.com/ZaparooProject/zaparoo-core/pkg/readers/file"
+	"github.com/ZaparooProject/zaparoo-core/pkg/readers/libnfc"
+	"github.com/ZaparooProject/zaparoo-core/pkg/readers/optical_drive"
+	"github.com/ZaparooProject/zaparoo-core/pkg/readers/simple_serial"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/tokens"
+	"github.com/ZaparooProject/zaparoo-core/pkg/utils"
+	"github.com/adrg/xdg"
+	"github.com/rs/zerolog/log"
+)
+
+// How long to wait for Steam to start a game after it's been requested.
+const steamLaunchTimeout = 2 * time.Minute
+
+type Platform struct {
+	tr  proctracker.Tracker
+	cfg *config.Instance
+}
+
+func (p *Platform) Id() string {
+	return "linux"
+}
+
+func (p *Platform) SupportedReaders(cfg *config.Instance) []readers.Reader {
+	return []readers.Reader{
+		file.NewReader(cfg),
+		simple_serial.NewReader(cfg),
+		libnfc.NewReader(cfg),
+		acr122_pcsc.NewAcr122Pcsc(cfg),
+		optical_drive.NewReader(cfg),
+	}
+}
+
+func (p *Platform) StartPre(cfg *config.Instance) error {
+	p.cfg = cfg
+
+	err := os.MkdirAll(p.DataDir(), 0755)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *Platform) StartPost(_ *config.Instance, ns chan<- models.Notification) error {
+	p.tr.SetNotifications(ns)
+	return nil
+}
+
+func (p *Platform) Stop() error {
+	return nil
+}
+
+func (p *Platform) AfterScanHook(_ tokens.Token) error {
+	return nil
+}
+
+func (p *Platform) ReadersUpdateHook(_ map[string]*readers.Reader) error {
+	return nil
+}
+
+// RootDirs returns the index roots from the config followed by common ROM
+// folders, like the one used by RetroPie, and the home folder for media
+// folders such as ~/Videos and ~/Music.
+func (p *Platform) RootDirs(cfg *config.Instance) []string {
+	return append(
+		cfg.IndexRoots(),
+		filepath.Join(xdg.Home, "RetroPie", "roms"),
+		filepath.Join(xdg.Home, "roms"),
+		xdg.Home,
+	)
+}
+
+func (p *Platform) ZipsAsDirs() bool {
+	return false
+}
+
+func (p *Platform) DataDir() string {
+	return filepath.Join(xdg.DataHome, config.AppName)
+}
+
+func (p *Platform) LogDir() string {
+	return filepath.Join(xdg.DataHome, config.AppName)
+}
+
+func (p *Platform) ConfigDir() string {
+	return filepath.Join(xdg.ConfigHome, config.AppName)
+}
+
+func (p *Platform) TempDir() string {
+	return filepath.Join(os.TempDir(), config.AppName)
+}
+
+func (p *Platform) NormalizePath(_ *config.Instance, path string) string {
+	return path
+}
+
+// KillLauncher asks the active launcher to exit, if it can, so it has the
+// chance to save first. Otherwise, the tracked process is killed.
+func (p *Platform) KillLauncher() error {
+	if p.cfg == nil {
+		return p.tr.Kill()
+	}
+
+	m, tracked := p.tr.Active()
+	if !tracked {
+		// retroarch may be running a game started from its own menu
+		err := retroarch.Kill(p.cfg)
+		if err != nil {
+			log.Debug().Err(err).Msg("error quitting retroarch")
+		}
+		return nil
+	}
+
+	for _, l := range p.Launchers(p.cfg) {
+		if l.Id != m.LauncherId || l.Kill == nil {
+			continue
+		}
+
+		err := l.Kill(p.cfg)
+		if err == nil {
+			return nil
+		}
+		log.Debug().Err(err).Msgf("error exiting launcher: %s", l.Id)
+		break
+	}
+
+	return p.tr.Kill()
+}
+
+// Track starts a launcher's command and tracks its process as the active
+// media. It can be used as the start function of custom launchers.
+func (p *Platform) Track(cmd *exec.Cmd, l platforms.Launcher, path string) error {
+	return p.tr.Start(cmd, proctracker.NewMedia(l.Id, l.SystemId, path))
+}
+
+// active returns the tracked media process or, if there isn't one, the game
+// RetroArch reports is running. Games started from RetroArch's own menu
+// can only be seen this way.
+func (p *Platform) active() proctracker.Media {
+	if m, ok := p.tr.Active(); ok {
+		return m
+	} else if p.cfg == nil {
+		return proctracker.Media{}
+	}
+
+	status, err := retroarch.NewClient(p.cfg.RetroArch().CommandPort).GetStatus()
+	if err != nil || !status.Running() {
+		return proctracker.Media{}
+	}
+
+	m := proctracker.NewMedia(retroarch.LauncherId, retroarch.SystemId(status.CoreSystemId), "")
+	m.Name = status.Content
+
+	return m
+}
+
+func (p *Platform) GetActiveLauncher() string {
+	return p.active().LauncherId
+}
+
+func (p *Platform) ActiveSystem() string {
+	return p.active().SystemId
+}
+
+func (p *Platform) ActiveGame() string {
+	return p.active().Path
+}
+
+func (p *Platform) ActiveGameName() string {
+	return p.active().Name
+}
+
+func (p *Platform) ActiveGamePath() string {
+	return p.active().Path
+}
+
+func (p *Platform) LaunchSystem(_ *config.Instance, _ string) error {
+	return nil
+}
+
+func (p *Platform) LaunchFile(cfg *config.Instance, path string) error {
+	launchers := utils.PathToLaunchers(cfg, p, path)
+	if len(launchers) == 0 {
+		return errors.New("no launcher found")
+	}
+	launcher := launchers[0]
+
+	if launcher.AllowListOnly && !cfg.IsLauncherFileAllowed(path) {
+		return errors.New("file not allowed: " + path)
+	}
+
+	log.Info().Msgf("launching file: %s", path)
+	return launcher.Launch(cfg, path)
+}
+
+func (p *Platform) KeyboardInput(_ string) error {
+	return nil
+}
+
+func (p *Platform) KeyboardPress(_ string) error {
+	return nil
+}
+
+func (p *Platform) GamepadPress(_ string) error {
+	return nil
+}
+
+func (p *Platform) ForwardCmd(_ platforms.CmdEnv) error {
+	return nil
+}
+
+func (p *Platform) LookupMapping(_ tokens.Token) (string, bool) {
+	return "", false
+}
+
+// RetroArchOptions returns where RetroArch's cores and playlists are for
+// the current user.
+func RetroArchOptions() retroarch.Options {
+	return retroarch.Options{
+		Binary:       "retroarch",
+		CoresDir:     filepath.Join(xdg.ConfigHome, "retroarch", "cores"),
+		PlaylistsDir: filepath.Join(xdg.ConfigHome, "retroarch", "playlists"),
+	}
+}
+
+// SteamDir returns the location of the Steam install for the current user,
+// checking the native and Flatpak install locations.
+func SteamDir() string {
+	dirs := []string{
+		filepath.Join(xdg.Home, ".steam", "steam"),
+		filepath.Join(xdg.DataHome, "Steam"),
+		filepath.Join(xdg.Home, ".var", "app", "com.valvesoftware.Steam", ".local", "share", "Steam"),
+	}
+
+	for _, dir := range dirs {
+		if _, err := os.Stat(filepath.Join(dir, "steamapps")); err == nil {
+			return dir
+		}
+	}
+
+	return dirs[0]
+}
+
+// Launchers returns the custom launchers from the config followed by the
+// standard Linux launchers, RetroArch's and mpv's. Embedding platforms can
+// append their own.
+func (p *Platform) Launchers(cfg *config.Instance) []platforms.Launcher {
+	launchers := []platforms.Launcher{
+		{
+			Id:             "Steam",
+			SystemId:       gamesdb.SystemPC,
+			Schemes:        []string{"steam"},
+			CommandOptions: true,
+			Scanner: func(
+				cfg *config.Instance,
+				systemId string,
+				results []platforms.ScanResult,
+			) ([]platforms.ScanResult, error) {
+				appResults, err := utils.ScanSteamApps(SteamDir())
+				if err != nil {
+					return nil, err
+				}
+				return append(results, appResults...), nil
+			},
+			Launch: func(cfg *config.Instance, path string) error {
+				id := strings.TrimPrefix(path, "steam://")
+				id = strings.TrimPrefix(id, "rungameid/")
+				cmd := platforms.LaunchCommand(
+					cfg,
+					"Steam",
+					gamesdb.SystemPC,
+					"steam",
+					"steam://rungameid/"+id,
+				)
+				err := cmd.Start()
+				if err != nil {
+					return err
+				}
+				go func() {
+					_ = cmd.Wait()
+				}()
+
+				// non-steam shortcuts are launched with a game ID which
+				// has their app ID in the upper bits
+				appId := id
+				if gameId, err := strconv.ParseUint(id, 10, 64); err == nil && gameId > math.MaxUint32 {
+					appId = strconv.FormatUint(gameId>>32, 10)
+				}
+
+				// steam hands the launch off to the running client, the
+				// game itself runs under a reaper process with the app ID
+				p.tr.Watch(
+					proctracker.NewMedia("Steam", gamesdb.SystemPC, path),
+					proctracker.FindByArg("AppId="+appId),
+					steamLaunchTimeout,
+				)
+				return nil
+			},
+		},
+		{
+			Id:             "Generic",
+			Extensions:     []string{".sh"},
+			AllowListOnly:  true,
+			CommandOptions: true,
+			Launch: func(cfg *config.Instance, path string) error {
+				return p.tr.Start(
+					platforms.LaunchCommand(cfg, "Generic", "", "bash", "-c", path),
+					proctracker.NewMedia("Generic", "", path),
+				)
+			},
+		},
+	}
+
+	launchers = append(launchers, retroarch.Launchers(cfg, RetroArchOptions(), p.Track)...)
+	launchers = append(launchers, mpv.Launchers(mpv.Options{
+		Socket: filepath.Join(p.TempDir(), "mpv.sock"),
+	}, p.Track)...)
+
+	return append(platforms.CustomLaunchers(cfg, p.Track), launchers...)
+}
@@ -0,0 +1,144 @@
+package linux
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/database/gamesdb"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms/mpv"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms/retroarch"
+	"github.com/adrg/xdg"
+)
+
+func testConfig(t *testing.T, defaults config.Values) *config.Instance {
+	cfg, err := config.NewConfig(t.TempDir(), defaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestLaunchers(t *testing.T) {
+	defaults := config.BaseDefaults
+	defaults.Launchers.Custom = []config.LaunchersCustom{
+		{Id: "Custom", Extensions: []string{".sh"}, Command: "sh {path}"},
+	}
+	cfg := testConfig(t, defaults)
+
+	p := &Platform{}
+	ls := p.Launchers(cfg)
+
+	if len(ls) == 0 || ls[0].Id != "Custom" {
+		t.Fatal("custom launchers should come first")
+	}
+
+	ids := make(map[string]bool)
+	for _, l := range ls {
+		if ids[l.Id] {
+			t.Errorf("duplicate launcher id: %s", l.Id)
+		}
+		ids[l.Id] = true
+
+		if l.Launch == nil {
+			t.Errorf("launcher has no launch function: %s", l.Id)
+		}
+	}
+
+	want := []string{
+		"Steam",
+		"Generic",
+		retroarch.LauncherId,
+		retroarch.LauncherId + gamesdb.SystemSNES,
+		mpv.VideoLauncherId,
+	}
+	for _, id := range want {
+		if !ids[id] {
+			t.Errorf("missing launcher: %s", id)
+		}
+	}
+}
+
+func TestLaunchFileNotAllowed(t *testing.T) {
+	cfg := testConfig(t, config.BaseDefaults)
+	p := &Platform{}
+
+	err := p.LaunchFile(cfg, "/tmp/script.sh")
+	if err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("expected not allowed error, got: %v", err)
+	}
+
+	err = p.LaunchFile(cfg, "/tmp/unknown.nope")
+	if err == nil {
+		t.Error("expected no launcher error")
+	}
+}
+
+func TestSteamDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", "")
+	xdg.Reload()
+	t.Cleanup(xdg.Reload)
+
+	native := filepath.Join(home, ".steam", "steam")
+	if got := SteamDir(); got != native {
+		t.Errorf("expected native dir without an install, got: %s", got)
+	}
+
+	flatpak := filepath.Join(home, ".var", "app", "com.valvesoftware.Steam", ".local", "share", "Steam")
+	err := os.MkdirAll(filepath.Join(flatpak, "steamapps"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := SteamDir(); got != flatpak {
+		t.Errorf("expected flatpak install, got: %s", got)
+	}
+
+	err = os.MkdirAll(filepath.Join(native, "steamapps"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := SteamDir(); got != native {
+		t.Errorf("expected native install first, got: %s", got)
+	}
+}
+
+func TestTrackAndKill(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not available")
+	}
+
+	p := &Platform{}
+	l := platforms.Launcher{Id: "Test", SystemId: "Genesis"}
+
+	err := p.Track(exec.Command("sleep", "60"), l, "/roms/game.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.GetActiveLauncher() != "Test" || p.ActiveSystem() != "Genesis" ||
+		p.ActiveGamePath() != "/roms/game.md" {
+		t.Errorf("tracked media not active: %s, %s, %s",
+			p.GetActiveLauncher(), p.ActiveSystem(), p.ActiveGamePath())
+	}
+
+	err = p.KillLauncher()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the process is cleared once it's exited
+	deadline := time.Now().Add(5 * time.Second)
+	for p.GetActiveLauncher() != "" {
+		if time.Now().After(deadline) {
+			t.Fatalf("media still active after kill: %s", p.GetActiveLauncher())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
 	"github.com/ZaparooProject/zaparoo-core/pkg/readers"
@@ -101,12 +102,6 @@ func (p *Platform) GetActiveLauncher() string {
 	return ""
 }
 
-func (p *Platform) PlayFailSound(cfg *config.Instance) {
-}
-
-func (p *Platform) PlaySuccessSound(cfg *config.Instance) {
-}
-
 func (p *Platform) ActiveSystem() string {
 	return ""
 }
@@ -163,6 +158,20 @@ func (p *Platform) LookupMapping(_ tokens.Token) (string, bool) {
 	return "", false
 }
 
-func (p *Platform) Launchers() []platforms.Launcher {
-	return nil
+func (p *Platform) Launchers(cfg *config.Instance) []platforms.Launcher {
+	return platforms.CustomLaunchers(cfg, nil)
+}
+
+// ShowNotice shows the notice in the Notification Center. The duration is
+// decided by the system.
+func (p *Platform) ShowNotice(_ *config.Instance, n platforms.Notice) error {
+	quote := func(s string) string {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+		return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+	}
+
+	script := "display notification " + quote(n.Text) +
+		" with title " + quote(platforms.NoticeTitle)
+
+	return exec.Command("osascript", "-e", script).Run()
 }
@@ -0,0 +1,45 @@
+//go:build linux || darwin
+
+package mister
+
+import (
+	"fmt"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+	"github.com/wizzomafizzo/mrext/pkg/input"
+)
+
+// Cores with save state support have 4 slots, loaded with F1-F4 and saved
+// with Alt+F1-F4.
+const saveStateSlots = 4
+
+var saveStateKeys = []string{"f1", "f2", "f3", "f4"}
+
+// ControlEmulator runs an emulator operation on the active core using its
+// keyboard hotkeys. Pausing isn't supported by MiSTer cores.
+func ControlEmulator(kbd *input.Keyboard, op platforms.EmulatorOp, slot int) error {
+	switch op {
+	case platforms.EmulatorSaveState, platforms.EmulatorLoadState:
+		if slot == 0 {
+			slot = 1
+		} else if slot > saveStateSlots {
+			return fmt.Errorf("save state slot must be 1-%d: %d", saveStateSlots, slot)
+		}
+
+		key := KeyboardMap[saveStateKeys[slot-1]]
+		if op == platforms.EmulatorSaveState {
+			kbd.Combo(KeyboardMap["lalt"], key)
+		} else {
+			kbd.Press(key)
+		}
+	case platforms.EmulatorReset:
+		// the user button, which resets most cores
+		kbd.User()
+	case platforms.EmulatorScreenshot:
+		kbd.Screenshot()
+	default:
+		return fmt.Errorf("%w on mister: %s", platforms.ErrEmulatorUnsupported, op)
+	}
+
+	return nil
+}
@@ -4,7 +4,6 @@ package mister
 
 import (
 	"github.com/ZaparooProject/zaparoo-core/pkg/config"
-	"os/exec"
 	"strings"
 
 	"github.com/rs/zerolog/log"
@@ -13,28 +12,6 @@ import (
 	mrextMister "github.com/wizzomafizzo/mrext/pkg/mister"
 )
 
-func PlaySuccess(cfg *config.Instance) {
-	if !cfg.AudioFeedback() {
-		return
-	}
-
-	err := exec.Command("aplay", SuccessSoundFile).Start()
-	if err != nil {
-		log.Error().Msgf("error playing success sound: %s", err)
-	}
-}
-
-func PlayFail(cfg *config.Instance) {
-	if !cfg.AudioFeedback() {
-		return
-	}
-
-	err := exec.Command("aplay", FailSoundFile).Start()
-	if err != nil {
-		log.Error().Msgf("error playing fail sound: %s", err)
-	}
-}
-
 func ExitGame() {
 	_ = mrextMister.LaunchMenu()
 }
@@ -0,0 +1,58 @@
+//go:build linux || darwin
+
+package mister
+
+import (
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wizzomafizzo/mrext/pkg/input"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+)
+
+var noticeMu sync.Mutex
+
+// ShowNotice shows a notice on the console scripts are run on. MiSTer can
+// only switch to the console from the menu, which interrupts it, so only
+// error notices are shown and only while no core is running.
+func ShowNotice(kbd input.Keyboard, n platforms.Notice) error {
+	if !n.Error {
+		return platforms.ErrNoticeUnsupported
+	}
+
+	noticeMu.Lock()
+	defer noticeMu.Unlock()
+
+	err := openConsole(kbd, "3")
+	if err != nil {
+		return err
+	}
+	defer kbd.ExitConsole()
+
+	err = exec.Command("chvt", "2").Run()
+	if err != nil {
+		return err
+	}
+
+	err = cleanConsole("2")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = restoreConsole("2")
+	}()
+
+	// clear the screen and show the text below the title
+	text := strings.ReplaceAll(n.Text, "\n", "\r\n")
+	err = writeTty("2", "\033[2J\033[H"+platforms.NoticeTitle+"\r\n\r\n"+text+"\r\n")
+	if err != nil {
+		return err
+	}
+
+	time.Sleep(n.Duration)
+
+	return nil
+}
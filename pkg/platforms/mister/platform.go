@@ -8,7 +8,6 @@ import (
 	"errors"
 	"fmt"
 	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
-	"github.com/ZaparooProject/zaparoo-core/pkg/assets"
 	"github.com/ZaparooProject/zaparoo-core/pkg/config"
 	"github.com/ZaparooProject/zaparoo-core/pkg/database/gamesdb"
 	"github.com/ZaparooProject/zaparoo-core/pkg/readers/optical_drive"
@@ -146,32 +145,6 @@ func (p *Platform) StartPre(_ *config.Instance) error {
 	}
 	p.stopMappingsWatcher = closeMappingsWatcher
 
-	if _, err := os.Stat(SuccessSoundFile); err != nil {
-		// copy success sound to temp
-		sf, err := os.Create(SuccessSoundFile)
-		if err != nil {
-			log.Error().Msgf("error creating success sound file: %s", err)
-		}
-		_, err = sf.Write(assets.SuccessSound)
-		if err != nil {
-			log.Error().Msgf("error writing success sound file: %s", err)
-		}
-		_ = sf.Close()
-	}
-
-	if _, err := os.Stat(FailSoundFile); err != nil {
-		// copy fail sound to temp
-		ff, err := os.Create(FailSoundFile)
-		if err != nil {
-			log.Error().Msgf("error creating fail sound file: %s", err)
-		}
-		_, err = ff.Write(assets.FailSound)
-		if err != nil {
-			log.Error().Msgf("error writing fail sound file: %s", err)
-		}
-		_ = ff.Close()
-	}
-
 	stopSocket, err := StartSocketServer(
 		p,
 		func() *tokens.Token {
@@ -334,14 +307,6 @@ func (p *Platform) GetActiveLauncher() string {
 	return core
 }
 
-func (p *Platform) PlayFailSound(cfg *config.Instance) {
-	PlayFail(cfg)
-}
-
-func (p *Platform) PlaySuccessSound(cfg *config.Instance) {
-	PlaySuccess(cfg)
-}
-
 func (p *Platform) ActiveSystem() string {
 	return p.tr.ActiveSystem
 }
@@ -425,6 +390,13 @@ func (p *Platform) GamepadPress(name string) error {
 	return nil
 }
 
+func (p *Platform) EmulatorControl(_ *config.Instance, op platforms.EmulatorOp, slot int) error {
+	if p.GetActiveLauncher() == "" {
+		return errors.New("no core running")
+	}
+	return ControlEmulator(&p.kbd, op, slot)
+}
+
 func (p *Platform) ForwardCmd(env platforms.CmdEnv) error {
 	if f, ok := p.cmdMappings[env.Cmd]; ok {
 		return f(p, env)
@@ -497,7 +469,7 @@ func readRomsets(filepath string) ([]Romset, error) {
 	return romsets.Romsets, nil
 }
 
-func (p *Platform) Launchers() []platforms.Launcher {
+func (p *Platform) Launchers(cfg *config.Instance) []platforms.Launcher {
 	aGamesPath := "listings/games.txt"
 	aDemosPath := "listings/demos.txt"
 	amiga := platforms.Launcher{
@@ -527,7 +499,7 @@ func (p *Platform) Launchers() []platforms.Launcher {
 				return results, err
 			}
 
-			sfs := gamesdb.GetSystemPaths(p, p.RootDirs(cfg), []gamesdb.System{*s})
+			sfs := gamesdb.GetSystemPaths(cfg, p, p.RootDirs(cfg), []gamesdb.System{*s})
 			for _, sf := range sfs {
 				for _, txt := range []string{aGamesPath, aDemosPath} {
 					tp, err := gamesdb.FindPath(filepath.Join(sf.Path, txt))
@@ -592,7 +564,7 @@ func (p *Platform) Launchers() []platforms.Launcher {
 				return results, err
 			}
 
-			sfs := gamesdb.GetSystemPaths(p, p.RootDirs(cfg), []gamesdb.System{*s})
+			sfs := gamesdb.GetSystemPaths(cfg, p, p.RootDirs(cfg), []gamesdb.System{*s})
 			for _, sf := range sfs {
 				rsf, err := gamesdb.FindPath(filepath.Join(sf.Path, romsetsFilename))
 				if err == nil {
@@ -649,9 +621,17 @@ func (p *Platform) Launchers() []platforms.Launcher {
 		Kill:       killMPlayer,
 	}
 
-	ls := Launchers
+	ls := platforms.CustomLaunchers(cfg, nil)
+	ls = append(ls, Launchers...)
 	ls = append(ls, amiga)
 	ls = append(ls, neogeo)
 	ls = append(ls, mplayerVideo)
 	return ls
 }
+
+func (p *Platform) ShowNotice(_ *config.Instance, n platforms.Notice) error {
+	if p.GetActiveLauncher() != "" {
+		return platforms.ErrNoticeUnsupported
+	}
+	return ShowNotice(p.kbd, n)
+}
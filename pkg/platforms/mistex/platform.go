@@ -3,9 +3,9 @@
 package mistex
 
 import (
+	"errors"
 	"fmt"
 	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
-	"github.com/ZaparooProject/zaparoo-core/pkg/assets"
 	"github.com/ZaparooProject/zaparoo-core/pkg/config"
 	"github.com/ZaparooProject/zaparoo-core/pkg/service/tokens"
 	"github.com/ZaparooProject/zaparoo-core/pkg/utils"
@@ -75,32 +75,6 @@ func (p *Platform) StartPre(_ *config.Instance) error {
 	}
 	p.gpd = gpd
 
-	if _, err := os.Stat(mister.SuccessSoundFile); err != nil {
-		// copy success sound to temp
-		sf, err := os.Create(mister.SuccessSoundFile)
-		if err != nil {
-			log.Error().Msgf("error creating success sound file: %s", err)
-		}
-		_, err = sf.Write(assets.SuccessSound)
-		if err != nil {
-			log.Error().Msgf("error writing success sound file: %s", err)
-		}
-		_ = sf.Close()
-	}
-
-	if _, err := os.Stat(mister.FailSoundFile); err != nil {
-		// copy fail sound to temp
-		ff, err := os.Create(mister.FailSoundFile)
-		if err != nil {
-			log.Error().Msgf("error creating fail sound file: %s", err)
-		}
-		_, err = ff.Write(assets.FailSound)
-		if err != nil {
-			log.Error().Msgf("error writing fail sound file: %s", err)
-		}
-		_ = ff.Close()
-	}
-
 	return nil
 }
 
@@ -239,14 +213,6 @@ func (p *Platform) GetActiveLauncher() string {
 	return core
 }
 
-func (p *Platform) PlayFailSound(cfg *config.Instance) {
-	mister.PlayFail(cfg)
-}
-
-func (p *Platform) PlaySuccessSound(cfg *config.Instance) {
-	mister.PlaySuccess(cfg)
-}
-
 func (p *Platform) ActiveSystem() string {
 	return p.tr.ActiveSystem
 }
@@ -315,6 +281,13 @@ func (p *Platform) GamepadPress(name string) error {
 	return nil
 }
 
+func (p *Platform) EmulatorControl(_ *config.Instance, op platforms.EmulatorOp, slot int) error {
+	if p.GetActiveLauncher() == "" {
+		return errors.New("no core running")
+	}
+	return mister.ControlEmulator(&p.kbd, op, slot)
+}
+
 func (p *Platform) ForwardCmd(env platforms.CmdEnv) error {
 	if f, ok := commandsMappings[env.Cmd]; ok {
 		return f(p, env)
@@ -327,6 +300,13 @@ func (p *Platform) LookupMapping(_ tokens.Token) (string, bool) {
 	return "", false
 }
 
-func (p *Platform) Launchers() []platforms.Launcher {
-	return mister.Launchers
+func (p *Platform) Launchers(cfg *config.Instance) []platforms.Launcher {
+	return append(platforms.CustomLaunchers(cfg, nil), mister.Launchers...)
+}
+
+func (p *Platform) ShowNotice(_ *config.Instance, n platforms.Notice) error {
+	if p.GetActiveLauncher() != "" {
+		return platforms.ErrNoticeUnsupported
+	}
+	return mister.ShowNotice(p.kbd, n)
 }
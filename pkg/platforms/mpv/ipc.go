@@ -0,0 +1,157 @@
+package mpv
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// How long to wait for mpv to reply to a command.
+const replyTimeout = 2 * time.Second
+
+type ipcRequest struct {
+	Command   []any `json:"command"`
+	RequestId int   `json:"request_id"`
+}
+
+// Replies and events are sent on the same connection, events have no
+// request ID and an event name instead.
+type ipcMessage struct {
+	Data      json.RawMessage `json:"data"`
+	Error     string          `json:"error"`
+	RequestId int             `json:"request_id"`
+	Event     string          `json:"event"`
+}
+
+// Client sends commands to mpv's JSON IPC socket, which mpv creates when
+// started with --input-ipc-server.
+type Client struct {
+	socket string
+	mu     sync.Mutex
+	nextId int
+}
+
+func NewClient(socket string) *Client {
+	return &Client{socket: socket}
+}
+
+// Command runs an mpv input command and returns its reply data.
+func (c *Client) Command(args ...any) (json.RawMessage, error) {
+	c.mu.Lock()
+	c.nextId++
+	id := c.nextId
+	c.mu.Unlock()
+
+	conn, err := net.DialTimeout("unix", c.socket, replyTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer func(conn net.Conn) {
+		_ = conn.Close()
+	}(conn)
+
+	err = conn.SetDeadline(time.Now().Add(replyTimeout))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := json.Marshal(ipcRequest{Command: args, RequestId: id})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = conn.Write(append(req, '\n'))
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var msg ipcMessage
+		err := json.Unmarshal(scanner.Bytes(), &msg)
+		if err != nil {
+			return nil, err
+		}
+
+		if msg.Event != "" || msg.RequestId != id {
+			continue
+		}
+
+		if msg.Error != "success" {
+			return nil, fmt.Errorf("mpv command %v: %s", args[0], msg.Error)
+		}
+
+		return msg.Data, nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, errors.New("mpv closed connection without reply")
+}
+
+// Running returns true if mpv is listening on the socket.
+func (c *Client) Running() bool {
+	_, err := c.Command("get_property", "pid")
+	return err == nil
+}
+
+// Play replaces the current file with a new one.
+func (c *Client) Play(path string) error {
+	_, err := c.Command("loadfile", path, "replace")
+	return err
+}
+
+// Stop stops playback, mpv exits unless it was started with --idle.
+func (c *Client) Stop() error {
+	_, err := c.Command("stop")
+	return err
+}
+
+func (c *Client) Quit() error {
+	_, err := c.Command("quit")
+	return err
+}
+
+func (c *Client) TogglePause() error {
+	_, err := c.Command("cycle", "pause")
+	return err
+}
+
+func (c *Client) SetPause(paused bool) error {
+	_, err := c.Command("set_property", "pause", paused)
+	return err
+}
+
+// Seek moves playback by a number of seconds or, if absolute is true, to
+// a position in seconds.
+func (c *Client) Seek(seconds float64, absolute bool) error {
+	mode := "relative"
+	if absolute {
+		mode = "absolute"
+	}
+	_, err := c.Command("seek", seconds, mode)
+	return err
+}
+
+func (c *Client) Screenshot() error {
+	_, err := c.Command("screenshot")
+	return err
+}
+
+// Path returns the path of the file being played.
+func (c *Client) Path() (string, error) {
+	data, err := c.Command("get_property", "path")
+	if err != nil {
+		return "", err
+	}
+
+	var path string
+	err = json.Unmarshal(data, &path)
+	return path, err
+}
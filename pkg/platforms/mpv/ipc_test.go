@@ -0,0 +1,103 @@
+package mpv
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Stands in for mpv's IPC socket. It keeps the path of the loaded file,
+// records every command and sends an event before each reply, like mpv
+// does while playing.
+func newTestServer(t *testing.T) (string, <-chan []any) {
+	socket := filepath.Join(t.TempDir(), "mpv.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = ln.Close()
+	})
+
+	cmds := make(chan []any, 20)
+	go func() {
+		path := ""
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			scanner := bufio.NewScanner(conn)
+			for scanner.Scan() {
+				var req ipcRequest
+				err := json.Unmarshal(scanner.Bytes(), &req)
+				if err != nil {
+					break
+				}
+				cmds <- req.Command
+
+				reply := map[string]any{"error": "success", "request_id": req.RequestId}
+				switch req.Command[0] {
+				case "loadfile":
+					path = req.Command[1].(string)
+				case "get_property":
+					reply["data"] = path
+				case "rewind":
+					reply["error"] = "invalid parameter"
+				}
+
+				_, _ = conn.Write([]byte(`{"event":"playback-restart"}` + "\n"))
+				data, _ := json.Marshal(reply)
+				_, _ = conn.Write(append(data, '\n'))
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	return socket, cmds
+}
+
+func TestClient(t *testing.T) {
+	socket, cmds := newTestServer(t)
+	c := NewClient(socket)
+
+	tests := []struct {
+		run  func() error
+		want []any
+	}{
+		{func() error { return c.Play("/videos/a.mkv") }, []any{"loadfile", "/videos/a.mkv", "replace"}},
+		{c.TogglePause, []any{"cycle", "pause"}},
+		{func() error { return c.Seek(30, false) }, []any{"seek", float64(30), "relative"}},
+		{func() error { return c.Seek(0, true) }, []any{"seek", float64(0), "absolute"}},
+		{c.Stop, []any{"stop"}},
+	}
+
+	for _, tt := range tests {
+		err := tt.run()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := <-cmds
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("got command %v, want %v", got, tt.want)
+		}
+	}
+
+	path, err := c.Path()
+	if err != nil {
+		t.Fatal(err)
+	} else if path != "/videos/a.mkv" {
+		t.Errorf("got path %q", path)
+	}
+	<-cmds
+
+	_, err = c.Command("rewind")
+	if err == nil {
+		t.Error("expected error for failed command")
+	}
+}
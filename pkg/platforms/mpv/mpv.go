@@ -0,0 +1,170 @@
+// Package mpv provides launchers which play video and audio files in mpv,
+// controlled through its JSON IPC socket.
+package mpv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/database/gamesdb"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+)
+
+const (
+	VideoLauncherId = "MpvVideo"
+	AudioLauncherId = "MpvAudio"
+)
+
+// How long to wait for a running mpv to quit before starting a new one.
+const quitTimeout = 2 * time.Second
+
+var (
+	VideoFolders    = []string{"Videos", "Video", "Movies", "TV"}
+	VideoExtensions = []string{
+		".avi", ".m4v", ".mkv", ".mov", ".mp4", ".mpeg", ".mpg", ".ts", ".webm", ".wmv",
+	}
+	AudioFolders    = []string{"Music", "Audio"}
+	AudioExtensions = []string{
+		".aac", ".flac", ".m4a", ".mp3", ".ogg", ".opus", ".wav", ".wma",
+	}
+)
+
+// Options are the platform's settings for running mpv.
+type Options struct {
+	// Path to the mpv executable, defaults to finding it in PATH.
+	Binary string
+	// Path of the IPC socket mpv is started with.
+	Socket string
+}
+
+// Kill asks the running mpv to quit.
+func (o Options) Kill(_ *config.Instance) error {
+	return NewClient(o.Socket).Quit()
+}
+
+// Control runs an emulator operation on the running mpv. Reset restarts
+// the file from the beginning.
+func (o Options) Control(_ *config.Instance, op platforms.EmulatorOp, _ int) error {
+	c := NewClient(o.Socket)
+
+	switch op {
+	case platforms.EmulatorPause:
+		return c.TogglePause()
+	case platforms.EmulatorReset:
+		return c.Seek(0, true)
+	case platforms.EmulatorScreenshot:
+		return c.Screenshot()
+	default:
+		return fmt.Errorf("%w by mpv: %s", platforms.ErrEmulatorUnsupported, op)
+	}
+}
+
+// Only one mpv is run at a time, so it can always be found at the socket.
+func (o Options) quitRunning() {
+	c := NewClient(o.Socket)
+	if !c.Running() {
+		return
+	}
+
+	_ = c.Quit()
+
+	deadline := time.Now().Add(quitTimeout)
+	for c.Running() && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func (o Options) launch(
+	cfg *config.Instance,
+	start platforms.StartFunc,
+	l platforms.Launcher,
+	path string,
+	args ...string,
+) error {
+	o.quitRunning()
+
+	err := os.MkdirAll(filepath.Dir(o.Socket), 0755)
+	if err != nil {
+		return err
+	}
+
+	binary := o.Binary
+	if binary == "" {
+		binary = "mpv"
+	}
+
+	args = append([]string{"--input-ipc-server=" + o.Socket, "--idle=no"}, args...)
+	// mpv reads everything after -- as files, so it goes after the user's args
+	cmd := platforms.LaunchCommandFiles(cfg, l.Id, l.SystemId, binary, args, "--", path)
+
+	if start != nil {
+		return start(cmd, l, path)
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return err
+	}
+	go func() {
+		_ = cmd.Wait()
+	}()
+
+	return nil
+}
+
+// nameScanner fills in display names for the results of the standard scan.
+func nameScanner(name func(string) string) func(
+	*config.Instance,
+	string,
+	[]platforms.ScanResult,
+) ([]platforms.ScanResult, error) {
+	return func(
+		_ *config.Instance,
+		_ string,
+		results []platforms.ScanResult,
+	) ([]platforms.ScanResult, error) {
+		for i, r := range results {
+			if r.Name == "" {
+				results[i].Name = name(r.Path)
+			}
+		}
+		return results, nil
+	}
+}
+
+// Launchers returns launchers for the video and audio systems. If start is
+// nil, mpv is run without being tracked.
+func Launchers(opts Options, start platforms.StartFunc) []platforms.Launcher {
+	video := platforms.Launcher{
+		Id:             VideoLauncherId,
+		SystemId:       gamesdb.SystemVideo,
+		Folders:        VideoFolders,
+		Extensions:     VideoExtensions,
+		Scanner:        nameScanner(VideoName),
+		Kill:           opts.Kill,
+		Control:        opts.Control,
+		CommandOptions: true,
+	}
+	video.Launch = func(cfg *config.Instance, path string) error {
+		return opts.launch(cfg, start, video, path, "--fullscreen")
+	}
+
+	audio := platforms.Launcher{
+		Id:             AudioLauncherId,
+		SystemId:       gamesdb.SystemAudio,
+		Folders:        AudioFolders,
+		Extensions:     AudioExtensions,
+		Scanner:        nameScanner(AudioName),
+		Kill:           opts.Kill,
+		Control:        opts.Control,
+		CommandOptions: true,
+	}
+	audio.Launch = func(cfg *config.Instance, path string) error {
+		return opts.launch(cfg, start, audio, path, "--no-video")
+	}
+
+	return []platforms.Launcher{video, audio}
+}
@@ -0,0 +1,56 @@
+package mpv
+
+import (
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+)
+
+func TestLaunchArgs(t *testing.T) {
+	defaults := config.BaseDefaults
+	defaults.Launchers.Default = []config.LaunchersDefault{{
+		Launcher: VideoLauncherId,
+		LaunchOptions: config.LaunchOptions{
+			Args: []string{"--fullscreen", "--volume=50"},
+		},
+	}}
+	cfg, err := config.NewConfig(t.TempDir(), defaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := Options{
+		Binary: "mpv",
+		Socket: filepath.Join(t.TempDir(), "mpv.sock"),
+	}
+
+	var started *exec.Cmd
+	start := func(cmd *exec.Cmd, _ platforms.Launcher, _ string) error {
+		started = cmd
+		return nil
+	}
+
+	l := platforms.Launcher{Id: VideoLauncherId}
+	err = o.launch(cfg, start, l, "/videos/-movie.mkv", "--force-window=yes")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"mpv",
+		"--input-ipc-server=" + o.Socket,
+		"--idle=no",
+		"--force-window=yes",
+		"--fullscreen",
+		"--volume=50",
+		"--",
+		"/videos/-movie.mkv",
+	}
+	if !reflect.DeepEqual(started.Args, want) {
+		t.Errorf("got %v, want %v", started.Args, want)
+	}
+}
@@ -0,0 +1,67 @@
+package mpv
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	yearRe    = regexp.MustCompile(`^[(\[]?((?:19|20)\d{2})[)\]]?$`)
+	episodeRe = regexp.MustCompile(`^[sS]\d{1,2}[eE]\d{1,3}$`)
+	trackRe   = regexp.MustCompile(`^\d{1,3}(?:\s*[-.]\s*|\s+)`)
+	// release tags which end the title of scene style filenames
+	tagRe = regexp.MustCompile(`(?i)^(\d{3,4}p|4k|uhd|hdr|bluray|blu-ray|brrip|bdrip|web|web-?dl|webrip|hdtv|dvdrip|x26[45]|h26[45]|hevc|xvid|aac|ac3|dts|remux|proper|repack)$`)
+)
+
+func baseName(path string) string {
+	name := filepath.Base(path)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// splitWords splits a filename into words. Dots and underscores are used
+// as separators when a name has no spaces.
+func splitWords(name string) []string {
+	if !strings.Contains(name, " ") {
+		name = strings.NewReplacer(".", " ", "_", " ").Replace(name)
+	}
+	return strings.Fields(name)
+}
+
+// VideoName returns a display name for a video file. Movies are named like
+// "Title (Year)" and episodes like "Show S01E02", dropping any release tags.
+func VideoName(path string) string {
+	words := splitWords(baseName(path))
+
+	var title []string
+	for i, w := range words {
+		if episodeRe.MatchString(w) && i > 0 {
+			return strings.Join(title, " ") + " " + strings.ToUpper(w)
+		}
+
+		if m := yearRe.FindStringSubmatch(w); m != nil && i > 0 {
+			return strings.Join(title, " ") + " (" + m[1] + ")"
+		}
+
+		if tagRe.MatchString(w) && i > 0 {
+			break
+		}
+
+		title = append(title, w)
+	}
+
+	return strings.Join(title, " ")
+}
+
+// AudioName returns a display name for an audio file, without any leading
+// track number.
+func AudioName(path string) string {
+	name := baseName(path)
+
+	trimmed := trackRe.ReplaceAllString(name, "")
+	if strings.TrimSpace(trimmed) == "" {
+		return name
+	}
+
+	return strings.Join(splitWords(trimmed), " ")
+}
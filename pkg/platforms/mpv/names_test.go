@@ -0,0 +1,33 @@
+package mpv
+
+import "testing"
+
+func TestNames(t *testing.T) {
+	videos := map[string]string{
+		"/Movies/The.Matrix.1999.1080p.BluRay.x264.mkv":   "The Matrix (1999)",
+		"/Movies/Blade Runner (1982).mp4":                 "Blade Runner (1982)",
+		"/TV/Some_Show_S02E05_720p_WEB.mkv":               "Some Show S02E05",
+		"/TV/Another Show s01e10 Pilot.mkv":               "Another Show S01E10",
+		"/Videos/holiday.mp4":                             "holiday",
+		"/Videos/2001.A.Space.Odyssey.1968.2160p.UHD.mkv": "2001 A Space Odyssey (1968)",
+	}
+
+	for path, want := range videos {
+		if got := VideoName(path); got != want {
+			t.Errorf("VideoName(%q) = %q, want %q", path, got, want)
+		}
+	}
+
+	audio := map[string]string{
+		"/Music/Artist/Album/01 - Song Title.mp3": "Song Title",
+		"/Music/03. Another Song.flac":            "Another Song",
+		"/Music/Artist - Track.ogg":               "Artist - Track",
+		"/Music/1979.mp3":                         "1979",
+	}
+
+	for path, want := range audio {
+		if got := AudioName(path); got != want {
+			t.Errorf("AudioName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package platforms
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrNoticeUnsupported = errors.New("notices not supported")
+
+// NoticeTitle is the title of notices on platforms which show one.
+const NoticeTitle = "Zaparoo"
+
+// Notice is a short message shown on screen, like a toast.
+type Notice struct {
+	Text string
+	// Error notices are shown as urgent, where the platform can.
+	Error    bool
+	Duration time.Duration
+}
@@ -1,6 +1,9 @@
 package platforms
 
 import (
+	"os"
+	"os/exec"
+
 	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
 	"github.com/ZaparooProject/zaparoo-core/pkg/config"
 	"github.com/ZaparooProject/zaparoo-core/pkg/readers"
@@ -11,6 +14,7 @@ import (
 const (
 	AssetsDir   = "assets"
 	MappingsDir = "mappings"
+	SoundsDir   = "sounds"
 )
 
 type CmdEnv struct {
@@ -23,6 +27,10 @@ type CmdEnv struct {
 	Text          string
 	TotalCommands int
 	CurrentIndex  int
+	// Set when running a launch hook, which won't run any further hooks.
+	Hook bool
+	// Set when the token came from a remote client, like the API or MQTT.
+	Remote bool
 }
 
 type ScanResult struct {
@@ -50,12 +58,18 @@ type Launcher struct {
 	Launch func(*config.Instance, string) error
 	// Kill function kills the current active launcher, if possible.
 	Kill func(*config.Instance) error
+	// Optional function to control the emulator while this launcher's media
+	// is running, such as saving a state.
+	Control func(*config.Instance, EmulatorOp, int) error
 	// Optional function to perform custom media scanning. Takes the list of
 	// results from the standard scan, if any, and returns the final list.
 	Scanner func(*config.Instance, string, []ScanResult) ([]ScanResult, error)
 	// If true, all resolved paths must be in the allow list before they
 	// can be launched.
 	AllowListOnly bool
+	// If true, the launcher's process is started with LaunchCommand so the
+	// args, env and cwd launch options apply to it.
+	CommandOptions bool
 }
 
 type Platform interface {
@@ -97,10 +111,6 @@ type Platform interface {
 	KillLauncher() error
 	// Return the ID of the currently active launcher. Empty string if none.
 	GetActiveLauncher() string
-	// Play a sound effect for error feedback.
-	PlayFailSound(*config.Instance) // TODO: change to like PlaySound?
-	// Play a sound effect for success feedback.
-	PlaySuccessSound(*config.Instance)
 	// Returns the currently active system ID.
 	ActiveSystem() string
 	// Returns the currently active game ID.
@@ -122,10 +132,58 @@ type Platform interface {
 	// Process a token command that has been resolved to a platform command.
 	ForwardCmd(CmdEnv) error
 	LookupMapping(tokens.Token) (string, bool)
-	Launchers() []Launcher
+	// Launchers returns the platform's launchers, including custom launchers
+	// defined in the user's config.
+	Launchers(*config.Instance) []Launcher
+	// ShowNotice shows a short message on screen. Returns
+	// ErrNoticeUnsupported if the platform can't show it right now.
+	ShowNotice(*config.Instance, Notice) error
 }
 
 type LaunchToken struct {
 	Token    tokens.Token
 	Launcher Launcher
 }
+
+// LaunchCommand creates the command to start a launcher's process, with the
+// user's launch options for the launcher and system applied. The launch
+// options' args are added after args.
+func LaunchCommand(
+	cfg *config.Instance,
+	launcherId string,
+	systemId string,
+	name string,
+	args ...string,
+) *exec.Cmd {
+	return LaunchCommandFiles(cfg, launcherId, systemId, name, args)
+}
+
+// LaunchCommandFiles is LaunchCommand with files added after the launch
+// options' args, for programs which stop reading options at the first file
+// or at "--".
+func LaunchCommandFiles(
+	cfg *config.Instance,
+	launcherId string,
+	systemId string,
+	name string,
+	args []string,
+	files ...string,
+) *exec.Cmd {
+	opts := cfg.LookupLaunchOptions(launcherId, systemId)
+
+	cmdArgs := append(append([]string{}, args...), opts.Args...)
+	cmd := exec.Command(name, append(cmdArgs, files...)...)
+
+	if len(opts.Env) > 0 {
+		cmd.Env = os.Environ()
+		for k, v := range opts.Env {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+	}
+
+	if opts.Cwd != "" {
+		cmd.Dir = opts.Cwd
+	}
+
+	return cmd
+}
@@ -0,0 +1,241 @@
+//go:build linux
+
+// Package proctracker keeps track of the process started by a launcher, for
+// platforms which have no other way to know what media is running. It sends
+// media started and stopped notifications as the process comes and goes.
+package proctracker
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/assets"
+	"github.com/rs/zerolog/log"
+)
+
+// How often to check on processes which aren't children of the service.
+const pollInterval = 500 * time.Millisecond
+
+type Media struct {
+	LauncherId string
+	SystemId   string
+	SystemName string
+	Path       string
+	Name       string
+	Pid        int
+	Started    time.Time
+}
+
+// NewMedia fills in the display details for media launched from path.
+func NewMedia(launcherId string, systemId string, path string) Media {
+	m := Media{
+		LauncherId: launcherId,
+		SystemId:   systemId,
+		SystemName: systemId,
+		Path:       path,
+	}
+
+	if systemId != "" {
+		meta, err := assets.GetSystemMetadata(systemId)
+		if err == nil {
+			m.SystemName = meta.Name
+		}
+	}
+
+	name := path
+	if i := strings.Index(name, "://"); i >= 0 {
+		name = strings.TrimSuffix(name[i+3:], "/")
+	}
+	name = filepath.Base(name)
+	m.Name = strings.TrimSuffix(name, filepath.Ext(name))
+
+	return m
+}
+
+// Tracker holds the currently active media. The zero value is ready to use
+// and will not send notifications until SetNotifications is called.
+type Tracker struct {
+	mu     sync.RWMutex
+	ns     chan<- models.Notification
+	active *Media
+	proc   *os.Process
+	// incremented on every new process so late exits of replaced
+	// processes can be ignored
+	seq int
+}
+
+func (t *Tracker) SetNotifications(ns chan<- models.Notification) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ns = ns
+}
+
+func (t *Tracker) notify(n models.Notification) {
+	t.mu.RLock()
+	ns := t.ns
+	t.mu.RUnlock()
+	if ns != nil {
+		ns <- n
+	}
+}
+
+// Active returns the currently running media, if any.
+func (t *Tracker) Active() (Media, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.active == nil {
+		return Media{}, false
+	}
+	return *t.active, true
+}
+
+func (t *Tracker) track(m Media, proc *os.Process, wait func() error) {
+	m.Pid = proc.Pid
+	m.Started = time.Now()
+
+	t.mu.Lock()
+	replaced := t.active != nil
+	t.seq++
+	seq := t.seq
+	t.active = &m
+	t.proc = proc
+	t.mu.Unlock()
+
+	log.Info().Msgf("tracking media process %d: %s", m.Pid, m.Path)
+
+	if replaced {
+		t.notify(models.Notification{
+			Method: models.NotificationStopped,
+		})
+	}
+
+	t.notify(models.Notification{
+		Method: models.NotificationStarted,
+		Params: models.MediaStartedParams{
+			SystemId:   m.SystemId,
+			SystemName: m.SystemName,
+			MediaPath:  m.Path,
+			MediaName:  m.Name,
+		},
+	})
+
+	go func() {
+		err := wait()
+		if err != nil {
+			log.Debug().Err(err).Msgf("media process %d exited", m.Pid)
+		} else {
+			log.Debug().Msgf("media process %d exited", m.Pid)
+		}
+		t.exited(seq)
+	}()
+}
+
+func (t *Tracker) exited(seq int) {
+	t.mu.Lock()
+	if seq != t.seq || t.active == nil {
+		t.mu.Unlock()
+		return
+	}
+	t.active = nil
+	t.proc = nil
+	t.mu.Unlock()
+
+	t.notify(models.Notification{
+		Method: models.NotificationStopped,
+	})
+}
+
+// Start runs the launcher command and tracks it as the active media until
+// it exits.
+func (t *Tracker) Start(cmd *exec.Cmd, m Media) error {
+	err := cmd.Start()
+	if err != nil {
+		return err
+	}
+	t.track(m, cmd.Process, cmd.Wait)
+	return nil
+}
+
+// Watch tracks a process which wasn't started directly by the launcher,
+// such as a game started by a separate client. The find function is polled
+// until it returns a PID or the timeout is reached.
+func (t *Tracker) Watch(m Media, find func() (int, bool), timeout time.Duration) {
+	go func() {
+		deadline := time.Now().Add(timeout)
+		for time.Now().Before(deadline) {
+			pid, ok := find()
+			if !ok {
+				time.Sleep(pollInterval)
+				continue
+			}
+
+			proc, err := os.FindProcess(pid)
+			if err != nil {
+				log.Error().Err(err).Msgf("error finding media process: %d", pid)
+				return
+			}
+
+			t.track(m, proc, func() error {
+				for proc.Signal(syscall.Signal(0)) == nil {
+					time.Sleep(pollInterval)
+				}
+				return nil
+			})
+			return
+		}
+		log.Warn().Msgf("timed out waiting for media process: %s", m.Path)
+	}()
+}
+
+// Kill stops the active media process. Does nothing if no media is active.
+func (t *Tracker) Kill() error {
+	t.mu.RLock()
+	proc := t.proc
+	t.mu.RUnlock()
+
+	if proc == nil {
+		return nil
+	}
+
+	return proc.Kill()
+}
+
+// FindByArg returns a function which searches running processes for one
+// with the given exact command line argument, e.g. "AppId=123" for a Steam
+// game started by its reaper process.
+func FindByArg(arg string) func() (int, bool) {
+	return func() (int, bool) {
+		entries, err := os.ReadDir("/proc")
+		if err != nil {
+			log.Error().Err(err).Msg("error reading proc")
+			return 0, false
+		}
+
+		for _, e := range entries {
+			pid, err := strconv.Atoi(e.Name())
+			if err != nil {
+				continue
+			}
+
+			data, err := os.ReadFile(filepath.Join("/proc", e.Name(), "cmdline"))
+			if err != nil {
+				continue
+			}
+
+			for _, a := range strings.Split(string(data), "\x00") {
+				if a == arg {
+					return pid, true
+				}
+			}
+		}
+
+		return 0, false
+	}
+}
@@ -0,0 +1,192 @@
+//go:build linux
+
+package proctracker
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+)
+
+func nextNotification(t *testing.T, ns <-chan models.Notification) models.Notification {
+	t.Helper()
+	select {
+	case n := <-ns:
+		return n
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for notification")
+		return models.Notification{}
+	}
+}
+
+func expectMethod(t *testing.T, ns <-chan models.Notification, method string) models.Notification {
+	t.Helper()
+	n := nextNotification(t, ns)
+	if n.Method != method {
+		t.Fatalf("got %s notification, want %s", n.Method, method)
+	}
+	return n
+}
+
+func newTracker() (*Tracker, chan models.Notification) {
+	ns := make(chan models.Notification, 10)
+	t := &Tracker{}
+	t.SetNotifications(ns)
+	return t, ns
+}
+
+func TestNewMedia(t *testing.T) {
+	m := NewMedia("launcher", "", "/roms/snes/Super Metroid (USA).sfc")
+	if m.Name != "Super Metroid (USA)" || m.SystemName != "" {
+		t.Errorf("unexpected file media: %+v", m)
+	}
+
+	m = NewMedia("steam", "PC", "steam://rungameid/123")
+	if m.Name != "123" || m.SystemName == "" {
+		t.Errorf("unexpected url media: %+v", m)
+	}
+}
+
+func TestStartAndKill(t *testing.T) {
+	tr, ns := newTracker()
+
+	err := tr.Start(exec.Command("sleep", "30"), NewMedia("test", "", "/media/game.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := expectMethod(t, ns, models.NotificationStarted)
+	params, ok := n.Params.(models.MediaStartedParams)
+	if !ok || params.MediaName != "game" || params.MediaPath != "/media/game.bin" {
+		t.Errorf("unexpected started params: %+v", n.Params)
+	}
+
+	m, ok := tr.Active()
+	if !ok || m.Pid == 0 || m.Started.IsZero() {
+		t.Fatalf("media not active: %+v", m)
+	}
+
+	err = tr.Kill()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectMethod(t, ns, models.NotificationStopped)
+	if _, ok := tr.Active(); ok {
+		t.Error("media still active after exit")
+	}
+
+	if err := tr.Kill(); err != nil {
+		t.Errorf("kill without active media: %s", err)
+	}
+}
+
+func TestStartReplacesActive(t *testing.T) {
+	tr, ns := newTracker()
+
+	first := exec.Command("sleep", "30")
+	err := tr.Start(first, NewMedia("test", "", "first"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectMethod(t, ns, models.NotificationStarted)
+
+	err = tr.Start(exec.Command("sleep", "30"), NewMedia("test", "", "second"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectMethod(t, ns, models.NotificationStopped)
+	expectMethod(t, ns, models.NotificationStarted)
+
+	// a replaced process exiting doesn't stop the active media
+	_ = first.Process.Kill()
+	select {
+	case n := <-ns:
+		t.Fatalf("unexpected %s notification", n.Method)
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	m, ok := tr.Active()
+	if !ok || m.Name != "second" {
+		t.Fatalf("unexpected active media: %+v", m)
+	}
+
+	_ = tr.Kill()
+	expectMethod(t, ns, models.NotificationStopped)
+}
+
+func TestWatch(t *testing.T) {
+	tr, ns := newTracker()
+
+	// started outside the tracker, like a game run by another client
+	cmd := exec.Command("sleep", "30.25")
+	err := cmd.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = cmd.Wait()
+	}()
+
+	find := FindByArg("30.25")
+	polls := 0
+	tr.Watch(NewMedia("test", "", "watched"), func() (int, bool) {
+		// not found on the first poll
+		polls++
+		if polls == 1 {
+			return 0, false
+		}
+		return find()
+	}, 10*time.Second)
+
+	expectMethod(t, ns, models.NotificationStarted)
+
+	m, ok := tr.Active()
+	if !ok || m.Pid != cmd.Process.Pid {
+		t.Fatalf("wrong process watched: %+v, want pid %d", m, cmd.Process.Pid)
+	}
+
+	err = tr.Kill()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectMethod(t, ns, models.NotificationStopped)
+}
+
+func TestWatchTimeout(t *testing.T) {
+	tr, ns := newTracker()
+
+	tr.Watch(NewMedia("test", "", "missing"), func() (int, bool) {
+		return 0, false
+	}, 100*time.Millisecond)
+
+	select {
+	case n := <-ns:
+		t.Fatalf("unexpected %s notification", n.Method)
+	case <-time.After(time.Second):
+	}
+
+	if _, ok := tr.Active(); ok {
+		t.Error("media active without a process")
+	}
+}
+
+func TestNoNotifications(t *testing.T) {
+	tr := &Tracker{}
+
+	err := tr.Start(exec.Command("true"), NewMedia("test", "", "quiet"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, ok := tr.Active(); !ok {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("media still active after exit")
+}
@@ -0,0 +1,163 @@
+package retroarch
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCommandPort is the UDP port RetroArch listens on for network
+// commands, when they're enabled.
+const DefaultCommandPort = 55355
+
+// How long to wait for a reply to a command.
+const replyTimeout = 500 * time.Millisecond
+
+const (
+	CmdQuit        = "QUIT"
+	CmdSaveState   = "SAVE_STATE"
+	CmdLoadState   = "LOAD_STATE"
+	CmdPauseToggle = "PAUSE_TOGGLE"
+	CmdGetStatus   = "GET_STATUS"
+	CmdReset       = "RESET"
+	CmdScreenshot  = "SCREENSHOT"
+	// These take a slot number, they need RetroArch 1.17 or newer.
+	CmdSaveStateSlot = "SAVE_STATE_SLOT"
+	CmdLoadStateSlot = "LOAD_STATE_SLOT"
+)
+
+const (
+	StatePlaying     = "PLAYING"
+	StatePaused      = "PAUSED"
+	StateContentless = "CONTENTLESS"
+)
+
+// Status is the reply to a GET_STATUS command.
+type Status struct {
+	State string
+	// The libretro system ID of the running core, such as "super_nes".
+	CoreSystemId string
+	// Content filename without its extension.
+	Content string
+	Crc32   string
+}
+
+// Running returns true if content is loaded, even if it's paused.
+func (s Status) Running() bool {
+	return s.State == StatePlaying || s.State == StatePaused
+}
+
+// Client sends commands to RetroArch's network command interface.
+type Client struct {
+	addr string
+}
+
+func NewClient(port int) *Client {
+	if port == 0 {
+		port = DefaultCommandPort
+	}
+	return &Client{
+		addr: net.JoinHostPort("127.0.0.1", strconv.Itoa(port)),
+	}
+}
+
+// Send sends a command without waiting for a reply. RetroArch doesn't reply
+// to most commands, so an error is only returned if it couldn't be sent.
+func (c *Client) Send(cmd string) error {
+	conn, err := net.Dial("udp", c.addr)
+	if err != nil {
+		return err
+	}
+	defer func(conn net.Conn) {
+		_ = conn.Close()
+	}(conn)
+
+	_, err = conn.Write([]byte(cmd))
+	return err
+}
+
+func (c *Client) request(cmd string) (string, error) {
+	conn, err := net.Dial("udp", c.addr)
+	if err != nil {
+		return "", err
+	}
+	defer func(conn net.Conn) {
+		_ = conn.Close()
+	}(conn)
+
+	_, err = conn.Write([]byte(cmd))
+	if err != nil {
+		return "", err
+	}
+
+	err = conn.SetReadDeadline(time.Now().Add(replyTimeout))
+	if err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(buf[:n])), nil
+}
+
+func (c *Client) Quit() error {
+	return c.Send(CmdQuit)
+}
+
+func (c *Client) SaveState() error {
+	return c.Send(CmdSaveState)
+}
+
+func (c *Client) LoadState() error {
+	return c.Send(CmdLoadState)
+}
+
+func (c *Client) PauseToggle() error {
+	return c.Send(CmdPauseToggle)
+}
+
+// GetStatus asks RetroArch what it's running. An error is returned if
+// RetroArch isn't running or doesn't have network commands enabled.
+func (c *Client) GetStatus() (Status, error) {
+	reply, err := c.request(CmdGetStatus)
+	if err != nil {
+		return Status{}, err
+	}
+	return parseStatus(reply)
+}
+
+// The reply is in the format:
+// GET_STATUS PLAYING super_nes,Super Mario World,crc32=b19ed489
+func parseStatus(reply string) (Status, error) {
+	rest, ok := strings.CutPrefix(reply, CmdGetStatus+" ")
+	if !ok {
+		return Status{}, fmt.Errorf("unexpected status reply: %s", reply)
+	}
+
+	state, info, _ := strings.Cut(rest, " ")
+	status := Status{State: state}
+	if state == "" {
+		return status, errors.New("missing state in status reply")
+	} else if info == "" {
+		return status, nil
+	}
+
+	system, info, _ := strings.Cut(info, ",")
+	status.CoreSystemId = system
+
+	// the content name may contain commas itself
+	if i := strings.LastIndex(info, ",crc32="); i >= 0 {
+		status.Crc32 = info[i+len(",crc32="):]
+		info = info[:i]
+	}
+	status.Content = info
+
+	return status, nil
+}
@@ -0,0 +1,143 @@
+package retroarch
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+)
+
+// Stands in for RetroArch's network command interface, replying to status
+// requests and recording every other command received.
+func newTestServer(t *testing.T, status string) (int, <-chan string) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	cmds := make(chan string, 10)
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			cmd := string(buf[:n])
+			if cmd == CmdGetStatus {
+				_, _ = conn.WriteTo([]byte(status+"\n"), addr)
+				continue
+			}
+			cmds <- cmd
+		}
+	}()
+
+	return conn.LocalAddr().(*net.UDPAddr).Port, cmds
+}
+
+func TestClient(t *testing.T) {
+	port, cmds := newTestServer(t, "GET_STATUS PLAYING super_nes,Mario, Luigi,crc32=b19ed489")
+	c := NewClient(port)
+
+	status, err := c.GetStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Status{
+		State:        StatePlaying,
+		CoreSystemId: "super_nes",
+		Content:      "Mario, Luigi",
+		Crc32:        "b19ed489",
+	}
+	if status != want {
+		t.Errorf("got %+v, want %+v", status, want)
+	}
+
+	for cmd, send := range map[string]func() error{
+		CmdQuit:        c.Quit,
+		CmdSaveState:   c.SaveState,
+		CmdLoadState:   c.LoadState,
+		CmdPauseToggle: c.PauseToggle,
+	} {
+		err := send()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case got := <-cmds:
+			if got != cmd {
+				t.Errorf("got command %q, want %q", got, cmd)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("command not received: %s", cmd)
+		}
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	status, err := parseStatus("GET_STATUS CONTENTLESS")
+	if err != nil {
+		t.Fatal(err)
+	} else if status.State != StateContentless || status.Running() {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	_, err = parseStatus("UNKNOWN")
+	if err == nil {
+		t.Error("expected error for unexpected reply")
+	}
+}
+
+func TestControl(t *testing.T) {
+	port, cmds := newTestServer(t, "GET_STATUS PAUSED nes,Zelda,crc32=0")
+
+	defaults := config.BaseDefaults
+	defaults.Launchers.RetroArch.CommandPort = port
+	cfg, err := config.NewConfig(t.TempDir(), defaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		op   platforms.EmulatorOp
+		slot int
+		want string
+	}{
+		{platforms.EmulatorSaveState, 0, CmdSaveState},
+		{platforms.EmulatorSaveState, 3, "SAVE_STATE_SLOT 3"},
+		{platforms.EmulatorLoadState, 2, "LOAD_STATE_SLOT 2"},
+		{platforms.EmulatorReset, 0, CmdReset},
+		{platforms.EmulatorPause, 0, CmdPauseToggle},
+		{platforms.EmulatorScreenshot, 0, CmdScreenshot},
+	}
+
+	for _, tt := range tests {
+		err := Control(cfg, tt.op, tt.slot)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case got := <-cmds:
+			if got != tt.want {
+				t.Errorf("%s: got command %q, want %q", tt.op, got, tt.want)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("%s: command not received", tt.op)
+		}
+	}
+
+	err = Control(cfg, "rewind", 0)
+	if !errors.Is(err, platforms.ErrEmulatorUnsupported) {
+		t.Errorf("expected unsupported error, got: %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package retroarch
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/database/gamesdb"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+	"github.com/rs/zerolog/log"
+)
+
+// Core paths in playlists set to this are picked when launched.
+const detectCore = "DETECT"
+
+// Libretro database names, which playlists are named after, mapped to
+// gamesdb system IDs.
+var dbSystemIds = map[string]string{
+	"Atari - 2600":                                   gamesdb.SystemAtari2600,
+	"Atari - 5200":                                   gamesdb.SystemAtari5200,
+	"Atari - 7800":                                   gamesdb.SystemAtari7800,
+	"Atari - Jaguar":                                 gamesdb.SystemJaguar,
+	"Atari - Lynx":                                   gamesdb.SystemAtariLynx,
+	"Bandai - WonderSwan":                            gamesdb.SystemWonderSwan,
+	"Bandai - WonderSwan Color":                      gamesdb.SystemWonderSwanColor,
+	"Coleco - ColecoVision":                          gamesdb.SystemColecoVision,
+	"Commodore - 64":                                 gamesdb.SystemC64,
+	"Commodore - Amiga":                              gamesdb.SystemAmiga,
+	"DOS":                                            gamesdb.SystemDOS,
+	"FBNeo - Arcade Games":                           gamesdb.SystemArcade,
+	"GCE - Vectrex":                                  gamesdb.SystemVectrex,
+	"MAME":                                           gamesdb.SystemArcade,
+	"Mattel - Intellivision":                         gamesdb.SystemIntellivision,
+	"Microsoft - MSX":                                gamesdb.SystemMSX,
+	"Microsoft - MSX2":                               gamesdb.SystemMSX,
+	"NEC - PC Engine - TurboGrafx 16":                gamesdb.SystemTurboGrafx16,
+	"NEC - PC Engine CD - TurboGrafx-CD":             gamesdb.SystemTurboGrafx16CD,
+	"NEC - PC Engine SuperGrafx":                     gamesdb.SystemSuperGrafx,
+	"Nintendo - Family Computer Disk System":         gamesdb.SystemFDS,
+	"Nintendo - Game Boy":                            gamesdb.SystemGameboy,
+	"Nintendo - Game Boy Advance":                    gamesdb.SystemGBA,
+	"Nintendo - Game Boy Color":                      gamesdb.SystemGameboyColor,
+	"Nintendo - GameCube":                            gamesdb.SystemGameCube,
+	"Nintendo - Nintendo 3DS":                        gamesdb.System3DS,
+	"Nintendo - Nintendo 64":                         gamesdb.SystemNintendo64,
+	"Nintendo - Nintendo DS":                         gamesdb.SystemNDS,
+	"Nintendo - Nintendo Entertainment System":       gamesdb.SystemNES,
+	"Nintendo - Pokemon Mini":                        gamesdb.SystemPokemonMini,
+	"Nintendo - Super Nintendo Entertainment System": gamesdb.SystemSNES,
+	"Nintendo - Virtual Boy":                         gamesdb.SystemVirtualBoy,
+	"Nintendo - Wii":                                 gamesdb.SystemWii,
+	"SNK - Neo Geo":                                  gamesdb.SystemNeoGeo,
+	"SNK - Neo Geo CD":                               gamesdb.SystemNeoGeoCD,
+	"SNK - Neo Geo Pocket":                           gamesdb.SystemNeoGeoPocket,
+	"SNK - Neo Geo Pocket Color":                     gamesdb.SystemNeoGeoPocketColor,
+	"Sega - 32X":                                     gamesdb.SystemSega32X,
+	"Sega - Dreamcast":                               gamesdb.SystemDreamcast,
+	"Sega - Game Gear":                               gamesdb.SystemGameGear,
+	"Sega - Master System - Mark III":                gamesdb.SystemMasterSystem,
+	"Sega - Mega Drive - Genesis":                    gamesdb.SystemGenesis,
+	"Sega - Mega-CD - Sega CD":                       gamesdb.SystemMegaCD,
+	"Sega - SG-1000":                                 gamesdb.SystemSG1000,
+	"Sega - Saturn":                                  gamesdb.SystemSaturn,
+	"Sinclair - ZX 81":                               gamesdb.SystemZX81,
+	"Sinclair - ZX Spectrum":                         gamesdb.SystemZXSpectrum,
+	"Sony - PlayStation":                             gamesdb.SystemPSX,
+	"Sony - PlayStation 2":                           gamesdb.SystemPS2,
+	"Sony - PlayStation Portable":                    gamesdb.SystemPSP,
+	"The 3DO Company - 3DO":                          gamesdb.System3DO,
+}
+
+// PlaylistItem is a game from a playlist, with the system it belongs to.
+type PlaylistItem struct {
+	SystemId string
+	Path     string
+	Label    string
+	// Path to the core set for the game, empty if it should be picked for
+	// the system.
+	CorePath string
+}
+
+type lplFile struct {
+	Items []struct {
+		Path     string `json:"path"`
+		Label    string `json:"label"`
+		CorePath string `json:"core_path"`
+		DbName   string `json:"db_name"`
+	} `json:"items"`
+}
+
+// dbSystemId returns the system ID for a playlist or database name.
+func dbSystemId(name string) (string, bool) {
+	id, ok := dbSystemIds[strings.TrimSuffix(name, ".lpl")]
+	return id, ok
+}
+
+// ParsePlaylist reads the items from a JSON .lpl playlist file. Items are
+// assigned a system from their database name, or the playlist's name if
+// they have none. Items with an unknown system are skipped.
+func ParsePlaylist(name string, data []byte) ([]PlaylistItem, error) {
+	var lpl lplFile
+	err := json.Unmarshal(data, &lpl)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []PlaylistItem
+	for _, i := range lpl.Items {
+		if i.Path == "" {
+			continue
+		}
+
+		systemId, ok := dbSystemId(i.DbName)
+		if !ok {
+			systemId, ok = dbSystemId(name)
+		}
+		if !ok {
+			continue
+		}
+
+		item := PlaylistItem{
+			SystemId: systemId,
+			Path:     i.Path,
+			Label:    i.Label,
+		}
+		if i.CorePath != detectCore {
+			item.CorePath = i.CorePath
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// ReadPlaylists reads the items from all playlists in a folder.
+func ReadPlaylists(dir string) ([]PlaylistItem, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.lpl"))
+	if err != nil {
+		return nil, err
+	}
+
+	var items []PlaylistItem
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Error().Err(err).Msgf("error reading playlist: %s", path)
+			continue
+		}
+
+		pis, err := ParsePlaylist(filepath.Base(path), data)
+		if err != nil {
+			// older line based playlists aren't supported
+			log.Warn().Err(err).Msgf("error parsing playlist: %s", path)
+			continue
+		}
+
+		items = append(items, pis...)
+	}
+
+	return items, nil
+}
+
+func findPlaylistItem(dir string, path string) (PlaylistItem, bool) {
+	items, err := ReadPlaylists(dir)
+	if err != nil {
+		return PlaylistItem{}, false
+	}
+
+	for _, item := range items {
+		if strings.EqualFold(item.Path, path) {
+			return item, true
+		}
+	}
+
+	return PlaylistItem{}, false
+}
+
+func scanPlaylists(dir string, systemId string, results []platforms.ScanResult) ([]platforms.ScanResult, error) {
+	items, err := ReadPlaylists(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		if item.SystemId == systemId {
+			results = append(results, platforms.ScanResult{
+				Path: item.Path,
+				Name: item.Label,
+			})
+		}
+	}
+
+	return results, nil
+}
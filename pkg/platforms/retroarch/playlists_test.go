@@ -0,0 +1,59 @@
+package retroarch
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/database/gamesdb"
+)
+
+func TestParsePlaylist(t *testing.T) {
+	data := []byte(`{
+		"version": "1.5",
+		"items": [
+			{
+				"path": "/roms/snes/Super Mario World.sfc",
+				"label": "Super Mario World",
+				"core_path": "DETECT",
+				"db_name": "Nintendo - Super Nintendo Entertainment System.lpl"
+			},
+			{
+				"path": "/roms/md/Sonic.md",
+				"label": "Sonic",
+				"core_path": "/cores/picodrive_libretro.so",
+				"db_name": ""
+			},
+			{
+				"path": "/roms/unknown.bin",
+				"db_name": "Unknown - System.lpl"
+			}
+		]
+	}`)
+
+	items, err := ParsePlaylist("Sega - Mega Drive - Genesis.lpl", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []PlaylistItem{
+		{
+			SystemId: gamesdb.SystemSNES,
+			Path:     "/roms/snes/Super Mario World.sfc",
+			Label:    "Super Mario World",
+		},
+		{
+			SystemId: gamesdb.SystemGenesis,
+			Path:     "/roms/md/Sonic.md",
+			Label:    "Sonic",
+			CorePath: "/cores/picodrive_libretro.so",
+		},
+		{
+			SystemId: gamesdb.SystemGenesis,
+			Path:     "/roms/unknown.bin",
+		},
+	}
+
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("got %+v, want %+v", items, want)
+	}
+}
@@ -0,0 +1,344 @@
+// Package retroarch provides launchers which run games in RetroArch, for
+// platforms where it's installed. Running games are controlled through
+// RetroArch's UDP network command interface, which is enabled for every
+// game launched by Zaparoo.
+package retroarch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/database/gamesdb"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+	"github.com/ZaparooProject/zaparoo-core/pkg/utils"
+)
+
+// LauncherId is the ID of the launcher for games found in playlists. System
+// launchers use it as a prefix, followed by the system ID.
+const LauncherId = "RetroArch"
+
+// Core is the default core used for a system, and where its games are found.
+type Core struct {
+	Core       string
+	Folders    []string
+	Extensions []string
+}
+
+// DefaultCores are the cores used for each system unless replaced in the
+// config. Only systems listed here get a launcher of their own.
+var DefaultCores = map[string]Core{
+	gamesdb.SystemArcade:            {"fbneo", []string{"arcade", "fbneo", "mame"}, []string{".zip"}},
+	gamesdb.SystemAtari2600:         {"stella", []string{"atari2600"}, []string{".a26", ".bin", ".zip"}},
+	gamesdb.SystemAtari7800:         {"prosystem", []string{"atari7800"}, []string{".a78", ".zip"}},
+	gamesdb.SystemAtariLynx:         {"handy", []string{"atarilynx", "lynx"}, []string{".lnx", ".zip"}},
+	gamesdb.SystemColecoVision:      {"bluemsx", []string{"coleco", "colecovision"}, []string{".col", ".zip"}},
+	gamesdb.SystemDreamcast:         {"flycast", []string{"dreamcast"}, []string{".cdi", ".chd", ".gdi"}},
+	gamesdb.SystemFDS:               {"fceumm", []string{"fds"}, []string{".fds", ".zip"}},
+	gamesdb.SystemGBA:               {"mgba", []string{"gba"}, []string{".gba", ".zip"}},
+	gamesdb.SystemGameGear:          {"genesis_plus_gx", []string{"gamegear"}, []string{".gg", ".zip"}},
+	gamesdb.SystemGameboy:           {"gambatte", []string{"gb"}, []string{".gb", ".zip"}},
+	gamesdb.SystemGameboyColor:      {"gambatte", []string{"gbc"}, []string{".gbc", ".zip"}},
+	gamesdb.SystemGenesis:           {"genesis_plus_gx", []string{"megadrive", "genesis"}, []string{".bin", ".gen", ".md", ".smd", ".zip"}},
+	gamesdb.SystemMasterSystem:      {"genesis_plus_gx", []string{"mastersystem"}, []string{".sms", ".zip"}},
+	gamesdb.SystemMegaCD:            {"genesis_plus_gx", []string{"segacd", "megacd"}, []string{".chd", ".cue", ".iso"}},
+	gamesdb.SystemNDS:               {"melonds", []string{"nds"}, []string{".nds", ".zip"}},
+	gamesdb.SystemNES:               {"fceumm", []string{"nes"}, []string{".nes", ".unf", ".unif", ".zip"}},
+	gamesdb.SystemNeoGeoPocket:      {"mednafen_ngp", []string{"ngp"}, []string{".ngp", ".zip"}},
+	gamesdb.SystemNeoGeoPocketColor: {"mednafen_ngp", []string{"ngpc"}, []string{".ngc", ".zip"}},
+	gamesdb.SystemNintendo64:        {"mupen64plus_next", []string{"n64"}, []string{".n64", ".v64", ".z64", ".zip"}},
+	gamesdb.SystemPSP:               {"ppsspp", []string{"psp"}, []string{".cso", ".iso", ".pbp"}},
+	gamesdb.SystemPSX:               {"pcsx_rearmed", []string{"psx"}, []string{".chd", ".cue", ".m3u", ".pbp"}},
+	gamesdb.SystemPokemonMini:       {"pokemini", []string{"pokemini"}, []string{".min", ".zip"}},
+	gamesdb.SystemSG1000:            {"genesis_plus_gx", []string{"sg-1000", "sg1000"}, []string{".sg", ".zip"}},
+	gamesdb.SystemSNES:              {"snes9x", []string{"snes"}, []string{".sfc", ".smc", ".zip"}},
+	gamesdb.SystemSaturn:            {"mednafen_saturn", []string{"saturn"}, []string{".chd", ".cue"}},
+	gamesdb.SystemSega32X:           {"picodrive", []string{"sega32x"}, []string{".32x", ".zip"}},
+	gamesdb.SystemTurboGrafx16:      {"mednafen_pce_fast", []string{"pcengine", "tg16"}, []string{".pce", ".zip"}},
+	gamesdb.SystemTurboGrafx16CD:    {"mednafen_pce_fast", []string{"pcenginecd", "tg16cd"}, []string{".chd", ".cue"}},
+	gamesdb.SystemVectrex:           {"vecx", []string{"vectrex"}, []string{".vec", ".zip"}},
+	gamesdb.SystemVirtualBoy:        {"mednafen_vb", []string{"virtualboy"}, []string{".vb", ".zip"}},
+	gamesdb.SystemWonderSwan:        {"mednafen_wswan", []string{"wonderswan"}, []string{".ws", ".zip"}},
+	gamesdb.SystemWonderSwanColor:   {"mednafen_wswan", []string{"wonderswancolor"}, []string{".wsc", ".zip"}},
+}
+
+// System IDs reported by cores in a status reply, mapped to gamesdb.
+var coreSystemIds = map[string]string{
+	"atari_2600":           gamesdb.SystemAtari2600,
+	"game_boy":             gamesdb.SystemGameboy,
+	"game_boy_advance":     gamesdb.SystemGBA,
+	"game_boy_color":       gamesdb.SystemGameboyColor,
+	"game_gear":            gamesdb.SystemGameGear,
+	"master_system":        gamesdb.SystemMasterSystem,
+	"mega_drive":           gamesdb.SystemGenesis,
+	"nes":                  gamesdb.SystemNES,
+	"nintendo_64":          gamesdb.SystemNintendo64,
+	"nintendo_ds":          gamesdb.SystemNDS,
+	"pc_engine":            gamesdb.SystemTurboGrafx16,
+	"playstation":          gamesdb.SystemPSX,
+	"playstation_portable": gamesdb.SystemPSP,
+	"super_nes":            gamesdb.SystemSNES,
+}
+
+// Options are the platform's defaults for where RetroArch is installed.
+// Each can be replaced in the config.
+type Options struct {
+	Binary       string
+	CoresDir     string
+	PlaylistsDir string
+}
+
+func (o Options) withConfig(cfg *config.Instance) (Options, int) {
+	ra := cfg.RetroArch()
+	if ra.Path != "" {
+		o.Binary = ra.Path
+	} else if o.Binary == "" {
+		o.Binary = "retroarch"
+	}
+	if ra.CoresDir != "" {
+		o.CoresDir = ra.CoresDir
+	}
+	if ra.PlaylistsDir != "" {
+		o.PlaylistsDir = ra.PlaylistsDir
+	}
+	return o, ra.CommandPort
+}
+
+// SystemId returns the gamesdb system ID for the system ID reported by a
+// core in a status reply.
+func SystemId(coreSystemId string) string {
+	return coreSystemIds[coreSystemId]
+}
+
+func coreExt() string {
+	switch runtime.GOOS {
+	case "windows":
+		return ".dll"
+	case "darwin":
+		return ".dylib"
+	default:
+		return ".so"
+	}
+}
+
+// CorePath returns the path to a core's file. A core given as a path is
+// returned as is.
+func CorePath(coresDir string, core string) string {
+	if strings.ContainsAny(core, `/\`) {
+		return core
+	}
+
+	if !strings.HasSuffix(core, "_libretro") {
+		core += "_libretro"
+	}
+
+	return filepath.Join(coresDir, core+coreExt())
+}
+
+// LookupCore returns the core to use for a system, preferring the one set
+// in the config.
+func LookupCore(cfg *config.Instance, systemId string) (string, bool) {
+	for _, rc := range cfg.RetroArch().Core {
+		if strings.EqualFold(rc.System, systemId) {
+			return rc.Core, true
+		}
+	}
+
+	if c, ok := DefaultCores[systemId]; ok {
+		return c.Core, true
+	}
+
+	return "", false
+}
+
+// Network commands are enabled with an extra config file appended to the
+// user's, so their own config is never modified.
+func writeAppendConfig(port int) (string, error) {
+	if port == 0 {
+		port = DefaultCommandPort
+	}
+
+	dir := filepath.Join(os.TempDir(), config.AppName)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, "retroarch.cfg")
+	data := fmt.Sprintf("network_cmd_enable = \"true\"\nnetwork_cmd_port = \"%d\"\n", port)
+
+	return path, os.WriteFile(path, []byte(data), 0644)
+}
+
+func launch(
+	cfg *config.Instance,
+	opts Options,
+	start platforms.StartFunc,
+	l platforms.Launcher,
+	corePath string,
+	path string,
+) error {
+	opts, port := opts.withConfig(cfg)
+
+	appendCfg, err := writeAppendConfig(port)
+	if err != nil {
+		return err
+	}
+
+	cmd := platforms.LaunchCommand(
+		cfg,
+		l.Id,
+		l.SystemId,
+		opts.Binary,
+		"-L", corePath,
+		"--appendconfig", appendCfg,
+		path,
+	)
+
+	if start != nil {
+		return start(cmd, l, path)
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return err
+	}
+	go func() {
+		_ = cmd.Wait()
+	}()
+
+	return nil
+}
+
+// RetroArch doesn't reply to most commands, so its status is checked first
+// to know a command will be received.
+func runningClient(cfg *config.Instance) (*Client, error) {
+	c := NewClient(cfg.RetroArch().CommandPort)
+
+	status, err := c.GetStatus()
+	if err != nil {
+		return nil, fmt.Errorf("retroarch not responding to commands: %w", err)
+	} else if !status.Running() {
+		return nil, errors.New("retroarch has no game running")
+	}
+
+	return c, nil
+}
+
+// Kill quits the running game.
+func Kill(cfg *config.Instance) error {
+	c, err := runningClient(cfg)
+	if err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// Control runs an emulator operation on the running game.
+func Control(cfg *config.Instance, op platforms.EmulatorOp, slot int) error {
+	c, err := runningClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	switch op {
+	case platforms.EmulatorSaveState:
+		if slot > 0 {
+			return c.Send(fmt.Sprintf("%s %d", CmdSaveStateSlot, slot))
+		}
+		return c.SaveState()
+	case platforms.EmulatorLoadState:
+		if slot > 0 {
+			return c.Send(fmt.Sprintf("%s %d", CmdLoadStateSlot, slot))
+		}
+		return c.LoadState()
+	case platforms.EmulatorReset:
+		return c.Send(CmdReset)
+	case platforms.EmulatorPause:
+		return c.PauseToggle()
+	case platforms.EmulatorScreenshot:
+		return c.Send(CmdScreenshot)
+	default:
+		return fmt.Errorf("%w by retroarch: %s", platforms.ErrEmulatorUnsupported, op)
+	}
+}
+
+// IsLauncher returns true if a launcher ID belongs to a RetroArch launcher.
+func IsLauncher(id string) bool {
+	return strings.HasPrefix(id, LauncherId)
+}
+
+// Launchers returns a launcher for each system with a default core and a
+// launcher for games from RetroArch's playlists. If start is nil, games are
+// run without being tracked.
+func Launchers(cfg *config.Instance, opts Options, start platforms.StartFunc) []platforms.Launcher {
+	launchers := make([]platforms.Launcher, 0, len(DefaultCores)+1)
+
+	for _, systemId := range utils.AlphaMapKeys(DefaultCores) {
+		dc := DefaultCores[systemId]
+		l := platforms.Launcher{
+			Id:             LauncherId + systemId,
+			SystemId:       systemId,
+			Folders:        dc.Folders,
+			Extensions:     dc.Extensions,
+			Kill:           Kill,
+			Control:        Control,
+			CommandOptions: true,
+		}
+		l.Launch = func(cfg *config.Instance, path string) error {
+			core, _ := LookupCore(cfg, l.SystemId)
+			o, _ := opts.withConfig(cfg)
+			return launch(cfg, opts, start, l, CorePath(o.CoresDir, core), path)
+		}
+
+		launchers = append(launchers, l)
+	}
+
+	// playlist games can be anywhere, so they're matched by path instead
+	// of folder and extension
+	pl := platforms.Launcher{
+		Id:             LauncherId,
+		Kill:           Kill,
+		Control:        Control,
+		CommandOptions: true,
+		Test: func(cfg *config.Instance, path string) bool {
+			o, _ := opts.withConfig(cfg)
+			_, ok := findPlaylistItem(o.PlaylistsDir, path)
+			return ok
+		},
+		Scanner: func(
+			cfg *config.Instance,
+			systemId string,
+			results []platforms.ScanResult,
+		) ([]platforms.ScanResult, error) {
+			o, _ := opts.withConfig(cfg)
+			return scanPlaylists(o.PlaylistsDir, systemId, results)
+		},
+	}
+	pl.Launch = func(cfg *config.Instance, path string) error {
+		o, _ := opts.withConfig(cfg)
+		item, ok := findPlaylistItem(o.PlaylistsDir, path)
+		if !ok {
+			return errors.New("game not found in retroarch playlists: " + path)
+		}
+
+		corePath := item.CorePath
+		if corePath == "" {
+			core, ok := LookupCore(cfg, item.SystemId)
+			if !ok {
+				return errors.New("no retroarch core set for system: " + item.SystemId)
+			}
+			corePath = CorePath(o.CoresDir, core)
+		}
+
+		l := pl
+		l.SystemId = item.SystemId
+		return launch(cfg, opts, start, l, corePath, path)
+	}
+
+	return append(launchers, pl)
+}
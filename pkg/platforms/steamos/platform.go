@@ -21,29 +21,20 @@ along with Zaparoo Core.  If not, see <http://www.gnu.org/licenses/>.
 package steamos
 
 import (
-	"errors"
-	"github.com/ZaparooProject/zaparoo-core/pkg/readers/libnfc"
-	"github.com/ZaparooProject/zaparoo-core/pkg/readers/optical_drive"
-	"github.com/ZaparooProject/zaparoo-core/pkg/service/tokens"
-	"github.com/ZaparooProject/zaparoo-core/pkg/utils"
-	"github.com/adrg/xdg"
-	"github.com/rs/zerolog/log"
-	"os"
-	"os/exec"
-	"path/filepath"
-	"strings"
-
-	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
-
 	"github.com/ZaparooProject/zaparoo-core/pkg/config"
-	"github.com/ZaparooProject/zaparoo-core/pkg/database/gamesdb"
-	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms/linux"
 	"github.com/ZaparooProject/zaparoo-core/pkg/readers"
 	"github.com/ZaparooProject/zaparoo-core/pkg/readers/file"
+	"github.com/ZaparooProject/zaparoo-core/pkg/readers/libnfc"
+	"github.com/ZaparooProject/zaparoo-core/pkg/readers/optical_drive"
 	"github.com/ZaparooProject/zaparoo-core/pkg/readers/simple_serial"
 )
 
-type Platform struct{}
+// Platform is the Linux platform, with the readers supported on the Steam
+// Deck.
+type Platform struct {
+	linux.Platform
+}
 
 func (p *Platform) Id() string {
 	return "steamos"
@@ -57,164 +48,3 @@ func (p *Platform) SupportedReaders(cfg *config.Instance) []readers.Reader {
 		optical_drive.NewReader(cfg),
 	}
 }
-
-func (p *Platform) StartPre(_ *config.Instance) error {
-	return os.MkdirAll(filepath.Join(xdg.DataHome, config.AppName), 0755)
-}
-
-func (p *Platform) StartPost(_ *config.Instance, _ chan<- models.Notification) error {
-	return nil
-}
-
-func (p *Platform) Stop() error {
-	return nil
-}
-
-func (p *Platform) AfterScanHook(_ tokens.Token) error {
-	return nil
-}
-
-func (p *Platform) ReadersUpdateHook(_ map[string]*readers.Reader) error {
-	return nil
-}
-
-func (p *Platform) RootDirs(_ *config.Instance) []string {
-	return []string{}
-}
-
-func (p *Platform) ZipsAsDirs() bool {
-	return false
-}
-
-func (p *Platform) DataDir() string {
-	return filepath.Join(xdg.DataHome, config.AppName)
-}
-
-func (p *Platform) LogDir() string {
-	return filepath.Join(xdg.DataHome, config.AppName)
-}
-
-func (p *Platform) ConfigDir() string {
-	return filepath.Join(xdg.ConfigHome, config.AppName)
-}
-
-func (p *Platform) TempDir() string {
-	return filepath.Join(os.TempDir(), config.AppName)
-}
-
-func (p *Platform) NormalizePath(_ *config.Instance, path string) string {
-	return path
-}
-
-func LaunchMenu() error {
-	return nil
-}
-
-func (p *Platform) KillLauncher() error {
-	return nil
-}
-
-func (p *Platform) GetActiveLauncher() string {
-	// TODO: is this possible?
-	return ""
-}
-
-func (p *Platform) PlayFailSound(_ *config.Instance) {
-}
-
-func (p *Platform) PlaySuccessSound(_ *config.Instance) {
-}
-
-func (p *Platform) ActiveSystem() string {
-	return ""
-}
-
-func (p *Platform) ActiveGame() string {
-	// TODO: can this go?
-	return ""
-}
-
-func (p *Platform) ActiveGameName() string {
-	return ""
-}
-
-func (p *Platform) ActiveGamePath() string {
-	return ""
-}
-
-func (p *Platform) LaunchSystem(_ *config.Instance, _ string) error {
-	return nil
-}
-
-func (p *Platform) LaunchFile(cfg *config.Instance, path string) error {
-	launchers := utils.PathToLaunchers(cfg, p, path)
-	if len(launchers) == 0 {
-		return errors.New("no launcher found")
-	}
-	launcher := launchers[0]
-
-	if launcher.AllowListOnly && !cfg.IsLauncherFileAllowed(path) {
-		return errors.New("file not allowed: " + path)
-	}
-
-	log.Info().Msgf("launching file: %s", path)
-	return launcher.Launch(cfg, path)
-}
-
-func (p *Platform) KeyboardInput(_ string) error {
-	return nil
-}
-
-func (p *Platform) KeyboardPress(name string) error {
-	return nil
-}
-
-func (p *Platform) GamepadPress(name string) error {
-	return nil
-}
-
-func (p *Platform) ForwardCmd(_ platforms.CmdEnv) error {
-	return nil
-}
-
-func (p *Platform) LookupMapping(_ tokens.Token) (string, bool) {
-	return "", false
-}
-
-func (p *Platform) Launchers() []platforms.Launcher {
-	return []platforms.Launcher{
-		{
-			Id:       "Steam",
-			SystemId: gamesdb.SystemPC,
-			Schemes:  []string{"steam"},
-			Scanner: func(
-				cfg *config.Instance,
-				systemId string,
-				results []platforms.ScanResult,
-			) ([]platforms.ScanResult, error) {
-				root := "/home/deck/.steam/steam/steamapps"
-				appResults, err := utils.ScanSteamApps(root)
-				if err != nil {
-					return nil, err
-				}
-				return append(results, appResults...), nil
-			},
-			Launch: func(cfg *config.Instance, path string) error {
-				id := strings.TrimPrefix(path, "steam://")
-				id = strings.TrimPrefix(id, "rungameid/")
-				return exec.Command(
-					"steam",
-					"steam://rungameid/"+id,
-				).Start()
-			},
-		},
-		{
-			Id:            "Generic",
-			Extensions:    []string{".sh"},
-			AllowListOnly: true,
-			Launch: func(cfg *config.Instance, path string) error {
-				return exec.Command("bash", "-c", path).Start()
-			},
-		},
-	}
-}
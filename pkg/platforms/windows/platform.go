@@ -112,12 +112,6 @@ func (p *Platform) GetActiveLauncher() string {
 	return ""
 }
 
-func (p *Platform) PlayFailSound(cfg *config.Instance) {
-}
-
-func (p *Platform) PlaySuccessSound(cfg *config.Instance) {
-}
-
 func (p *Platform) ActiveSystem() string {
 	return ""
 }
@@ -395,19 +389,20 @@ func findLaunchBoxDir() (string, error) {
 	return "", fmt.Errorf("launchbox directory not found")
 }
 
-func (p *Platform) Launchers() []platforms.Launcher {
-	return []platforms.Launcher{
+func (p *Platform) Launchers(cfg *config.Instance) []platforms.Launcher {
+	launchers := []platforms.Launcher{
 		{
-			Id:       "Steam",
-			SystemId: gamesdb.SystemPC,
-			Schemes:  []string{"steam"},
+			Id:             "Steam",
+			SystemId:       gamesdb.SystemPC,
+			CommandOptions: true,
+			Schemes:        []string{"steam"},
 			Scanner: func(
 				cfg *config.Instance,
 				systemId string,
 				results []platforms.ScanResult,
 			) ([]platforms.ScanResult, error) {
 				// TODO: detect this path from registry
-				root := "C:\\Program Files (x86)\\Steam\\steamapps"
+				root := "C:\\Program Files (x86)\\Steam"
 				appResults, err := utils.ScanSteamApps(root)
 				if err != nil {
 					return nil, err
@@ -417,7 +412,10 @@ func (p *Platform) Launchers() []platforms.Launcher {
 			Launch: func(cfg *config.Instance, path string) error {
 				id := strings.TrimPrefix(path, "steam://")
 				id = strings.TrimPrefix(id, "rungameid/")
-				return exec.Command(
+				return platforms.LaunchCommand(
+					cfg,
+					"Steam",
+					gamesdb.SystemPC,
 					"cmd", "/c",
 					"start",
 					"steam://rungameid/"+id,
@@ -425,13 +423,17 @@ func (p *Platform) Launchers() []platforms.Launcher {
 			},
 		},
 		{
-			Id:       "Flashpoint",
-			SystemId: gamesdb.SystemPC,
-			Schemes:  []string{"flashpoint"},
+			Id:             "Flashpoint",
+			SystemId:       gamesdb.SystemPC,
+			CommandOptions: true,
+			Schemes:        []string{"flashpoint"},
 			Launch: func(cfg *config.Instance, path string) error {
 				id := strings.TrimPrefix(path, "flashpoint://")
 				id = strings.TrimPrefix(id, "run/")
-				return exec.Command(
+				return platforms.LaunchCommand(
+					cfg,
+					"Flashpoint",
+					gamesdb.SystemPC,
 					"cmd", "/c",
 					"start",
 					"flashpoint://run/"+id,
@@ -439,16 +441,18 @@ func (p *Platform) Launchers() []platforms.Launcher {
 			},
 		},
 		{
-			Id:            "Generic",
-			Extensions:    []string{".exe", ".bat", ".cmd", ".lnk", ".a3x", ".ahk"},
-			AllowListOnly: true,
+			Id:             "Generic",
+			Extensions:     []string{".exe", ".bat", ".cmd", ".lnk", ".a3x", ".ahk"},
+			AllowListOnly:  true,
+			CommandOptions: true,
 			Launch: func(cfg *config.Instance, path string) error {
-				return exec.Command("cmd", "/c", path).Start()
+				return platforms.LaunchCommand(cfg, "Generic", "", "cmd", "/c", path).Start()
 			},
 		},
 		{
-			Id:      "LaunchBox",
-			Schemes: []string{"launchbox"},
+			Id:             "LaunchBox",
+			CommandOptions: true,
+			Schemes:        []string{"launchbox"},
 			Scanner: func(
 				cfg *config.Instance,
 				systemId string,
@@ -518,8 +522,50 @@ func (p *Platform) Launchers() []platforms.Launcher {
 				}
 
 				id := strings.TrimPrefix(path, "launchbox://")
-				return exec.Command(cliLauncher, "launch_by_id", id).Start()
+				return platforms.LaunchCommand(cfg, "LaunchBox", "", cliLauncher, "launch_by_id", id).Start()
 			},
 		},
 	}
+
+	return append(platforms.CustomLaunchers(cfg, nil), launchers...)
+}
+
+// ShowNotice shows the notice as a notification from a temporary tray icon,
+// which is removed once the notice has been shown.
+func (p *Platform) ShowNotice(_ *config.Instance, n platforms.Notice) error {
+	quote := func(s string) string {
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	}
+
+	icon := "Info"
+	if n.Error {
+		icon = "Error"
+	}
+	ms := n.Duration.Milliseconds()
+
+	script := fmt.Sprintf(
+		"Add-Type -AssemblyName System.Windows.Forms; "+
+			"$n = New-Object System.Windows.Forms.NotifyIcon; "+
+			"$n.Icon = [System.Drawing.SystemIcons]::Information; "+
+			"$n.Visible = $true; "+
+			"$n.ShowBalloonTip(%d, %s, %s, '%s'); "+
+			"Start-Sleep -Milliseconds %d; "+
+			"$n.Dispose()",
+		ms,
+		quote(platforms.NoticeTitle),
+		quote(n.Text),
+		icon,
+		ms,
+	)
+
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	err := cmd.Start()
+	if err != nil {
+		return err
+	}
+	go func() {
+		_ = cmd.Wait()
+	}()
+
+	return nil
 }
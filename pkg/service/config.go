@@ -0,0 +1,147 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/state"
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+)
+
+// Editors often write a file in several steps or replace it entirely, so
+// reloads wait until events have stopped for this long.
+const configReloadDelay = 1 * time.Second
+
+func notifySettingsChanged(st *state.State, sections []string) {
+	st.Notifications <- models.Notification{
+		Method: models.NotificationSettingsChanged,
+		Params: models.SettingsChangedResponse{
+			Sections: sections,
+		},
+	}
+}
+
+func reloadConfig(cfg *config.Instance, st *state.State) {
+	log.Info().Msg("config file changed, reloading")
+
+	changed, err := cfg.Reload()
+	if err != nil {
+		log.Error().Err(err).Msg("error reloading config, keeping current values")
+		return
+	} else if len(changed) == 0 {
+		log.Debug().Msg("config unchanged")
+		return
+	}
+
+	log.Info().Msgf("config sections changed: %v", changed)
+
+	// reapply the log level, the rest is read from the config when used
+	cfg.SetDebugLogging(cfg.BaseValues().DebugLogging)
+
+	notifySettingsChanged(st, changed)
+}
+
+func reloadMappings(cfg *config.Instance, st *state.State, mapDir string) {
+	log.Info().Msg("mapping files changed, reloading")
+
+	err := cfg.LoadMappings(mapDir)
+	if err != nil {
+		log.Error().Err(err).Msg("error reloading mapping files")
+		return
+	}
+
+	notifySettingsChanged(st, []string{"mappings"})
+}
+
+// Watch the config file and mapping files for changes and reload them
+// automatically. Reader connections and the API port pick up the new values
+// from the config on their own.
+func watchConfig(
+	pl platforms.Platform,
+	cfg *config.Instance,
+	st *state.State,
+) (func() error, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	cfgPath := filepath.Clean(cfg.Path())
+	mapDir := filepath.Clean(filepath.Join(pl.DataDir(), platforms.MappingsDir))
+	profilesDir := filepath.Clean(cfg.ProfilesPath())
+
+	err = os.MkdirAll(profilesDir, 0755)
+	if err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+
+	// watch the parent directory so the file being replaced doesn't remove
+	// the watch on it
+	for _, dir := range []string{filepath.Dir(cfgPath), mapDir, profilesDir} {
+		err = watcher.Add(dir)
+		if err != nil {
+			_ = watcher.Close()
+			return nil, err
+		}
+	}
+
+	go func() {
+		var cfgReload <-chan time.Time
+		var mapReload <-chan time.Time
+
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				if event.Op == fsnotify.Chmod {
+					continue
+				}
+
+				name := filepath.Clean(event.Name)
+				if name == cfgPath {
+					cfgReload = time.After(configReloadDelay)
+				} else if filepath.Dir(name) == profilesDir && filepath.Ext(name) == ".toml" {
+					// profiles are applied when the config is loaded
+					cfgReload = time.After(configReloadDelay)
+				} else if filepath.Dir(name) == mapDir && filepath.Ext(name) == ".toml" {
+					mapReload = time.After(configReloadDelay)
+				}
+			case <-cfgReload:
+				cfgReload = nil
+				reloadConfig(cfg, st)
+			case <-mapReload:
+				mapReload = nil
+				reloadMappings(cfg, st, mapDir)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Error().Err(err).Msg("config watcher error")
+			}
+		}
+	}()
+
+	return watcher.Close, nil
+}
+
+// Notify clients when the active profile changes, whether it was switched
+// by ZapScript, the API or by editing the config file.
+func notifyProfileChanged(st *state.State, profile string) {
+	log.Info().Msgf("active profile changed: %s", profile)
+
+	st.Notifications <- models.Notification{
+		Method: models.NotificationProfilesChanged,
+		Params: models.ProfileChangedResponse{
+			Profile: profile,
+		},
+	}
+}
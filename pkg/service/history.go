@@ -0,0 +1,58 @@
+package service
+
+import (
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/database"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/state"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/tokens"
+	"github.com/rs/zerolog/log"
+)
+
+const historyPruneInterval = time.Hour
+
+// historyError copies a launch error to be stored in a history entry.
+func historyError(le *tokens.LaunchError) *database.HistoryError {
+	if le == nil {
+		return nil
+	}
+
+	return &database.HistoryError{
+		Code:        le.Code,
+		Message:     le.Message,
+		Command:     le.Command,
+		Stage:       le.Stage,
+		Candidates:  le.Candidates,
+		Suggestions: le.Suggestions,
+	}
+}
+
+// Delete history entries outside the configured retention limits, on
+// startup and then every hour until the service is stopped.
+func pruneHistory(cfg *config.Instance, st *state.State, db *database.Database) {
+	var lastPruned time.Time
+
+	for !st.ShouldStopService() {
+		if time.Since(lastPruned) >= historyPruneInterval {
+			lastPruned = time.Now()
+
+			hc := cfg.History()
+			if hc.RetentionDays > 0 || hc.MaxEntries > 0 {
+				var before time.Time
+				if hc.RetentionDays > 0 {
+					before = time.Now().AddDate(0, 0, -hc.RetentionDays)
+				}
+
+				deleted, err := db.PruneHistory(before, hc.MaxEntries)
+				if err != nil {
+					log.Error().Err(err).Msg("error pruning history")
+				} else if deleted > 0 {
+					log.Info().Msgf("pruned %d history entries", deleted)
+				}
+			}
+		}
+
+		time.Sleep(500 * time.Millisecond)
+	}
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/database"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/tokens"
+)
+
+func TestHistoryError(t *testing.T) {
+	if he := historyError(nil); he != nil {
+		t.Errorf("expected nil, got: %v", he)
+	}
+
+	le := tokens.AsLaunchError(errors.New("not found"), tokens.ErrCodeNoResults, tokens.StageResolve)
+	le.Command = "launch.search"
+	le.Suggestions = []string{"Sonic"}
+
+	want := &database.HistoryError{
+		Code:        tokens.ErrCodeNoResults,
+		Message:     "not found",
+		Command:     "launch.search",
+		Stage:       tokens.StageResolve,
+		Suggestions: []string{"Sonic"},
+	}
+	if got := historyError(le); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/database"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/playlists"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/playlists/saved"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/state"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/tokens"
+	"github.com/rs/zerolog/log"
+)
+
+// Run the post-exit hook of the launcher and system of the media which was
+// running when media stops.
+func runPostExitHooks(
+	pl platforms.Platform,
+	cfg *config.Instance,
+	st *state.State,
+	db *database.Database,
+	lsq chan<- *tokens.Token,
+	plq chan *playlists.Playlist,
+	ns <-chan models.Notification,
+) {
+	var launcherId, systemId string
+
+	for !st.ShouldStopService() {
+		select {
+		case n := <-ns:
+			switch n.Method {
+			case models.NotificationStarted:
+				launcherId = pl.GetActiveLauncher()
+				systemId = ""
+				if params, ok := n.Params.(models.MediaStartedParams); ok {
+					systemId = params.SystemId
+				}
+			case models.NotificationStopped:
+				if launcherId == "" && systemId == "" {
+					continue
+				}
+
+				opts := cfg.LookupLaunchOptions(launcherId, systemId)
+				launcherId, systemId = "", ""
+				if opts.PostExit == "" {
+					continue
+				}
+
+				log.Info().Msgf("running post-exit hook: %s", opts.PostExit)
+				t := tokens.Token{
+					Text:     opts.PostExit,
+					ScanTime: time.Now(),
+					Source:   tokens.SourceHook,
+				}
+				plsc := playlists.PlaylistController{
+					Active: st.GetActivePlaylist(),
+					Queue:  plq,
+					Store:  saved.Store{Db: db},
+				}
+
+				go func() {
+					err := launchToken(pl, cfg, t, db, lsq, plsc)
+					if err != nil {
+						log.Error().Err(err).Msg("error running post-exit hook")
+					}
+				}()
+			}
+		case <-time.After(500 * time.Millisecond):
+			continue
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/database"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/playlists"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/state"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/tokens"
+)
+
+// testPlatform only implements the active launcher, launchers, mappings and
+// keyboard presses, which are sent to keys. Any other method panics.
+type testPlatform struct {
+	platforms.Platform
+	activeLauncher string
+	launchers      []platforms.Launcher
+	keys           chan string
+}
+
+func (p *testPlatform) GetActiveLauncher() string {
+	return p.activeLauncher
+}
+
+func (p *testPlatform) Launchers(_ *config.Instance) []platforms.Launcher {
+	return p.launchers
+}
+
+func (p *testPlatform) LookupMapping(_ tokens.Token) (string, bool) {
+	return "", false
+}
+
+func (p *testPlatform) KeyboardPress(name string) error {
+	p.keys <- name
+	return nil
+}
+
+func testConfig(t *testing.T, defaults config.Values) *config.Instance {
+	cfg, err := config.NewConfig(t.TempDir(), defaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func openTestDb(t *testing.T) *database.Database {
+	db, err := database.OpenFile(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+func TestRunPostExitHooks(t *testing.T) {
+	defaults := config.BaseDefaults
+	defaults.Launchers.Default = []config.LaunchersDefault{{
+		Launcher: "Test",
+		LaunchOptions: config.LaunchOptions{
+			PostExit: "**input.keyboard:a",
+		},
+	}}
+	cfg := testConfig(t, defaults)
+
+	pl := &testPlatform{
+		activeLauncher: "Test",
+		keys:           make(chan string, 10),
+	}
+	st, _ := state.NewState(pl)
+	ns := make(chan models.Notification)
+	lsq := make(chan *tokens.Token, 1)
+	plq := make(chan *playlists.Playlist, 1)
+
+	done := make(chan struct{})
+	go func() {
+		runPostExitHooks(pl, cfg, st, openTestDb(t), lsq, plq, ns)
+		close(done)
+	}()
+	defer func() {
+		st.StopService()
+		<-done
+	}()
+
+	stopped := models.Notification{Method: models.NotificationStopped}
+	started := models.Notification{
+		Method: models.NotificationStarted,
+		Params: models.MediaStartedParams{SystemId: "Genesis"},
+	}
+
+	// media which started before the runner has no known launcher
+	ns <- stopped
+	ns <- started
+	ns <- stopped
+
+	select {
+	case key := <-pl.keys:
+		if key != "a" {
+			t.Errorf("unexpected key press: %s", key)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("post-exit hook not run")
+	}
+
+	// the hook only runs once for each media started
+	ns <- stopped
+
+	select {
+	case key := <-pl.keys:
+		t.Errorf("post-exit hook run again: %s", key)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func TestRunPostExitHooksOtherLauncher(t *testing.T) {
+	defaults := config.BaseDefaults
+	defaults.Launchers.Default = []config.LaunchersDefault{{
+		Launcher: "Test",
+		LaunchOptions: config.LaunchOptions{
+			PostExit: "**input.keyboard:a",
+		},
+	}}
+	cfg := testConfig(t, defaults)
+
+	pl := &testPlatform{
+		activeLauncher: "Other",
+		keys:           make(chan string, 10),
+	}
+	st, _ := state.NewState(pl)
+	ns := make(chan models.Notification)
+
+	done := make(chan struct{})
+	go func() {
+		runPostExitHooks(pl, cfg, st, openTestDb(t), nil, nil, ns)
+		close(done)
+	}()
+	defer func() {
+		st.StopService()
+		<-done
+	}()
+
+	ns <- models.Notification{Method: models.NotificationStarted}
+	ns <- models.Notification{Method: models.NotificationStopped}
+
+	select {
+	case key := <-pl.keys:
+		t.Errorf("post-exit hook of another launcher run: %s", key)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
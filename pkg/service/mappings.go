@@ -133,7 +133,7 @@ func getMapping(cfg *config.Instance, db *database.Database, pl platforms.Platfo
 	// reported and debugged by the user if there's issues
 
 	// check db mappings
-	ms, err := db.GetEnabledMappings()
+	ms, err := db.GetEnabledMappings(cfg.Profile())
 	if err != nil {
 		log.Error().Err(err).Msgf("error getting db mappings")
 	}
@@ -0,0 +1,273 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/state"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/tokens"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	DefaultTopicPrefix     = "zaparoo"
+	DefaultDiscoveryPrefix = "homeassistant"
+	TopicStatus            = "status"
+	TopicRun               = "run"
+	TopicMedia             = "media"
+	TopicToken             = "token"
+	StatusOnline           = "online"
+	StatusOffline          = "offline"
+	publishTimeout         = 5 * time.Second
+)
+
+// DevicePrefix returns the base topic for all messages sent and received by
+// this device, e.g. "zaparoo/<device id>".
+func DevicePrefix(cfg *config.Instance) string {
+	prefix := strings.TrimSuffix(cfg.Mqtt().TopicPrefix, "/")
+	if prefix == "" {
+		prefix = DefaultTopicPrefix
+	}
+	return prefix + "/" + cfg.DeviceId()
+}
+
+// NotificationTopic maps an API notification method to a topic under the
+// device prefix, e.g. "tokens.added" becomes "<prefix>/tokens/added".
+func NotificationTopic(prefix string, method string) string {
+	return prefix + "/" + strings.ReplaceAll(method, ".", "/")
+}
+
+type discoveryDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model"`
+	SwVersion    string   `json:"sw_version"`
+}
+
+type discoveryConfig struct {
+	Name                string          `json:"name"`
+	UniqueId            string          `json:"unique_id"`
+	StateTopic          string          `json:"state_topic,omitempty"`
+	CommandTopic        string          `json:"command_topic,omitempty"`
+	ValueTemplate       string          `json:"value_template,omitempty"`
+	JsonAttributesTopic string          `json:"json_attributes_topic,omitempty"`
+	AvailabilityTopic   string          `json:"availability_topic"`
+	Icon                string          `json:"icon,omitempty"`
+	Device              discoveryDevice `json:"device"`
+}
+
+// DiscoveryPayloads returns all Home Assistant discovery config messages for
+// this device, mapped by the topic they should be published to.
+func DiscoveryPayloads(pl platforms.Platform, cfg *config.Instance) (map[string][]byte, error) {
+	discPrefix := strings.TrimSuffix(cfg.Mqtt().DiscoveryPrefix, "/")
+	if discPrefix == "" {
+		discPrefix = DefaultDiscoveryPrefix
+	}
+
+	prefix := DevicePrefix(cfg)
+	nodeId := config.AppName + "_" + strings.ReplaceAll(cfg.DeviceId(), "-", "")
+	device := discoveryDevice{
+		Identifiers:  []string{nodeId},
+		Name:         "Zaparoo Core",
+		Manufacturer: "Zaparoo",
+		Model:        pl.Id(),
+		SwVersion:    config.AppVersion,
+	}
+
+	entities := map[string]discoveryConfig{
+		discPrefix + "/sensor/" + nodeId + "/media/config": {
+			Name:                "Active media",
+			UniqueId:            nodeId + "_media",
+			StateTopic:          prefix + "/" + TopicMedia,
+			ValueTemplate:       "{{ value_json.mediaName }}",
+			JsonAttributesTopic: prefix + "/" + TopicMedia,
+			Icon:                "mdi:gamepad-variant",
+		},
+		discPrefix + "/sensor/" + nodeId + "/token/config": {
+			Name:                "Active token",
+			UniqueId:            nodeId + "_token",
+			StateTopic:          prefix + "/" + TopicToken,
+			ValueTemplate:       "{{ value_json.text }}",
+			JsonAttributesTopic: prefix + "/" + TopicToken,
+			Icon:                "mdi:nfc-variant",
+		},
+		discPrefix + "/text/" + nodeId + "/run/config": {
+			Name:         "Run ZapScript",
+			UniqueId:     nodeId + "_run",
+			CommandTopic: prefix + "/" + TopicRun,
+			Icon:         "mdi:script-text-play",
+		},
+	}
+
+	payloads := make(map[string][]byte)
+	for topic, entity := range entities {
+		entity.AvailabilityTopic = prefix + "/" + TopicStatus
+		entity.Device = device
+		data, err := json.Marshal(entity)
+		if err != nil {
+			return nil, err
+		}
+		payloads[topic] = data
+	}
+
+	return payloads, nil
+}
+
+type Bridge struct {
+	pl     platforms.Platform
+	cfg    *config.Instance
+	st     *state.State
+	itq    chan<- tokens.Token
+	prefix string
+	client paho.Client
+}
+
+func (b *Bridge) publish(topic string, retained bool, payload []byte) {
+	t := b.client.Publish(topic, 1, retained, payload)
+	go func() {
+		if !t.WaitTimeout(publishTimeout) {
+			log.Warn().Str("topic", topic).Msg("mqtt publish timed out")
+		} else if t.Error() != nil {
+			log.Error().Err(t.Error()).Str("topic", topic).Msg("mqtt publish failed")
+		}
+	}()
+}
+
+// Run ZapScript received on the command topic, the same as an API run request.
+func (b *Bridge) handleRun(_ paho.Client, msg paho.Message) {
+	text := strings.TrimSpace(string(msg.Payload()))
+	if text == "" {
+		log.Warn().Msg("received empty mqtt run message")
+		return
+	}
+
+	log.Info().Msgf("received mqtt run: %s", text)
+
+	t := tokens.Token{
+		Text:     norm.NFC.String(text),
+		ScanTime: time.Now(),
+		Remote:   true,
+		Source:   tokens.SourceMqtt,
+	}
+
+	b.st.SetActiveCard(t)
+	b.itq <- t
+}
+
+func (b *Bridge) onConnect(c paho.Client) {
+	log.Info().Msg("connected to mqtt broker")
+
+	b.publish(b.prefix+"/"+TopicStatus, true, []byte(StatusOnline))
+
+	runTopic := b.prefix + "/" + TopicRun
+	t := c.Subscribe(runTopic, 1, b.handleRun)
+	if t.WaitTimeout(publishTimeout) && t.Error() != nil {
+		log.Error().Err(t.Error()).Msgf("error subscribing to mqtt topic: %s", runTopic)
+	}
+
+	if b.cfg.Mqtt().Discovery {
+		payloads, err := DiscoveryPayloads(b.pl, b.cfg)
+		if err != nil {
+			log.Error().Err(err).Msg("error creating mqtt discovery payloads")
+			return
+		}
+		for topic, payload := range payloads {
+			b.publish(topic, true, payload)
+		}
+	}
+}
+
+func (b *Bridge) handleNotification(n models.Notification) {
+	payload := []byte("{}")
+	if n.Params != nil {
+		data, err := json.Marshal(n.Params)
+		if err != nil {
+			log.Error().Err(err).Msg("error marshalling mqtt notification")
+			return
+		}
+		payload = data
+	}
+
+	b.publish(NotificationTopic(b.prefix, n.Method), false, payload)
+
+	// retained state topics for consumers which can't track events
+	switch n.Method {
+	case models.NotificationStarted, models.NotificationStopped:
+		b.publish(b.prefix+"/"+TopicMedia, true, payload)
+	case models.NotificationTokensAdded, models.NotificationTokensRemoved:
+		b.publish(b.prefix+"/"+TopicToken, true, payload)
+	}
+}
+
+// Start connects to the configured MQTT broker and starts publishing all
+// service notifications. Returns a function to disconnect the bridge.
+func Start(
+	pl platforms.Platform,
+	cfg *config.Instance,
+	st *state.State,
+	itq chan<- tokens.Token,
+	ns <-chan models.Notification,
+) (func() error, error) {
+	mc := cfg.Mqtt()
+	if mc.Broker == "" {
+		return nil, errors.New("mqtt broker not set")
+	}
+
+	b := &Bridge{
+		pl:     pl,
+		cfg:    cfg,
+		st:     st,
+		itq:    itq,
+		prefix: DevicePrefix(cfg),
+	}
+
+	opts := paho.NewClientOptions()
+	opts.AddBroker(mc.Broker)
+	opts.SetClientID(config.AppName + "-" + cfg.DeviceId())
+	opts.SetUsername(mc.Username)
+	opts.SetPassword(mc.Password)
+	opts.SetWill(b.prefix+"/"+TopicStatus, StatusOffline, 1, true)
+	opts.SetAutoReconnect(true)
+	opts.SetConnectRetry(true)
+	opts.SetOnConnectHandler(b.onConnect)
+	opts.SetConnectionLostHandler(func(_ paho.Client, err error) {
+		log.Warn().Err(err).Msg("lost connection to mqtt broker")
+	})
+
+	b.client = paho.NewClient(opts)
+	log.Info().Msgf("connecting to mqtt broker: %s", mc.Broker)
+	// connection is retried in the background until it succeeds
+	b.client.Connect()
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case n := <-ns:
+				if b.client.IsConnectionOpen() {
+					b.handleNotification(n)
+				}
+			}
+		}
+	}()
+
+	return func() error {
+		close(done)
+		if b.client.IsConnectionOpen() {
+			t := b.client.Publish(b.prefix+"/"+TopicStatus, 1, true, StatusOffline)
+			t.WaitTimeout(publishTimeout)
+		}
+		b.client.Disconnect(250)
+		return nil
+	}, nil
+}
@@ -0,0 +1,162 @@
+package mqtt
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/state"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/tokens"
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+type doneToken struct{}
+
+func (doneToken) Wait() bool                     { return true }
+func (doneToken) WaitTimeout(time.Duration) bool { return true }
+func (doneToken) Error() error                   { return nil }
+func (doneToken) Done() <-chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}
+
+type published struct {
+	topic    string
+	retained bool
+	payload  string
+}
+
+// testClient records published messages. Any other client method panics.
+type testClient struct {
+	paho.Client
+	mu        sync.Mutex
+	published []published
+}
+
+func (c *testClient) Publish(topic string, _ byte, retained bool, payload any) paho.Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var data string
+	switch p := payload.(type) {
+	case []byte:
+		data = string(p)
+	case string:
+		data = p
+	}
+
+	c.published = append(c.published, published{topic, retained, data})
+	return doneToken{}
+}
+
+type testMessage struct {
+	paho.Message
+	payload []byte
+}
+
+func (m testMessage) Payload() []byte {
+	return m.payload
+}
+
+func newConfig(t *testing.T, prefix string) *config.Instance {
+	defaults := config.BaseDefaults
+	defaults.Service.DeviceId = "device"
+	defaults.Mqtt.TopicPrefix = prefix
+
+	cfg, err := config.NewConfig(t.TempDir(), defaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cfg
+}
+
+func TestDevicePrefix(t *testing.T) {
+	if got := DevicePrefix(newConfig(t, "")); got != "zaparoo/device" {
+		t.Errorf("default prefix: %s", got)
+	}
+
+	if got := DevicePrefix(newConfig(t, "home/games/")); got != "home/games/device" {
+		t.Errorf("custom prefix: %s", got)
+	}
+}
+
+func TestNotificationTopic(t *testing.T) {
+	got := NotificationTopic("zaparoo/device", models.NotificationTokensAdded)
+	if got != "zaparoo/device/tokens/added" {
+		t.Errorf("unexpected topic: %s", got)
+	}
+}
+
+func TestHandleNotification(t *testing.T) {
+	c := &testClient{}
+	b := &Bridge{prefix: "zaparoo/device", client: c}
+
+	b.handleNotification(models.Notification{
+		Method: models.NotificationStarted,
+		Params: models.MediaStartedParams{SystemId: "NES"},
+	})
+	b.handleNotification(models.Notification{Method: models.NotificationReadersConnected})
+
+	want := []struct {
+		topic    string
+		retained bool
+	}{
+		{"zaparoo/device/media/started", false},
+		{"zaparoo/device/media", true},
+		{"zaparoo/device/readers/added", false},
+	}
+
+	if len(c.published) != len(want) {
+		t.Fatalf("published %d messages, want %d: %+v", len(c.published), len(want), c.published)
+	}
+
+	for i, w := range want {
+		p := c.published[i]
+		if p.topic != w.topic || p.retained != w.retained {
+			t.Errorf("message %d: got %s (retained %v), want %s (retained %v)",
+				i, p.topic, p.retained, w.topic, w.retained)
+		}
+	}
+
+	if c.published[0].payload != c.published[1].payload {
+		t.Error("retained media state doesn't match notification")
+	}
+
+	if c.published[2].payload != "{}" {
+		t.Errorf("empty params payload: %s", c.published[2].payload)
+	}
+}
+
+func TestHandleRun(t *testing.T) {
+	st, ns := state.NewState(nil)
+	go func() {
+		for range ns {
+		}
+	}()
+
+	itq := make(chan tokens.Token, 1)
+	b := &Bridge{st: st, itq: itq}
+
+	b.handleRun(nil, testMessage{payload: []byte("  **launch.random:snes\n")})
+
+	select {
+	case tok := <-itq:
+		if tok.Text != "**launch.random:snes" {
+			t.Errorf("unexpected token text: %q", tok.Text)
+		}
+		if !tok.Remote || tok.Source != tokens.SourceMqtt {
+			t.Errorf("token not marked as from mqtt: %+v", tok)
+		}
+	default:
+		t.Fatal("token not queued")
+	}
+
+	b.handleRun(nil, testMessage{payload: []byte("  ")})
+	if len(itq) != 0 {
+		t.Error("empty message queued a token")
+	}
+}
@@ -0,0 +1,109 @@
+package notices
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/state"
+	"github.com/rs/zerolog/log"
+)
+
+// capitalize uppercases the first letter of a message, errors usually start
+// lowercase.
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// FromNotification returns the notice shown for a service notification.
+// Returns false if the notification doesn't have one.
+func FromNotification(n models.Notification) (platforms.Notice, bool) {
+	switch n.Method {
+	case models.NotificationStarted:
+		params, ok := n.Params.(models.MediaStartedParams)
+		if !ok {
+			return platforms.Notice{}, false
+		}
+
+		name := params.MediaName
+		if name == "" && params.MediaPath != "" {
+			name = filepath.Base(params.MediaPath)
+			name = strings.TrimSuffix(name, filepath.Ext(name))
+		}
+		if name == "" {
+			name = params.SystemName
+		}
+		if name == "" {
+			return platforms.Notice{}, false
+		}
+
+		return platforms.Notice{Text: "Launching " + name}, true
+	case models.NotificationTokensFailed:
+		params, ok := n.Params.(models.TokenFailedParams)
+		if !ok || params.Error.Message == "" {
+			return platforms.Notice{}, false
+		}
+
+		text := capitalize(params.Error.Message)
+		if len(params.Error.Suggestions) > 0 {
+			// suggestions are <system>/<name>, only the name is shown
+			s := params.Error.Suggestions[0]
+			if i := strings.Index(s, "/"); i != -1 {
+				s = s[i+1:]
+			}
+			text += "\nDid you mean " + s + "?"
+		}
+
+		return platforms.Notice{Text: text, Error: true}, true
+	case models.NotificationReadersConnected:
+		return platforms.Notice{Text: "Reader connected"}, true
+	case models.NotificationReadersDisconnected:
+		return platforms.Notice{Text: "Reader disconnected", Error: true}, true
+	default:
+		return platforms.Notice{}, false
+	}
+}
+
+// Start shows notices for notifications from the service, if they're
+// enabled, until the service is stopped.
+func Start(
+	pl platforms.Platform,
+	cfg *config.Instance,
+	st *state.State,
+	ns <-chan models.Notification,
+) {
+	for !st.ShouldStopService() {
+		select {
+		case n := <-ns:
+			if !cfg.NoticesEnabled() {
+				continue
+			}
+
+			notice, ok := FromNotification(n)
+			if !ok {
+				continue
+			}
+			notice.Duration = cfg.NoticeDuration()
+
+			log.Debug().Msgf("showing notice: %s", notice.Text)
+			err := pl.ShowNotice(cfg, notice)
+			if errors.Is(err, platforms.ErrNoticeUnsupported) {
+				log.Debug().Msgf("notice not shown: %s", notice.Text)
+			} else if err != nil {
+				log.Warn().Err(err).Msg("error showing notice")
+			}
+		case <-time.After(500 * time.Millisecond):
+			continue
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package notices
+
+import (
+	"testing"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+)
+
+func TestFromNotification(t *testing.T) {
+	tests := []struct {
+		n    models.Notification
+		want platforms.Notice
+		ok   bool
+	}{
+		{
+			n: models.Notification{
+				Method: models.NotificationStarted,
+				Params: models.MediaStartedParams{MediaName: "Super Metroid"},
+			},
+			want: platforms.Notice{Text: "Launching Super Metroid"},
+			ok:   true,
+		},
+		{
+			n: models.Notification{
+				Method: models.NotificationStarted,
+				Params: models.MediaStartedParams{MediaPath: "/roms/snes/Zelda.sfc"},
+			},
+			want: platforms.Notice{Text: "Launching Zelda"},
+			ok:   true,
+		},
+		{
+			n: models.Notification{
+				Method: models.NotificationTokensFailed,
+				Params: models.TokenFailedParams{
+					Text: "**launch.search:snes/metroid",
+					Error: models.LaunchError{
+						Code:        "no_results",
+						Message:     "no results found for: metroid",
+						Suggestions: []string{"SNES/Super Metroid (USA)"},
+					},
+				},
+			},
+			want: platforms.Notice{
+				Text:  "No results found for: metroid\nDid you mean Super Metroid (USA)?",
+				Error: true,
+			},
+			ok: true,
+		},
+		{
+			n: models.Notification{
+				Method: models.NotificationReadersDisconnected,
+				Params: "pn532_uart:/dev/ttyUSB0",
+			},
+			want: platforms.Notice{Text: "Reader disconnected", Error: true},
+			ok:   true,
+		},
+		{
+			n:  models.Notification{Method: models.NotificationStopped},
+			ok: false,
+		},
+	}
+
+	for _, tt := range tests {
+		got, ok := FromNotification(tt.n)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("%s: got %+v %v, want %+v %v", tt.n.Method, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package notifications
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/state"
+	"github.com/rs/zerolog/log"
+)
+
+// Size of each subscriber's queue before notifications start being dropped.
+const subscriberBuffer = 100
+
+// Size of each internal subscriber's queue before the broker waits for it.
+// Internal subscribers can send notifications themselves, so it's large
+// enough they're never waited on in practice.
+const internalBuffer = 1000
+
+// How often a send to a full internal subscriber checks if the service is
+// stopping.
+const stopCheckInterval = 500 * time.Millisecond
+
+type subscriber struct {
+	ch       chan models.Notification
+	internal bool
+}
+
+// Broker fans out the single notifications channel owned by the service
+// state to any number of subscribers (API clients, MQTT, etc.).
+type Broker struct {
+	mu   sync.RWMutex
+	subs []subscriber
+}
+
+func NewBroker() *Broker {
+	return &Broker{}
+}
+
+func (b *Broker) subscribe(internal bool) <-chan models.Notification {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	size := subscriberBuffer
+	if internal {
+		size = internalBuffer
+	}
+	ch := make(chan models.Notification, size)
+	b.subs = append(b.subs, subscriber{ch: ch, internal: internal})
+	return ch
+}
+
+// Subscribe returns a new channel which will receive a copy of every
+// notification sent through the broker. Slow subscribers will miss
+// notifications instead of blocking the others.
+func (b *Broker) Subscribe() <-chan models.Notification {
+	return b.subscribe(false)
+}
+
+// SubscribeInternal returns a new channel which will receive every
+// notification sent through the broker, for parts of the service which
+// depend on seeing them all, like playlist auto-advance. The broker waits
+// for a full internal subscriber instead of dropping notifications, so it
+// must always be read from until the service stops.
+func (b *Broker) SubscribeInternal() <-chan models.Notification {
+	return b.subscribe(true)
+}
+
+// send forwards a notification to all subscribers. Sends to full internal
+// subscribers are only given up on once stopping returns true.
+func (b *Broker) send(n models.Notification, stopping func() bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, sub := range b.subs {
+		select {
+		case sub.ch <- n:
+			continue
+		default:
+		}
+
+		if !sub.internal {
+			log.Warn().Str("method", n.Method).Msg("notification subscriber full, dropping")
+			continue
+		}
+
+		log.Debug().Str("method", n.Method).Msg("internal notification subscriber full, waiting")
+	wait:
+		for {
+			select {
+			case sub.ch <- n:
+				break wait
+			case <-time.After(stopCheckInterval):
+				if stopping() {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Run consumes notifications from the service and forwards them to all
+// subscribers until the service is stopped. Blocks until then.
+func (b *Broker) Run(st *state.State, ns <-chan models.Notification) {
+	for !st.ShouldStopService() {
+		select {
+		case n := <-ns:
+			b.send(n, st.ShouldStopService)
+		case <-time.After(500 * time.Millisecond):
+			continue
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package notifications
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/state"
+)
+
+func notStopping() bool {
+	return false
+}
+
+func TestBrokerFansOut(t *testing.T) {
+	b := NewBroker()
+	a := b.Subscribe()
+	c := b.SubscribeInternal()
+
+	b.send(models.Notification{Method: models.NotificationStarted}, notStopping)
+	b.send(models.Notification{Method: models.NotificationStopped}, notStopping)
+
+	for _, ch := range []<-chan models.Notification{a, c} {
+		for _, want := range []string{models.NotificationStarted, models.NotificationStopped} {
+			select {
+			case n := <-ch:
+				if n.Method != want {
+					t.Errorf("got %s, want %s", n.Method, want)
+				}
+			default:
+				t.Fatalf("missing %s notification", want)
+			}
+		}
+	}
+}
+
+func TestBrokerDropsForFullSubscriber(t *testing.T) {
+	b := NewBroker()
+	ch := b.Subscribe()
+
+	for i := 0; i < subscriberBuffer+10; i++ {
+		b.send(models.Notification{Method: models.NotificationStopped}, notStopping)
+	}
+
+	if len(ch) != subscriberBuffer {
+		t.Errorf("subscriber has %d notifications, want %d", len(ch), subscriberBuffer)
+	}
+}
+
+func TestBrokerWaitsForInternalSubscriber(t *testing.T) {
+	b := NewBroker()
+	ch := b.SubscribeInternal()
+	total := internalBuffer + 10
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < total; i++ {
+			b.send(models.Notification{Method: models.NotificationStopped}, notStopping)
+		}
+		close(done)
+	}()
+
+	received := 0
+	timeout := time.After(10 * time.Second)
+	for received < total {
+		select {
+		case <-ch:
+			received++
+		case <-timeout:
+			t.Fatalf("received %d of %d notifications", received, total)
+		}
+	}
+
+	<-done
+}
+
+func TestBrokerStopsWaiting(t *testing.T) {
+	b := NewBroker()
+	_ = b.SubscribeInternal()
+
+	for i := 0; i < internalBuffer; i++ {
+		b.send(models.Notification{Method: models.NotificationStopped}, notStopping)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		b.send(models.Notification{Method: models.NotificationStopped}, func() bool {
+			return true
+		})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("send to full internal subscriber didn't stop")
+	}
+}
+
+func TestBrokerRun(t *testing.T) {
+	st, ns := state.NewState(nil)
+	b := NewBroker()
+	ch := b.Subscribe()
+
+	go b.Run(st, ns)
+	st.Notifications <- models.Notification{Method: models.NotificationTokensAdded}
+
+	select {
+	case n := <-ch:
+		if n.Method != models.NotificationTokensAdded {
+			t.Errorf("got %s", n.Method)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("notification not forwarded")
+	}
+
+	st.StopService()
+}
@@ -0,0 +1,217 @@
+package playlists
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// JSON playlist file format.
+type jsonPlaylist struct {
+	Name          string  `json:"name"`
+	Shuffle       bool    `json:"shuffle"`
+	Repeat        string  `json:"repeat"`
+	Advance       float64 `json:"advance"`
+	AdvanceOnStop bool    `json:"advanceOnStop"`
+	Items         []struct {
+		Name      string `json:"name"`
+		ZapScript string `json:"zapscript"`
+	} `json:"items"`
+}
+
+func nameFromPath(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// Every file with an extension in a directory, in name order.
+func loadDir(path string) ([]PlaylistItem, error) {
+	files, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]PlaylistItem, 0)
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) == "" {
+			continue
+		}
+
+		items = append(items, PlaylistItem{
+			Name:      nameFromPath(file.Name()),
+			ZapScript: filepath.Join(path, file.Name()),
+		})
+	}
+
+	return items, nil
+}
+
+func isUri(s string) bool {
+	return strings.Contains(s, "://")
+}
+
+// Extended M3U, relative paths are resolved from the playlist's folder and
+// #EXTINF titles are used as item names.
+func loadM3u(path string) ([]PlaylistItem, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+
+	dir := filepath.Dir(path)
+	items := make([]PlaylistItem, 0)
+	name := ""
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#EXTINF:") {
+			if i := strings.Index(line, ","); i >= 0 {
+				name = strings.TrimSpace(line[i+1:])
+			}
+			continue
+		} else if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		entry := line
+		if !isUri(entry) && !filepath.IsAbs(entry) {
+			entry = filepath.Join(dir, filepath.FromSlash(entry))
+		}
+
+		if name == "" {
+			name = nameFromPath(entry)
+		}
+
+		items = append(items, PlaylistItem{
+			Name:      name,
+			ZapScript: entry,
+		})
+		name = ""
+	}
+
+	return items, scanner.Err()
+}
+
+// One ZapScript line per item, lines starting with # are ignored.
+func loadText(path string) ([]PlaylistItem, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+
+	items := make([]PlaylistItem, 0)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		items = append(items, PlaylistItem{
+			ZapScript: line,
+		})
+	}
+
+	return items, scanner.Err()
+}
+
+func loadJson(path string) (jsonPlaylist, []PlaylistItem, error) {
+	var jp jsonPlaylist
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return jp, nil, err
+	}
+
+	err = json.Unmarshal(data, &jp)
+	if err != nil {
+		return jp, nil, fmt.Errorf("invalid json playlist: %w", err)
+	}
+
+	switch jp.Repeat {
+	case "", RepeatAll, RepeatOne, RepeatNone:
+	default:
+		return jp, nil, fmt.Errorf("invalid repeat mode: %s", jp.Repeat)
+	}
+
+	items := make([]PlaylistItem, 0, len(jp.Items))
+	for i, item := range jp.Items {
+		if strings.TrimSpace(item.ZapScript) == "" {
+			return jp, nil, fmt.Errorf("playlist item %d has no zapscript", i+1)
+		}
+
+		items = append(items, PlaylistItem{
+			Name:      item.Name,
+			ZapScript: item.ZapScript,
+		})
+	}
+
+	return jp, items, nil
+}
+
+// Load reads a playlist from a directory of media files, an M3U file, a
+// JSON playlist file or a text file of ZapScript lines.
+func Load(path string) (*Playlist, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	name := nameFromPath(path)
+	var items []PlaylistItem
+	var opts Options
+
+	if info.IsDir() {
+		items, err = loadDir(path)
+	} else {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".m3u", ".m3u8":
+			items, err = loadM3u(path)
+		case ".txt":
+			items, err = loadText(path)
+		case ".json":
+			var jp jsonPlaylist
+			jp, items, err = loadJson(path)
+			if jp.Name != "" {
+				name = jp.Name
+			}
+			opts = Options{
+				Shuffle:       jp.Shuffle,
+				Repeat:        jp.Repeat,
+				Advance:       time.Duration(jp.Advance * float64(time.Minute)),
+				AdvanceOnStop: jp.AdvanceOnStop,
+			}
+		default:
+			return nil, fmt.Errorf("unsupported playlist file: %s", path)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, errors.New("no media found in playlist: " + path)
+	}
+
+	pls := NewPlaylist(name, items)
+	pls.SetOptions(opts)
+
+	return pls, nil
+}
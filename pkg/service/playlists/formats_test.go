@@ -0,0 +1,94 @@
+package playlists
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"games.m3u": "#EXTM3U\n" +
+			"#EXTINF:-1,Sonic the Hedgehog\n" +
+			"Genesis/sonic.md\n" +
+			"\n" +
+			"/media/fat/games/SNES/mario.sfc\n" +
+			"steam://rungameid/123\n",
+		"list.txt": "# comment\n" +
+			"**launch.search:Genesis/sonic*\n" +
+			"\n" +
+			"**launch.system:snes\n",
+		"named.json": `{"name": "Party", "items": [` +
+			`{"name": "Mario", "zapscript": "**launch.search:SNES/mario*"},` +
+			`{"zapscript": "SNES/zelda.sfc"}]}`,
+		"empty.json": `{"items": [{"name": "Broken"}]}`,
+		"other.xml":  "<playlist/>",
+	}
+	for name, data := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := map[string]struct {
+		path    string
+		name    string
+		items   []PlaylistItem
+		wantErr bool
+	}{
+		"m3u": {
+			path: "games.m3u",
+			name: "games",
+			items: []PlaylistItem{
+				{Name: "Sonic the Hedgehog", ZapScript: filepath.Join(dir, "Genesis", "sonic.md")},
+				{Name: "mario", ZapScript: "/media/fat/games/SNES/mario.sfc"},
+				{Name: "123", ZapScript: "steam://rungameid/123"},
+			},
+		},
+		"text": {
+			path: "list.txt",
+			name: "list",
+			items: []PlaylistItem{
+				{ZapScript: "**launch.search:Genesis/sonic*"},
+				{ZapScript: "**launch.system:snes"},
+			},
+		},
+		"json": {
+			path: "named.json",
+			name: "Party",
+			items: []PlaylistItem{
+				{Name: "Mario", ZapScript: "**launch.search:SNES/mario*"},
+				{ZapScript: "SNES/zelda.sfc"},
+			},
+		},
+		"json missing zapscript": {path: "empty.json", wantErr: true},
+		"unsupported":            {path: "other.xml", wantErr: true},
+		"missing":                {path: "missing.m3u", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			pls, err := Load(filepath.Join(dir, tc.path))
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", pls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if pls.Name != tc.name {
+				t.Errorf("name: got %q, want %q", pls.Name, tc.name)
+			}
+			if !reflect.DeepEqual(pls.Items, tc.items) {
+				t.Errorf("items: got %+v, want %+v", pls.Items, tc.items)
+			}
+		})
+	}
+}
@@ -1,44 +1,211 @@
 package playlists
 
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	RepeatAll  = "all"
+	RepeatOne  = "one"
+	RepeatNone = "none"
+)
+
+type PlaylistItem struct {
+	// Display name of the item, may be empty.
+	Name string
+	// ZapScript run when the item is played. Plain paths are launched as
+	// media like any other token.
+	ZapScript string
+}
+
+type Options struct {
+	Shuffle bool
+	// One of the Repeat constants, defaults to RepeatAll.
+	Repeat string
+	// Move to the next item after this long, 0 to disable.
+	Advance time.Duration
+	// Move to the next item when the platform reports media has stopped.
+	AdvanceOnStop bool
+}
+
+// ParseOptions reads playlist options from ZapScript named arguments,
+// starting from the given defaults:
+//
+//	shuffle=yes, repeat=all|one|none, advance=<minutes>, advance_on_stop=yes
+func ParseOptions(args map[string]string, defaults Options) (Options, error) {
+	o := defaults
+
+	parseBool := func(s string) bool {
+		s = strings.ToLower(s)
+		return s == "true" || s == "yes" || s == "1"
+	}
+
+	if v, ok := args["shuffle"]; ok {
+		o.Shuffle = parseBool(v)
+	}
+
+	if v, ok := args["repeat"]; ok {
+		v = strings.ToLower(v)
+		if v != RepeatAll && v != RepeatOne && v != RepeatNone {
+			return o, fmt.Errorf("invalid repeat mode: %s", v)
+		}
+		o.Repeat = v
+	}
+
+	if v, ok := args["advance"]; ok {
+		mins, err := strconv.ParseFloat(v, 64)
+		if err != nil || mins < 0 {
+			return o, fmt.Errorf("invalid advance minutes: %s", v)
+		}
+		o.Advance = time.Duration(mins * float64(time.Minute))
+	}
+
+	if v, ok := args["advance_on_stop"]; ok {
+		o.AdvanceOnStop = parseBool(v)
+	}
+
+	return o, nil
+}
+
 type Playlist struct {
-	Media []string
+	Name  string
+	Items []PlaylistItem
+	// Position in the play order, not necessarily the index of the item.
 	Index int
+	// Item indexes in play order when shuffled, nil to play in order.
+	Order []int
+	// Set once the playlist is active and its current item has been
+	// launched.
+	Started bool
+	Options
 }
 
-func NewPlaylist(media []string) *Playlist {
+func NewPlaylist(name string, items []PlaylistItem) *Playlist {
 	return &Playlist{
-		Media: media,
+		Name:  name,
+		Items: items,
 		Index: 0,
+		Options: Options{
+			Repeat: RepeatAll,
+		},
 	}
 }
 
+// SetOptions changes the playlist's options. Turning on shuffle creates a
+// new random play order, which is kept for the rest of the session. If the
+// playlist has already started, the order starts from the current item so
+// it keeps playing.
+func (p *Playlist) SetOptions(o Options) {
+	if o.Repeat == "" {
+		o.Repeat = RepeatAll
+	}
+
+	if o.Shuffle && p.Order == nil && len(p.Items) > 0 {
+		order := rand.Perm(len(p.Items))
+		if p.Started {
+			current := p.ItemIndex()
+			for i, idx := range order {
+				if idx == current {
+					order[0], order[i] = order[i], order[0]
+					break
+				}
+			}
+		}
+		p.Order = order
+		p.Index = 0
+	} else if !o.Shuffle && p.Order != nil {
+		p.Index = p.ItemIndex()
+		p.Order = nil
+	}
+
+	p.Options = o
+}
+
+// ItemIndex returns the index in Items of the current item.
+func (p *Playlist) ItemIndex() int {
+	if p.Order != nil && p.Index < len(p.Order) {
+		return p.Order[p.Index]
+	}
+	return p.Index
+}
+
+func (p *Playlist) Current() PlaylistItem {
+	return p.Items[p.ItemIndex()]
+}
+
+func (p Playlist) at(idx int) *Playlist {
+	p.Index = idx
+	return &p
+}
+
+// Next returns the playlist moved to the next item. At the end of the
+// playlist it wraps around, unless repeat is off in which case nil is
+// returned.
 func Next(p Playlist) *Playlist {
 	idx := p.Index + 1
-	if idx >= len(p.Media) {
+	if idx >= len(p.Items) {
+		if p.Repeat == RepeatNone {
+			return nil
+		}
 		idx = 0
 	}
-	return &Playlist{
-		Media: p.Media,
-		Index: idx,
-	}
+	return p.at(idx)
 }
 
+// Previous returns the playlist moved to the previous item. At the start of
+// the playlist it wraps around, unless repeat is off.
 func Previous(p Playlist) *Playlist {
 	idx := p.Index - 1
 	if idx < 0 {
-		idx = len(p.Media) - 1
+		if p.Repeat == RepeatNone {
+			idx = 0
+		} else {
+			idx = len(p.Items) - 1
+		}
 	}
-	return &Playlist{
-		Media: p.Media,
-		Index: idx,
+	return p.at(idx)
+}
+
+// Advance returns the playlist moved on automatically after the current
+// item has finished, following the repeat mode. Returns nil when the
+// playlist has ended.
+func Advance(p Playlist) *Playlist {
+	if p.Repeat == RepeatOne {
+		return p.at(p.Index)
+	}
+	return Next(p)
+}
+
+// Goto returns the playlist moved to the item at the given index in Items.
+func Goto(p Playlist, item int) (*Playlist, error) {
+	if item < 0 || item >= len(p.Items) {
+		return nil, fmt.Errorf("playlist index out of range: %d", item)
 	}
+
+	if p.Order == nil {
+		return p.at(item), nil
+	}
+
+	for i, idx := range p.Order {
+		if idx == item {
+			return p.at(i), nil
+		}
+	}
+
+	return nil, fmt.Errorf("playlist index not in play order: %d", item)
 }
 
-func (p *Playlist) Current() string {
-	return p.Media[p.Index]
+// Store looks up saved playlists by name.
+type Store interface {
+	LoadPlaylist(name string) (*Playlist, error)
 }
 
 type PlaylistController struct {
 	Active *Playlist
 	Queue  chan<- *Playlist
+	Store  Store
 }
@@ -0,0 +1,80 @@
+package playlists
+
+import (
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func testPlaylist(n int) *Playlist {
+	items := make([]PlaylistItem, 0, n)
+	for i := 0; i < n; i++ {
+		items = append(items, PlaylistItem{ZapScript: strconv.Itoa(i)})
+	}
+	return NewPlaylist("test", items)
+}
+
+func checkOrder(t *testing.T, p *Playlist) {
+	if len(p.Order) != len(p.Items) {
+		t.Fatalf("order has %d items, want %d", len(p.Order), len(p.Items))
+	}
+
+	sorted := append([]int(nil), p.Order...)
+	sort.Ints(sorted)
+	for i, idx := range sorted {
+		if i != idx {
+			t.Fatalf("order isn't a permutation of items: %v", p.Order)
+		}
+	}
+}
+
+func TestShuffleNewPlaylist(t *testing.T) {
+	firsts := make(map[int]bool)
+	for i := 0; i < 50; i++ {
+		p := testPlaylist(10)
+		p.SetOptions(Options{Shuffle: true})
+		checkOrder(t, p)
+
+		if p.Index != 0 {
+			t.Fatalf("shuffled playlist doesn't start at the beginning: %d", p.Index)
+		}
+
+		firsts[p.ItemIndex()] = true
+	}
+
+	if len(firsts) < 2 {
+		t.Errorf("shuffled playlists always start on the same item: %v", firsts)
+	}
+}
+
+func TestShuffleStartedPlaylist(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		p := testPlaylist(10)
+		p.Index = 3
+		p.Started = true
+
+		p.SetOptions(Options{Shuffle: true})
+		checkOrder(t, p)
+
+		if p.Index != 0 || p.ItemIndex() != 3 {
+			t.Fatalf("current item not kept: index %d, item %d", p.Index, p.ItemIndex())
+		}
+	}
+}
+
+func TestUnshuffle(t *testing.T) {
+	p := testPlaylist(10)
+	p.Started = true
+	p.SetOptions(Options{Shuffle: true})
+
+	p = Next(*p)
+	item := p.ItemIndex()
+
+	p.SetOptions(Options{})
+	if p.Order != nil {
+		t.Errorf("order kept after turning off shuffle: %v", p.Order)
+	}
+	if p.Index != item {
+		t.Errorf("current item changed from %d to %d", item, p.Index)
+	}
+}
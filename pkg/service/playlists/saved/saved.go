@@ -0,0 +1,119 @@
+// Package saved converts between playlists stored in the database and
+// playlists ready to play.
+package saved
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/database"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/playlists"
+)
+
+func toOptions(o database.PlaylistOptions) playlists.Options {
+	return playlists.Options{
+		Shuffle:       o.Shuffle,
+		Repeat:        o.Repeat,
+		Advance:       time.Duration(o.Advance * float64(time.Minute)),
+		AdvanceOnStop: o.AdvanceOnStop,
+	}
+}
+
+// Options converts playlist options, such as those loaded from a playlist
+// file, to be saved.
+func Options(o playlists.Options) database.PlaylistOptions {
+	return database.PlaylistOptions{
+		Shuffle:       o.Shuffle,
+		Repeat:        o.Repeat,
+		Advance:       o.Advance.Minutes(),
+		AdvanceOnStop: o.AdvanceOnStop,
+	}
+}
+
+func toItems(items []database.PlaylistItem) []playlists.PlaylistItem {
+	pis := make([]playlists.PlaylistItem, 0, len(items))
+	for _, item := range items {
+		pis = append(pis, playlists.PlaylistItem{
+			Name:      item.Name,
+			ZapScript: item.ZapScript,
+		})
+	}
+	return pis
+}
+
+// Items converts playlist items, such as those loaded from a playlist file,
+// to be saved.
+func Items(pis []playlists.PlaylistItem) []database.PlaylistItem {
+	items := make([]database.PlaylistItem, 0, len(pis))
+	for _, pi := range pis {
+		items = append(items, database.PlaylistItem{
+			Name:      pi.Name,
+			ZapScript: pi.ZapScript,
+		})
+	}
+	return items
+}
+
+// ToPlaylist converts a saved playlist to a new playlist ready to play.
+func ToPlaylist(p database.Playlist) *playlists.Playlist {
+	pls := playlists.NewPlaylist(p.Name, toItems(p.Items))
+	pls.SetOptions(toOptions(p.PlaylistOptions))
+	return pls
+}
+
+// Store looks up saved playlists in the database. Implements the
+// playlists.Store interface.
+type Store struct {
+	Db *database.Database
+}
+
+// LoadPlaylist returns a saved playlist by name, ready to play.
+func (s Store) LoadPlaylist(name string) (*playlists.Playlist, error) {
+	p, err := s.Db.GetPlaylistByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(p.Items) == 0 {
+		return nil, fmt.Errorf("playlist is empty: %s", name)
+	}
+
+	return ToPlaylist(p), nil
+}
+
+// SetActive stores the active playlist so it can be restored after a
+// restart. A nil playlist clears it.
+func SetActive(db *database.Database, pls *playlists.Playlist) error {
+	if pls == nil {
+		return db.SetActivePlaylist(nil)
+	}
+
+	return db.SetActivePlaylist(&database.ActivePlaylist{
+		Name:            pls.Name,
+		Items:           Items(pls.Items),
+		Index:           pls.Index,
+		Order:           pls.Order,
+		PlaylistOptions: Options(pls.Options),
+	})
+}
+
+// GetActive returns the stored active playlist, or nil if there isn't one.
+func GetActive(db *database.Database) (*playlists.Playlist, error) {
+	ap, err := db.GetActivePlaylist()
+	if err != nil || ap == nil {
+		return nil, err
+	}
+
+	pls := playlists.NewPlaylist(ap.Name, toItems(ap.Items))
+	// set directly to keep the same shuffled order
+	pls.Options = toOptions(ap.PlaylistOptions)
+	if pls.Repeat == "" {
+		pls.Repeat = playlists.RepeatAll
+	}
+	pls.Order = ap.Order
+	pls.Index = ap.Index
+	// only active playlists are stored, so it was already playing
+	pls.Started = true
+
+	return pls, nil
+}
@@ -2,13 +2,17 @@ package service
 
 import (
 	"errors"
+	"github.com/ZaparooProject/zaparoo-core/pkg/audio"
 	"github.com/ZaparooProject/zaparoo-core/pkg/config"
 	"github.com/ZaparooProject/zaparoo-core/pkg/database"
 	"github.com/ZaparooProject/zaparoo-core/pkg/service/playlists"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/playlists/saved"
 	"github.com/ZaparooProject/zaparoo-core/pkg/service/tokens"
 	"strings"
 	"time"
 
+	"golang.org/x/exp/slices"
+
 	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
 	"github.com/ZaparooProject/zaparoo-core/pkg/readers"
 	"github.com/ZaparooProject/zaparoo-core/pkg/service/state"
@@ -81,6 +85,7 @@ func connectReaders(
 					} else {
 						st.SetReader(device, r)
 						log.Info().Msgf("opened reader: %s", device)
+						audio.Feedback(pl, cfg, config.SoundReaderConnected)
 						break
 					}
 				}
@@ -101,6 +106,7 @@ func connectReaders(
 
 			if r.Connected() {
 				st.SetReader(detect, r)
+				audio.Feedback(pl, cfg, config.SoundReaderConnected)
 			} else {
 				err := r.Close()
 				if err != nil {
@@ -127,6 +133,25 @@ func connectReaders(
 	return nil
 }
 
+// activeExitDelay returns the hold mode exit delay in seconds for the
+// active launcher. Launch options may override the reader's delay.
+func activeExitDelay(pl platforms.Platform, cfg *config.Instance) float32 {
+	exitDelay := cfg.ReadersScan().ExitDelay
+
+	activeLauncher := pl.GetActiveLauncher()
+	for _, l := range pl.Launchers(cfg) {
+		if l.Id == activeLauncher {
+			opts := cfg.LookupLaunchOptions(l.Id, l.SystemId)
+			if opts.ExitDelay != nil {
+				exitDelay = *opts.ExitDelay
+			}
+			break
+		}
+	}
+
+	return exitDelay
+}
+
 func readerManager(
 	pl platforms.Platform,
 	cfg *config.Instance,
@@ -149,7 +174,7 @@ func readerManager(
 
 	playFail := func() {
 		if time.Since(lastError) > 1*time.Second {
-			pl.PlayFailSound(cfg)
+			audio.Feedback(pl, cfg, config.SoundScanFail)
 		}
 	}
 
@@ -163,8 +188,9 @@ func readerManager(
 			}
 		}
 
-		timerLen := time.Second * time.Duration(cfg.ReadersScan().ExitDelay)
-		log.Debug().Msgf("exit timer set to: %s seconds", timerLen)
+		exitDelay := activeExitDelay(pl, cfg)
+		timerLen := time.Duration(float64(exitDelay) * float64(time.Second))
+		log.Debug().Msgf("exit timer set to: %s", timerLen)
 		exitTimer = time.NewTimer(timerLen)
 
 		go func() {
@@ -185,7 +211,7 @@ func readerManager(
 			// run before_exit hook if one exists for system
 			var launcher platforms.Launcher
 			found := false
-			for _, l := range pl.Launchers() {
+			for _, l := range pl.Launchers(cfg) {
 				if l.Id == activeLauncher {
 					launcher = l
 					found = true
@@ -199,6 +225,7 @@ func readerManager(
 					plsc := playlists.PlaylistController{
 						Active: st.GetActivePlaylist(),
 						Queue:  plq,
+						Store:  saved.Store{Db: db},
 					}
 					t := tokens.Token{
 						ScanTime: time.Now(),
@@ -224,11 +251,30 @@ func readerManager(
 
 	// manage reader connections
 	go func() {
+		connect := cfg.Readers().Connect
+
 		for {
 			select {
 			case <-stopService:
 				return
 			case <-readerTicker.C:
+				// close all readers if the configured connections have
+				// changed, they're reconnected below using the new config
+				if newConnect := cfg.Readers().Connect; !slices.Equal(connect, newConnect) {
+					log.Info().Msg("reader connections changed, reconnecting readers")
+					connect = newConnect
+					for _, device := range st.ListReaders() {
+						r, ok := st.GetReader(device)
+						if ok && r != nil {
+							err := r.Close()
+							if err != nil {
+								log.Warn().Msgf("error closing reader: %s", err)
+							}
+						}
+						st.RemoveReader(device)
+					}
+				}
+
 				rs := st.ListReaders()
 				for _, device := range rs {
 					r, ok := st.GetReader(device)
@@ -312,7 +358,7 @@ func readerManager(
 			}
 
 			log.Info().Msgf("sending token: %v", scan)
-			pl.PlaySuccessSound(cfg)
+			audio.Feedback(pl, cfg, config.SoundScan)
 			itq <- *scan
 		} else {
 			log.Info().Msg("token was removed")
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+)
+
+func TestActiveExitDelay(t *testing.T) {
+	launcherDelay := float32(5)
+	systemDelay := float32(3)
+
+	defaults := config.BaseDefaults
+	defaults.Readers.Scan.ExitDelay = 2
+	defaults.Launchers.Default = []config.LaunchersDefault{{
+		Launcher: "Slow",
+		LaunchOptions: config.LaunchOptions{
+			ExitDelay: &launcherDelay,
+		},
+	}}
+	defaults.Systems.Default = []config.SystemsDefault{
+		{
+			System: "Genesis",
+			LaunchOptions: config.LaunchOptions{
+				ExitDelay: &systemDelay,
+			},
+		},
+		{
+			System: "SNES",
+			LaunchOptions: config.LaunchOptions{
+				ExitDelay: &systemDelay,
+			},
+		},
+	}
+	cfg := testConfig(t, defaults)
+
+	pl := &testPlatform{
+		launchers: []platforms.Launcher{
+			{Id: "Slow", SystemId: "Genesis"},
+			{Id: "Other", SystemId: "SNES"},
+			{Id: "Plain", SystemId: "NES"},
+		},
+	}
+
+	tests := []struct {
+		launcher string
+		want     float32
+	}{
+		{"Slow", launcherDelay},
+		{"Other", systemDelay},
+		{"Plain", 2},
+		{"Missing", 2},
+		{"", 2},
+	}
+
+	for _, tt := range tests {
+		pl.activeLauncher = tt.launcher
+		if got := activeExitDelay(pl, cfg); got != tt.want {
+			t.Errorf("%q: got %v, want %v", tt.launcher, got, tt.want)
+		}
+	}
+}
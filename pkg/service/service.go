@@ -22,10 +22,16 @@ along with Zaparoo Core.  If not, see <http://www.gnu.org/licenses/>.
 package service
 
 import (
-	"fmt"
 	"github.com/ZaparooProject/zaparoo-core/pkg/api"
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/audio"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/mqtt"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/notices"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/notifications"
 	"github.com/ZaparooProject/zaparoo-core/pkg/service/playlists"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/playlists/saved"
 	"github.com/ZaparooProject/zaparoo-core/pkg/service/tokens"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/webhooks"
 	"os"
 	"path/filepath"
 	"strings"
@@ -37,6 +43,7 @@ import (
 	"github.com/ZaparooProject/zaparoo-core/pkg/database"
 	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
 	"github.com/ZaparooProject/zaparoo-core/pkg/service/state"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/stats"
 	"github.com/ZaparooProject/zaparoo-core/pkg/zapscript"
 	"github.com/rs/zerolog/log"
 )
@@ -66,7 +73,7 @@ func launchToken(
 	}
 
 	if text == "" {
-		return fmt.Errorf("no ZapScript in token")
+		return tokens.NewLaunchError(tokens.ErrCodeNoZapScript, tokens.StageParse, "no ZapScript in token")
 	}
 
 	log.Info().Msgf("launching ZapScript: %s", text)
@@ -96,6 +103,16 @@ func launchToken(
 	return nil
 }
 
+// Set the active playlist and store it so it can be restored on the next
+// start.
+func setActivePlaylist(st *state.State, db *database.Database, pls *playlists.Playlist) {
+	st.SetActivePlaylist(pls)
+	err := saved.SetActive(db, pls)
+	if err != nil {
+		log.Error().Err(err).Msg("error saving active playlist")
+	}
+}
+
 func processTokenQueue(
 	platform platforms.Platform,
 	cfg *config.Instance,
@@ -104,7 +121,53 @@ func processTokenQueue(
 	db *database.Database,
 	lsq chan<- *tokens.Token,
 	plq chan *playlists.Playlist,
+	mns <-chan models.Notification,
 ) {
+	// when the current playlist item was launched and if media has been
+	// reported as started since, used for auto-advance
+	var itemLaunched time.Time
+	mediaStarted := false
+
+	if st.GetActivePlaylist() != nil {
+		itemLaunched = time.Now()
+	}
+
+	launchItem := func(pls *playlists.Playlist) {
+		itemLaunched = time.Now()
+		mediaStarted = false
+
+		go func() {
+			t := tokens.Token{
+				Text:     pls.Current().ZapScript,
+				ScanTime: time.Now(),
+				Source:   tokens.SourcePlaylist,
+			}
+			plsc := playlists.PlaylistController{
+				Active: pls,
+				Queue:  plq,
+				Store:  saved.Store{Db: db},
+			}
+			err := launchToken(platform, cfg, t, db, lsq, plsc)
+			if err != nil {
+				log.Error().Err(err).Msgf("error launching token")
+			}
+		}()
+	}
+
+	advance := func(pls *playlists.Playlist) {
+		next := playlists.Advance(*pls)
+		if next == nil {
+			log.Info().Msg("end of playlist, clearing active playlist")
+			setActivePlaylist(st, db, nil)
+			return
+		}
+
+		log.Info().Msg("advancing active playlist, launching token")
+		audio.Feedback(platform, cfg, config.SoundPlaylistAdvance)
+		setActivePlaylist(st, db, next)
+		launchItem(next)
+	}
+
 	for {
 		select {
 		case pls := <-plq:
@@ -113,52 +176,40 @@ func processTokenQueue(
 			if pls == nil {
 				if activePlaylist != nil {
 					log.Info().Msg("clearing active playlist")
+					setActivePlaylist(st, db, nil)
 				}
-				activePlaylist = nil
 				continue
-			} else if activePlaylist == nil {
-				log.Info().Msg("setting new active playlist, launching token")
-				activePlaylist = pls
-				go func() {
-					t := tokens.Token{
-						Text:     pls.Current(),
-						ScanTime: time.Now(),
-						Source:   tokens.SourcePlaylist,
-					}
-					plsc := playlists.PlaylistController{
-						Active: activePlaylist,
-						Queue:  plq,
-					}
-					err := launchToken(platform, cfg, t, db, lsq, plsc)
-					if err != nil {
-						log.Error().Err(err).Msgf("error launching token")
-					}
-				}()
+			}
+
+			// the current item is either launched below or already
+			// running from the previous active playlist
+			pls.Started = true
+			setActivePlaylist(st, db, pls)
+
+			if activePlaylist != nil && pls.Current() == activePlaylist.Current() {
+				log.Debug().Msg("playlist current token unchanged, skipping")
 				continue
-			} else {
-				if pls.Current() == activePlaylist.Current() {
-					log.Debug().Msg("playlist current token unchanged, skipping")
-					continue
-				}
+			}
 
+			if activePlaylist == nil {
+				log.Info().Msg("setting new active playlist, launching token")
+			} else {
 				log.Info().Msg("updating active playlist, launching token")
-				activePlaylist = pls
-				go func() {
-					t := tokens.Token{
-						Text:     pls.Current(),
-						ScanTime: time.Now(),
-						Source:   tokens.SourcePlaylist,
-					}
-					plsc := playlists.PlaylistController{
-						Active: activePlaylist,
-						Queue:  plq,
-					}
-					err := launchToken(platform, cfg, t, db, lsq, plsc)
-					if err != nil {
-						log.Error().Err(err).Msgf("error launching token")
-					}
-				}()
-				continue
+				audio.Feedback(platform, cfg, config.SoundPlaylistAdvance)
+			}
+
+			launchItem(pls)
+		case n := <-mns:
+			switch n.Method {
+			case models.NotificationStarted:
+				mediaStarted = true
+			case models.NotificationStopped:
+				pls := st.GetActivePlaylist()
+				// media being replaced by the next launch also reports
+				// stopped, so only advance if it started after the launch
+				if pls != nil && pls.AdvanceOnStop && mediaStarted {
+					advance(pls)
+				}
 			}
 		case t := <-itq:
 			// TODO: change this channel to send a token pointer or something
@@ -175,11 +226,13 @@ func processTokenQueue(
 			}
 
 			he := database.HistoryEntry{
-				Time: t.ScanTime,
-				Type: t.Type,
-				UID:  t.UID,
-				Text: t.Text,
-				Data: t.Data,
+				Time:    t.ScanTime,
+				Type:    t.Type,
+				UID:     t.UID,
+				Text:    t.Text,
+				Data:    t.Data,
+				Source:  t.Source,
+				Profile: cfg.Profile(),
 			}
 
 			if !st.RunZapScriptEnabled() {
@@ -196,11 +249,27 @@ func processTokenQueue(
 				plsc := playlists.PlaylistController{
 					Active: st.GetActivePlaylist(),
 					Queue:  plq,
+					Store:  saved.Store{Db: db},
 				}
 
 				err = launchToken(platform, cfg, t, db, lsq, plsc)
 				if err != nil {
 					log.Error().Err(err).Msgf("error launching token")
+					audio.Feedback(platform, cfg, config.SoundLaunchFail)
+
+					le := tokens.AsLaunchError(err, tokens.ErrCodeCommandFailed, tokens.StageRun)
+					he.Error = historyError(le)
+					st.Notifications <- models.Notification{
+						Method: models.NotificationTokensFailed,
+						Params: models.TokenFailedParams{
+							Type:  t.Type,
+							UID:   t.UID,
+							Text:  t.Text,
+							Error: state.LaunchErrorResponse(le),
+						},
+					}
+				} else {
+					audio.Feedback(platform, cfg, config.SoundLaunchSuccess)
 				}
 
 				he.Success = err == nil
@@ -213,6 +282,11 @@ func processTokenQueue(
 			if st.ShouldStopService() {
 				break
 			}
+
+			pls := st.GetActivePlaylist()
+			if pls != nil && pls.Advance > 0 && time.Since(itemLaunched) >= pls.Advance {
+				advance(pls)
+			}
 		}
 	}
 }
@@ -227,6 +301,7 @@ func Start(
 		pl.DataDir(),
 		pl.TempDir(),
 		filepath.Join(pl.DataDir(), platforms.MappingsDir),
+		audio.SoundsPath(pl),
 	}
 	for _, dir := range dirs {
 		err := os.MkdirAll(dir, 0755)
@@ -263,14 +338,66 @@ func Start(
 		return nil, err
 	}
 
+	nb := notifications.NewBroker()
+	apiNs := nb.Subscribe()
+
+	var stopMqtt func() error
+	if cfg.Mqtt().Enabled {
+		log.Info().Msg("starting MQTT bridge")
+		stopMqtt, err = mqtt.Start(pl, cfg, st, itq, nb.Subscribe())
+		if err != nil {
+			log.Error().Err(err).Msg("error starting MQTT bridge")
+		}
+	}
+
+	log.Info().Msg("starting webhook dispatcher")
+	go webhooks.Start(cfg, st, db, nb.SubscribeInternal())
+
+	log.Info().Msg("starting history pruner")
+	go pruneHistory(cfg, st, db)
+
+	log.Info().Msg("starting notice display")
+	go notices.Start(pl, cfg, st, nb.Subscribe())
+
+	log.Info().Msg("starting play session recorder")
+	go stats.Start(pl, st, db, nb.SubscribeInternal())
+
+	plNs := nb.SubscribeInternal()
+
+	log.Info().Msg("starting post-exit hook runner")
+	go runPostExitHooks(pl, cfg, st, db, lsq, plq, nb.SubscribeInternal())
+
+	go nb.Run(st, ns)
+
+	// restored after the broker is running so clients are notified
+	pls, err := saved.GetActive(db)
+	if err != nil {
+		log.Error().Err(err).Msg("error loading active playlist")
+	} else if pls != nil {
+		log.Info().Msgf("restoring active playlist: %s", pls.Name)
+		st.SetActivePlaylist(pls)
+	}
+
+	log.Info().Msg("starting config watcher")
+	stopWatcher, err := watchConfig(pl, cfg, st)
+	if err != nil {
+		log.Error().Err(err).Msg("error starting config watcher")
+	}
+
+	cfg.OnProfileChange(func(profile string) {
+		if !st.ShouldStopService() {
+			notifyProfileChanged(st, profile)
+		}
+	})
+
 	log.Info().Msg("starting API service")
-	go api.Start(pl, cfg, st, itq, db, ns)
+	go api.Start(pl, cfg, st, itq, plq, db, apiNs)
 
 	log.Info().Msg("starting reader manager")
 	go readerManager(pl, cfg, st, db, itq, lsq, plq)
 
 	log.Info().Msg("starting input token queue manager")
-	go processTokenQueue(pl, cfg, st, itq, db, lsq, plq)
+	go processTokenQueue(pl, cfg, st, itq, db, lsq, plq, plNs)
 
 	log.Info().Msg("running platform post start")
 	err = pl.StartPost(cfg, st.Notifications)
@@ -284,6 +411,18 @@ func Start(
 		if err != nil {
 			log.Warn().Msgf("error stopping platform: %s", err)
 		}
+		if stopWatcher != nil {
+			err = stopWatcher()
+			if err != nil {
+				log.Warn().Msgf("error stopping config watcher: %s", err)
+			}
+		}
+		if stopMqtt != nil {
+			err = stopMqtt()
+			if err != nil {
+				log.Warn().Msgf("error stopping MQTT bridge: %s", err)
+			}
+		}
 		st.StopService()
 		close(plq)
 		close(lsq)
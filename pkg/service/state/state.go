@@ -24,16 +24,23 @@ type State struct {
 	wroteToken     *tokens.Token
 	Notifications  chan<- models.Notification // TODO: move outside state
 	activePlaylist *playlists.Playlist
+	// signals notifyPlaylist that the active playlist changed
+	playlistChanged chan struct{}
+	stop            chan struct{}
 }
 
 func NewState(platform platforms.Platform) (*State, <-chan models.Notification) {
 	ns := make(chan models.Notification)
-	return &State{
-		runZapScript:  true,
-		platform:      platform,
-		readers:       make(map[string]readers.Reader),
-		Notifications: ns,
-	}, ns
+	s := &State{
+		runZapScript:    true,
+		platform:        platform,
+		readers:         make(map[string]readers.Reader),
+		Notifications:   ns,
+		playlistChanged: make(chan struct{}, 1),
+		stop:            make(chan struct{}),
+	}
+	go s.notifyPlaylist()
+	return s, ns
 }
 
 func (s *State) SetActiveCard(card tokens.Token) {
@@ -81,6 +88,9 @@ func (s *State) GetLastScanned() tokens.Token {
 
 func (s *State) StopService() {
 	s.mu.Lock()
+	if !s.stopService {
+		close(s.stop)
+	}
 	s.stopService = true
 	s.mu.Unlock()
 }
@@ -188,8 +198,99 @@ func (s *State) GetActivePlaylist() *playlists.Playlist {
 	return s.activePlaylist
 }
 
+// SetActivePlaylist changes the active playlist and notifies clients with a
+// playlists.changed notification. It doesn't wait for the notification to
+// be sent.
 func (s *State) SetActivePlaylist(playlist *playlists.Playlist) {
 	s.mu.Lock()
 	s.activePlaylist = playlist
 	s.mu.Unlock()
+
+	select {
+	case s.playlistChanged <- struct{}{}:
+	default:
+		// a notification is already pending and will have this playlist
+	}
+}
+
+// notifyPlaylist sends a playlists.changed notification with the active
+// playlist each time it changes, until the service stops. Changes made
+// while a notification is waiting to be read are sent as one notification
+// of the latest playlist.
+func (s *State) notifyPlaylist() {
+	for {
+		select {
+		case <-s.playlistChanged:
+		case <-s.stop:
+			return
+		}
+
+		n := models.Notification{
+			Method: models.NotificationPlaylistsChanged,
+			Params: ActivePlaylistResponse(s.GetActivePlaylist()),
+		}
+
+		select {
+		case s.Notifications <- n:
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// LaunchErrorResponse describes why a token failed for API clients.
+func LaunchErrorResponse(le *tokens.LaunchError) models.LaunchError {
+	resp := models.LaunchError{
+		Code:        le.Code,
+		Message:     le.Message,
+		Command:     le.Command,
+		Stage:       le.Stage,
+		Candidates:  make([]string, 0, len(le.Candidates)),
+		Suggestions: make([]string, 0, len(le.Suggestions)),
+	}
+	resp.Candidates = append(resp.Candidates, le.Candidates...)
+	resp.Suggestions = append(resp.Suggestions, le.Suggestions...)
+
+	return resp
+}
+
+// ActivePlaylistResponse describes the active playlist and its position for
+// API clients. A nil playlist is reported as inactive.
+func ActivePlaylistResponse(pls *playlists.Playlist) models.ActivePlaylistResponse {
+	if pls == nil {
+		return models.ActivePlaylistResponse{
+			Items: make([]models.PlaylistItemResponse, 0),
+			Order: make([]int, 0),
+		}
+	}
+
+	resp := models.ActivePlaylistResponse{
+		Active:        true,
+		Name:          pls.Name,
+		Items:         make([]models.PlaylistItemResponse, 0, len(pls.Items)),
+		Index:         pls.ItemIndex(),
+		Position:      pls.Index,
+		Order:         make([]int, 0, len(pls.Items)),
+		Shuffle:       pls.Shuffle,
+		Repeat:        pls.Repeat,
+		Advance:       pls.Advance.Minutes(),
+		AdvanceOnStop: pls.AdvanceOnStop,
+	}
+
+	for _, item := range pls.Items {
+		resp.Items = append(resp.Items, models.PlaylistItemResponse{
+			Name:      item.Name,
+			ZapScript: item.ZapScript,
+		})
+	}
+
+	if pls.Order != nil {
+		resp.Order = append(resp.Order, pls.Order...)
+	} else {
+		for i := range pls.Items {
+			resp.Order = append(resp.Order, i)
+		}
+	}
+
+	return resp
 }
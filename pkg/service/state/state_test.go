@@ -0,0 +1,124 @@
+package state
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/playlists"
+)
+
+func testPlaylist() *playlists.Playlist {
+	return playlists.NewPlaylist("Favourites", []playlists.PlaylistItem{
+		{Name: "Sonic", ZapScript: "genesis/sonic.md"},
+		{Name: "Mario", ZapScript: "snes/mario.sfc"},
+	})
+}
+
+func readPlaylistChanged(t *testing.T, ns <-chan models.Notification) models.ActivePlaylistResponse {
+	t.Helper()
+
+	select {
+	case n := <-ns:
+		if n.Method != models.NotificationPlaylistsChanged {
+			t.Fatalf("unexpected notification: %s", n.Method)
+		}
+		resp, ok := n.Params.(models.ActivePlaylistResponse)
+		if !ok {
+			t.Fatalf("unexpected params: %T", n.Params)
+		}
+		return resp
+	case <-time.After(time.Second):
+		t.Fatal("no playlists.changed notification")
+	}
+
+	return models.ActivePlaylistResponse{}
+}
+
+func TestSetActivePlaylistNotifies(t *testing.T) {
+	st, ns := NewState(nil)
+	t.Cleanup(st.StopService)
+
+	pls := playlists.Next(*testPlaylist())
+	st.SetActivePlaylist(pls)
+
+	want := models.ActivePlaylistResponse{
+		Active: true,
+		Name:   "Favourites",
+		Items: []models.PlaylistItemResponse{
+			{Name: "Sonic", ZapScript: "genesis/sonic.md"},
+			{Name: "Mario", ZapScript: "snes/mario.sfc"},
+		},
+		Index:    1,
+		Position: 1,
+		Order:    []int{0, 1},
+		Repeat:   playlists.RepeatAll,
+	}
+	if got := readPlaylistChanged(t, ns); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	st.SetActivePlaylist(nil)
+
+	got := readPlaylistChanged(t, ns)
+	if got.Active || got.Items == nil || got.Order == nil {
+		t.Errorf("cleared playlist reported wrong: %+v", got)
+	}
+}
+
+func TestSetActivePlaylistDoesntBlock(t *testing.T) {
+	st, ns := NewState(nil)
+	t.Cleanup(st.StopService)
+
+	done := make(chan struct{})
+	go func() {
+		// nothing is reading notifications yet
+		st.SetActivePlaylist(testPlaylist())
+		st.SetActivePlaylist(nil)
+		st.SetActivePlaylist(playlists.Next(*testPlaylist()))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("setting the active playlist blocked")
+	}
+
+	// the last change is always sent, earlier ones may be combined with it
+	var last models.ActivePlaylistResponse
+	for {
+		last = readPlaylistChanged(t, ns)
+		if last.Active && last.Index == 1 {
+			break
+		}
+	}
+
+	// it may be sent twice, but nothing older can follow it
+	select {
+	case n := <-ns:
+		if !reflect.DeepEqual(n.Params, last) {
+			t.Errorf("older playlist sent after latest: %+v", n)
+		}
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSetActivePlaylistStopped(t *testing.T) {
+	st, _ := NewState(nil)
+	st.StopService()
+
+	done := make(chan struct{})
+	go func() {
+		st.SetActivePlaylist(testPlaylist())
+		st.SetActivePlaylist(nil)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("setting the active playlist blocked after stopping")
+	}
+}
@@ -0,0 +1,276 @@
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/database"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/state"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// How often the end time of a running session is saved, so a session
+	// isn't lost if the service exits without media stopping.
+	saveInterval = time.Minute
+	// Maximum time between a token being scanned and media starting for
+	// the session to be credited to that token.
+	tokenWindow = 2 * time.Minute
+)
+
+type MediaStats struct {
+	SystemId   string
+	SystemName string
+	MediaName  string
+	MediaPath  string
+	Sessions   int
+	TotalTime  time.Duration
+	LastPlayed time.Time
+}
+
+type SystemStats struct {
+	SystemId   string
+	SystemName string
+	Sessions   int
+	TotalTime  time.Duration
+}
+
+type TokenStats struct {
+	Type       string
+	UID        string
+	Text       string
+	Sessions   int
+	TotalTime  time.Duration
+	LastPlayed time.Time
+}
+
+// MostPlayed groups sessions by media and returns them ordered by total
+// play time, limited to the given number of results.
+func MostPlayed(sessions []database.PlaySession, limit int) []MediaStats {
+	idx := make(map[string]int)
+	var ms []MediaStats
+
+	for _, s := range sessions {
+		key := s.SystemId + "/" + s.MediaPath
+		i, ok := idx[key]
+		if !ok {
+			i = len(ms)
+			idx[key] = i
+			ms = append(ms, MediaStats{
+				SystemId:   s.SystemId,
+				SystemName: s.SystemName,
+				MediaName:  s.MediaName,
+				MediaPath:  s.MediaPath,
+			})
+		}
+
+		ms[i].Sessions++
+		ms[i].TotalTime += s.Duration()
+		if s.Start.After(ms[i].LastPlayed) {
+			ms[i].LastPlayed = s.Start
+		}
+	}
+
+	sort.SliceStable(ms, func(i, j int) bool {
+		if ms[i].TotalTime == ms[j].TotalTime {
+			return ms[i].Sessions > ms[j].Sessions
+		}
+		return ms[i].TotalTime > ms[j].TotalTime
+	})
+
+	if limit > 0 && len(ms) > limit {
+		ms = ms[:limit]
+	}
+
+	return ms
+}
+
+// SystemTotals groups sessions by system, ordered by total play time.
+func SystemTotals(sessions []database.PlaySession) []SystemStats {
+	idx := make(map[string]int)
+	var ss []SystemStats
+
+	for _, s := range sessions {
+		i, ok := idx[s.SystemId]
+		if !ok {
+			i = len(ss)
+			idx[s.SystemId] = i
+			ss = append(ss, SystemStats{
+				SystemId:   s.SystemId,
+				SystemName: s.SystemName,
+			})
+		}
+
+		ss[i].Sessions++
+		ss[i].TotalTime += s.Duration()
+	}
+
+	sort.SliceStable(ss, func(i, j int) bool {
+		return ss[i].TotalTime > ss[j].TotalTime
+	})
+
+	return ss
+}
+
+// TokenUsage groups sessions by the token which launched them, ordered by
+// number of sessions. Sessions not started by a token are skipped.
+func TokenUsage(sessions []database.PlaySession, limit int) []TokenStats {
+	idx := make(map[string]int)
+	var ts []TokenStats
+
+	for _, s := range sessions {
+		if s.TokenUID == "" && s.TokenText == "" {
+			continue
+		}
+
+		// tokens with no UID, like API runs, are identified by their text
+		key := "uid:" + s.TokenUID
+		if s.TokenUID == "" {
+			key = "text:" + s.TokenText
+		}
+
+		i, ok := idx[key]
+		if !ok {
+			i = len(ts)
+			idx[key] = i
+			ts = append(ts, TokenStats{
+				Type: s.TokenType,
+				UID:  s.TokenUID,
+				Text: s.TokenText,
+			})
+		}
+
+		ts[i].Sessions++
+		ts[i].TotalTime += s.Duration()
+		if s.Start.After(ts[i].LastPlayed) {
+			ts[i].LastPlayed = s.Start
+		}
+	}
+
+	sort.SliceStable(ts, func(i, j int) bool {
+		if ts[i].Sessions == ts[j].Sessions {
+			return ts[i].TotalTime > ts[j].TotalTime
+		}
+		return ts[i].Sessions > ts[j].Sessions
+	})
+
+	if limit > 0 && len(ts) > limit {
+		ts = ts[:limit]
+	}
+
+	return ts
+}
+
+type recorder struct {
+	pl     platforms.Platform
+	st     *state.State
+	db     *database.Database
+	active *database.PlaySession
+	saved  time.Time
+}
+
+func (r *recorder) end(t time.Time) {
+	if r.active == nil {
+		return
+	}
+
+	r.active.End = t
+	r.active.Ended = true
+	err := r.db.UpdatePlaySession(*r.active)
+	if err != nil {
+		log.Error().Err(err).Msg("error ending play session")
+	}
+
+	log.Debug().Msgf("play session ended: %s", r.active.Id)
+	r.active = nil
+}
+
+func (r *recorder) start(params models.MediaStartedParams) {
+	now := time.Now()
+	r.end(now)
+
+	s := database.PlaySession{
+		Start:      now,
+		End:        now,
+		SystemId:   params.SystemId,
+		SystemName: params.SystemName,
+		MediaName:  params.MediaName,
+		MediaPath:  params.MediaPath,
+		LauncherId: r.pl.GetActiveLauncher(),
+	}
+
+	t := r.st.GetSoftwareToken()
+	if t != nil && now.Sub(t.ScanTime) <= tokenWindow {
+		s.TokenType = t.Type
+		s.TokenUID = t.UID
+		s.TokenText = t.Text
+	}
+
+	id, err := r.db.AddPlaySession(s)
+	if err != nil {
+		log.Error().Err(err).Msg("error adding play session")
+		return
+	}
+
+	s.Id = id
+	r.active = &s
+	r.saved = now
+	log.Debug().Msgf("play session started: %s", id)
+}
+
+// save periodically records the current end time of a running session.
+func (r *recorder) save() {
+	if r.active == nil || time.Since(r.saved) < saveInterval {
+		return
+	}
+
+	r.saved = time.Now()
+	r.active.End = r.saved
+	err := r.db.UpdatePlaySession(*r.active)
+	if err != nil {
+		log.Error().Err(err).Msg("error saving play session")
+	}
+}
+
+// Start records play sessions from media started and stopped notifications
+// until the service is stopped. Blocks until then.
+func Start(
+	pl platforms.Platform,
+	st *state.State,
+	db *database.Database,
+	ns <-chan models.Notification,
+) {
+	err := db.CloseOpenPlaySessions()
+	if err != nil {
+		log.Error().Err(err).Msg("error closing open play sessions")
+	}
+
+	r := &recorder{
+		pl: pl,
+		st: st,
+		db: db,
+	}
+
+	for !st.ShouldStopService() {
+		select {
+		case n := <-ns:
+			switch n.Method {
+			case models.NotificationStarted:
+				params, ok := n.Params.(models.MediaStartedParams)
+				if !ok {
+					log.Warn().Msgf("unexpected media started params: %v", n.Params)
+					continue
+				}
+				r.start(params)
+			case models.NotificationStopped:
+				r.end(time.Now())
+			}
+		case <-time.After(500 * time.Millisecond):
+			r.save()
+		}
+	}
+
+	r.end(time.Now())
+}
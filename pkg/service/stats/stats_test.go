@@ -0,0 +1,227 @@
+package stats
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/database"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/state"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/tokens"
+)
+
+// testPlatform only implements the active launcher. Any other method panics.
+type testPlatform struct {
+	platforms.Platform
+}
+
+func (testPlatform) GetActiveLauncher() string {
+	return "launcher"
+}
+
+func openTestDb(t *testing.T) *database.Database {
+	db, err := database.OpenFile(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+func session(system string, path string, start time.Time, d time.Duration) database.PlaySession {
+	return database.PlaySession{
+		SystemId:  system,
+		MediaPath: path,
+		Start:     start,
+		End:       start.Add(d),
+	}
+}
+
+func TestMostPlayed(t *testing.T) {
+	now := time.Now()
+	ss := []database.PlaySession{
+		session("NES", "a", now.Add(-3*time.Hour), 10*time.Minute),
+		session("NES", "b", now.Add(-2*time.Hour), 30*time.Minute),
+		session("NES", "a", now.Add(-time.Hour), 25*time.Minute),
+		session("SNES", "a", now, 5*time.Minute),
+	}
+
+	ms := MostPlayed(ss, 0)
+	if len(ms) != 3 {
+		t.Fatalf("got %d media, want 3", len(ms))
+	}
+
+	if ms[0].MediaPath != "a" || ms[0].SystemId != "NES" ||
+		ms[0].Sessions != 2 || ms[0].TotalTime != 35*time.Minute {
+		t.Errorf("unexpected most played: %+v", ms[0])
+	}
+	if !ms[0].LastPlayed.Equal(now.Add(-time.Hour)) {
+		t.Errorf("unexpected last played: %s", ms[0].LastPlayed)
+	}
+	if ms[1].MediaPath != "b" || ms[2].SystemId != "SNES" {
+		t.Errorf("media not ordered by time: %+v", ms)
+	}
+
+	if len(MostPlayed(ss, 1)) != 1 {
+		t.Error("limit not applied")
+	}
+}
+
+func TestSystemTotals(t *testing.T) {
+	now := time.Now()
+	ss := []database.PlaySession{
+		session("NES", "a", now, 10*time.Minute),
+		session("SNES", "a", now, 30*time.Minute),
+		session("NES", "b", now, 5*time.Minute),
+	}
+
+	st := SystemTotals(ss)
+	if len(st) != 2 {
+		t.Fatalf("got %d systems, want 2", len(st))
+	}
+	if st[0].SystemId != "SNES" || st[0].TotalTime != 30*time.Minute {
+		t.Errorf("unexpected top system: %+v", st[0])
+	}
+	if st[1].Sessions != 2 || st[1].TotalTime != 15*time.Minute {
+		t.Errorf("unexpected NES totals: %+v", st[1])
+	}
+}
+
+func TestTokenUsage(t *testing.T) {
+	now := time.Now()
+	withToken := func(uid string, text string, d time.Duration) database.PlaySession {
+		s := session("NES", "a", now, d)
+		s.TokenUID = uid
+		s.TokenText = text
+		return s
+	}
+
+	ss := []database.PlaySession{
+		withToken("uid1", "**launch.random:nes", time.Minute),
+		withToken("uid1", "changed text", time.Minute),
+		withToken("", "api text", 10*time.Minute),
+		withToken("", "", time.Hour),
+	}
+
+	ts := TokenUsage(ss, 0)
+	if len(ts) != 2 {
+		t.Fatalf("got %d tokens, want 2: %+v", len(ts), ts)
+	}
+	if ts[0].UID != "uid1" || ts[0].Sessions != 2 {
+		t.Errorf("tokens not grouped by uid: %+v", ts[0])
+	}
+	if ts[1].Text != "api text" || ts[1].TotalTime != 10*time.Minute {
+		t.Errorf("token without uid not grouped by text: %+v", ts[1])
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	db := openTestDb(t)
+	st, _ := state.NewState(nil)
+	r := &recorder{pl: testPlatform{}, st: st, db: db}
+
+	st.SetSoftwareToken(&tokens.Token{
+		UID:      "uid1",
+		Text:     "**launch.random:nes",
+		ScanTime: time.Now(),
+	})
+
+	r.start(models.MediaStartedParams{SystemId: "NES", MediaPath: "first"})
+	if r.active == nil {
+		t.Fatal("session not started")
+	}
+
+	// starting new media ends the running session
+	r.start(models.MediaStartedParams{SystemId: "NES", MediaPath: "second"})
+	r.end(time.Now().Add(time.Minute))
+
+	ss, err := db.GetPlaySessions(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ss) != 2 {
+		t.Fatalf("got %d sessions, want 2", len(ss))
+	}
+
+	for _, s := range ss {
+		if !s.Ended || s.LauncherId != "launcher" || s.TokenUID != "uid1" {
+			t.Errorf("unexpected session: %+v", s)
+		}
+	}
+
+	if ss[0].MediaPath != "second" || ss[0].Duration() < time.Minute {
+		t.Errorf("unexpected last session: %+v", ss[0])
+	}
+
+	if r.active != nil {
+		t.Error("session still active after end")
+	}
+	r.end(time.Now())
+}
+
+func TestRecorderTokenWindow(t *testing.T) {
+	db := openTestDb(t)
+	st, _ := state.NewState(nil)
+	r := &recorder{pl: testPlatform{}, st: st, db: db}
+
+	st.SetSoftwareToken(&tokens.Token{
+		UID:      "old",
+		ScanTime: time.Now().Add(-tokenWindow - time.Minute),
+	})
+
+	r.start(models.MediaStartedParams{SystemId: "NES", MediaPath: "game"})
+	if r.active == nil || r.active.TokenUID != "" {
+		t.Errorf("old token credited with session: %+v", r.active)
+	}
+}
+
+func TestStart(t *testing.T) {
+	db := openTestDb(t)
+	st, _ := state.NewState(nil)
+
+	// left open by a previous run
+	_, err := db.AddPlaySession(database.PlaySession{Start: time.Now(), End: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ns := make(chan models.Notification)
+	done := make(chan struct{})
+	go func() {
+		Start(testPlatform{}, st, db, ns)
+		close(done)
+	}()
+
+	ns <- models.Notification{
+		Method: models.NotificationStarted,
+		Params: models.MediaStartedParams{SystemId: "SNES", MediaPath: "game"},
+	}
+	ns <- models.Notification{Method: models.NotificationStopped}
+
+	st.StopService()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("recorder didn't stop")
+	}
+
+	ss, err := db.GetPlaySessions(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ss) != 2 {
+		t.Fatalf("got %d sessions, want 2", len(ss))
+	}
+	for _, s := range ss {
+		if !s.Ended {
+			t.Errorf("session left open: %+v", s)
+		}
+	}
+	if ss[0].SystemId != "SNES" {
+		t.Errorf("unexpected recorded session: %+v", ss[0])
+	}
+}
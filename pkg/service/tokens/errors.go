@@ -0,0 +1,81 @@
+package tokens
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Codes of launch errors, for clients to decide how to help fix them.
+const (
+	ErrCodeNoZapScript     = "no_zapscript"
+	ErrCodeInvalidCommand  = "invalid_command"
+	ErrCodeUnknownCommand  = "unknown_command"
+	ErrCodeInvalidArgs     = "invalid_args"
+	ErrCodeSystemNotFound  = "system_not_found"
+	ErrCodeFileNotFound    = "file_not_found"
+	ErrCodeNoResults       = "no_results"
+	ErrCodeNoIndex         = "no_index"
+	ErrCodeLauncherMissing = "launcher_not_found"
+	ErrCodeLaunchFailed    = "launch_failed"
+	ErrCodeCommandFailed   = "command_failed"
+	ErrCodeHookFailed      = "hook_failed"
+)
+
+// Stages of running a token a launch error can happen at.
+const (
+	StageParse   = "parse"
+	StageResolve = "resolve"
+	StageLaunch  = "launch"
+	StageRun     = "run"
+)
+
+// LaunchError describes why a token's ZapScript couldn't be run.
+type LaunchError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// ZapScript command which failed, such as "launch.search".
+	Command string `json:"command,omitempty"`
+	Stage   string `json:"stage,omitempty"`
+	// Paths checked or systems searched before giving up.
+	Candidates []string `json:"candidates,omitempty"`
+	// Names from the games index similar to the one asked for.
+	Suggestions []string `json:"suggestions,omitempty"`
+	Err         error    `json:"-"`
+}
+
+// NewLaunchError creates a launch error with a formatted message.
+func NewLaunchError(code string, stage string, format string, args ...any) *LaunchError {
+	return &LaunchError{
+		Code:    code,
+		Stage:   stage,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func (e *LaunchError) Error() string {
+	return e.Message
+}
+
+func (e *LaunchError) Unwrap() error {
+	return e.Err
+}
+
+// AsLaunchError returns the launch error in err's chain or, if there isn't
+// one, wraps err as a launch error with the given code and stage.
+func AsLaunchError(err error, code string, stage string) *LaunchError {
+	if err == nil {
+		return nil
+	}
+
+	var le *LaunchError
+	if errors.As(err, &le) {
+		return le
+	}
+
+	return &LaunchError{
+		Code:    code,
+		Stage:   stage,
+		Message: err.Error(),
+		Err:     err,
+	}
+}
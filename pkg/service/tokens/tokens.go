@@ -10,6 +10,10 @@ const (
 	TypeAmiibo         = "Amiibo"
 	TypeLegoDimensions = "LegoDimensions"
 	SourcePlaylist     = "Playlist"
+	SourceMqtt         = "MQTT"
+	SourceApi          = "API"
+	SourceRest         = "REST"
+	SourceHook         = "Hook"
 )
 
 type Token struct {
@@ -0,0 +1,197 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/database"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/state"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	HeaderEvent     = "X-Zaparoo-Event"
+	HeaderDelivery  = "X-Zaparoo-Delivery"
+	HeaderSignature = "X-Zaparoo-Signature"
+	MaxAttempts     = 5
+	RequestTimeout  = 10 * time.Second
+	// Timeout of the single attempt made to deliver a test payload, short
+	// enough to answer the API request which asked for it.
+	TestTimeout = 5 * time.Second
+)
+
+// Events is the list of notification types a webhook can subscribe to.
+var Events = []string{
+	models.NotificationReadersConnected,
+	models.NotificationReadersDisconnected,
+	models.NotificationTokensAdded,
+	models.NotificationTokensRemoved,
+	models.NotificationTokensFailed,
+	models.NotificationStarted,
+	models.NotificationStopped,
+	models.NotificationMediaIndexing,
+}
+
+type Payload struct {
+	Id       string    `json:"id"`
+	Event    string    `json:"event"`
+	Time     time.Time `json:"time"`
+	DeviceId string    `json:"deviceId"`
+	Params   any       `json:"params,omitempty"`
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of body using the webhook secret,
+// formatted for the signature header.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Delay before the given retry attempt, doubling each time from 1 second.
+func backoff(attempt int) time.Duration {
+	return time.Duration(1<<(attempt-1)) * time.Second
+}
+
+var (
+	client     = &http.Client{Timeout: RequestTimeout}
+	testClient = &http.Client{Timeout: TestTimeout}
+)
+
+func post(c *http.Client, w database.Webhook, p Payload, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, w.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", config.AppName+"/"+config.AppVersion)
+	req.Header.Set(HeaderEvent, p.Event)
+	req.Header.Set(HeaderDelivery, p.Id)
+	if w.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(w.Secret, body))
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(resp.Body)
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Deliver sends a payload to a webhook, retrying with backoff on failure,
+// and records the final outcome in the delivery log.
+func Deliver(db *database.Database, w database.Webhook, p Payload) database.WebhookDelivery {
+	return deliver(db, w, p, client, MaxAttempts)
+}
+
+// Test sends a payload to a webhook once, without retries, and records the
+// outcome in the delivery log.
+func Test(db *database.Database, w database.Webhook, p Payload) database.WebhookDelivery {
+	return deliver(db, w, p, testClient, 1)
+}
+
+func deliver(
+	db *database.Database,
+	w database.Webhook,
+	p Payload,
+	c *http.Client,
+	attempts int,
+) database.WebhookDelivery {
+	wd := database.WebhookDelivery{
+		WebhookId: w.Id,
+		Time:      p.Time,
+		Event:     p.Event,
+	}
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		wd.Error = err.Error()
+	} else {
+		for attempt := 1; attempt <= attempts; attempt++ {
+			if attempt > 1 {
+				time.Sleep(backoff(attempt - 1))
+			}
+
+			wd.Attempts = attempt
+			wd.StatusCode, err = post(c, w, p, body)
+			if err == nil {
+				wd.Success = true
+				wd.Error = ""
+				break
+			}
+
+			wd.Error = err.Error()
+			log.Warn().Err(err).Msgf("webhook %s delivery attempt %d failed", w.Id, attempt)
+		}
+	}
+
+	if !wd.Success {
+		log.Error().Msgf("webhook %s delivery failed: %s", w.Id, wd.Error)
+	}
+
+	err = db.AddWebhookDelivery(wd)
+	if err != nil {
+		log.Error().Err(err).Msg("error adding webhook delivery")
+	}
+
+	return wd
+}
+
+func NewPayload(cfg *config.Instance, n models.Notification) Payload {
+	return Payload{
+		Id:       uuid.New().String(),
+		Event:    n.Method,
+		Time:     time.Now(),
+		DeviceId: cfg.DeviceId(),
+		Params:   n.Params,
+	}
+}
+
+// Start delivers every notification to all enabled webhooks subscribed to
+// it until the service is stopped. Blocks until then.
+func Start(
+	cfg *config.Instance,
+	st *state.State,
+	db *database.Database,
+	ns <-chan models.Notification,
+) {
+	for !st.ShouldStopService() {
+		select {
+		case n := <-ns:
+			ws, err := db.GetEnabledWebhooks()
+			if err != nil {
+				log.Error().Err(err).Msg("error getting webhooks")
+				continue
+			}
+
+			p := NewPayload(cfg, n)
+			for _, w := range ws {
+				if w.Subscribed(n.Method) {
+					go Deliver(db, w, p)
+				}
+			}
+		case <-time.After(500 * time.Millisecond):
+			continue
+		}
+	}
+}
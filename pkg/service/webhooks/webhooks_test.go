@@ -0,0 +1,127 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/api/models"
+	"github.com/ZaparooProject/zaparoo-core/pkg/database"
+)
+
+func openTestDb(t *testing.T) *database.Database {
+	db, err := database.OpenFile(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+func testPayload() Payload {
+	return Payload{
+		Id:       "delivery",
+		Event:    models.NotificationStarted,
+		Time:     time.Now(),
+		DeviceId: "device",
+	}
+}
+
+func TestSign(t *testing.T) {
+	// HMAC-SHA256 of "body" with key "secret"
+	want := "sha256=dc46983557fea127b43af721467eb9b3fde2338fe3e14f51952aa8478c13d355"
+	if got := Sign("secret", []byte("body")); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt, want := range map[int]time.Duration{
+		1: time.Second,
+		2: 2 * time.Second,
+		4: 8 * time.Second,
+	} {
+		if got := backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	db := openTestDb(t)
+	var calls atomic.Int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// fail the first attempt so it's retried
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(HeaderSignature) != Sign("secret", body) {
+			t.Error("invalid signature header")
+		}
+		if r.Header.Get(HeaderEvent) != models.NotificationStarted {
+			t.Errorf("unexpected event header: %s", r.Header.Get(HeaderEvent))
+		}
+
+		var p Payload
+		if err := json.Unmarshal(body, &p); err != nil || p.Id != "delivery" {
+			t.Errorf("unexpected payload: %s", body)
+		}
+	}))
+	defer ts.Close()
+
+	w := database.Webhook{Id: "1", Url: ts.URL, Secret: "secret"}
+	wd := Deliver(db, w, testPayload())
+
+	if !wd.Success || wd.Attempts != 2 || wd.StatusCode != http.StatusOK || wd.Error != "" {
+		t.Errorf("unexpected delivery: %+v", wd)
+	}
+
+	wds, err := db.GetWebhookDeliveries("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wds) != 1 || !wds[0].Success {
+		t.Errorf("delivery not recorded: %+v", wds)
+	}
+}
+
+func TestTestSendsOnce(t *testing.T) {
+	db := openTestDb(t)
+	var calls atomic.Int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	w := database.Webhook{Id: "1", Url: ts.URL}
+	wd := Test(db, w, testPayload())
+
+	if wd.Success || wd.Attempts != 1 || wd.StatusCode != http.StatusBadGateway || wd.Error == "" {
+		t.Errorf("unexpected delivery: %+v", wd)
+	}
+
+	if calls.Load() != 1 {
+		t.Errorf("test sent %d times, want 1", calls.Load())
+	}
+
+	wds, err := db.GetWebhookDeliveries("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wds) != 1 || wds[0].Success {
+		t.Errorf("failed delivery not recorded: %+v", wds)
+	}
+}
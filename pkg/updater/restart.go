@@ -0,0 +1,131 @@
+//go:build linux || darwin
+
+package updater
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+	"github.com/ZaparooProject/zaparoo-core/pkg/utils"
+)
+
+// systemdUnit is the service installed by the Linux and SteamOS -install
+// flag.
+const systemdUnit = "zaparoo"
+
+// Restart restarts a service started with the -service flag, or by the
+// systemd unit, so it runs the binary at binPath. From inside the service,
+// the restart is handed to a new process of the binary, which would
+// otherwise be stopped with it. Other services must be restarted by the
+// user and ErrRestartUnsupported is returned.
+func Restart(pl platforms.Platform, binPath string) error {
+	svc, err := utils.NewService(utils.ServiceArgs{Platform: pl})
+	if err != nil {
+		return err
+	}
+
+	if !svc.Running() {
+		return restartSystemd()
+	}
+
+	pid, err := svc.Pid()
+	if err != nil {
+		return err
+	}
+
+	if pid != os.Getpid() {
+		// start the service from the updated binary, not this one
+		err = os.Setenv(config.AppEnv, binPath)
+		if err != nil {
+			return err
+		}
+		return svc.Restart()
+	}
+
+	cmd := exec.Command(binPath, "-service", "restart")
+	cmd.Env = os.Environ()
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	err = cmd.Start()
+	if err != nil {
+		return fmt.Errorf("error starting restart: %w", err)
+	}
+
+	return cmd.Process.Release()
+}
+
+// unitStatus is the state of a systemd unit read from systemctl show.
+type unitStatus struct {
+	mainPid int
+	restart string
+}
+
+// parseUnitStatus reads the output of systemctl show with the MainPID and
+// Restart properties.
+func parseUnitStatus(out string) unitStatus {
+	var us unitStatus
+	for _, line := range strings.Split(out, "\n") {
+		k, v, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "MainPID":
+			us.mainPid, _ = strconv.Atoi(v)
+		case "Restart":
+			us.restart = v
+		}
+	}
+	return us
+}
+
+// restartSystemd restarts the systemd unit if it's running. The restart is
+// queued without waiting, so it can be requested from inside the service.
+// The service usually runs as a user who isn't allowed to restart it, in
+// which case it stops itself and is started again by systemd, if the unit
+// restarts it.
+func restartSystemd() error {
+	systemctl, err := exec.LookPath("systemctl")
+	if err != nil {
+		return ErrRestartUnsupported
+	}
+
+	out, err := exec.Command(
+		systemctl, "show", systemdUnit, "--property=MainPID,Restart",
+	).Output()
+	if err != nil {
+		return ErrRestartUnsupported
+	}
+	us := parseUnitStatus(string(out))
+	if us.mainPid == 0 {
+		return ErrRestartUnsupported
+	}
+
+	out, err = exec.Command(
+		systemctl, "restart", "--no-block", "--no-ask-password", systemdUnit,
+	).CombinedOutput()
+	if err == nil {
+		return nil
+	} else if us.mainPid != os.Getpid() {
+		return fmt.Errorf(
+			"error restarting %s unit: %w: %s",
+			systemdUnit, err, strings.TrimSpace(string(out)),
+		)
+	} else if us.restart != "always" {
+		return ErrRestartUnsupported
+	}
+
+	// give the caller time to respond before the service stops
+	time.AfterFunc(time.Second, func() {
+		_ = syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	})
+
+	return nil
+}
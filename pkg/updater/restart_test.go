@@ -0,0 +1,22 @@
+//go:build linux || darwin
+
+package updater
+
+import "testing"
+
+func TestParseUnitStatus(t *testing.T) {
+	us := parseUnitStatus("MainPID=1234\nRestart=always\n")
+	if us.mainPid != 1234 || us.restart != "always" {
+		t.Errorf("unexpected status: %+v", us)
+	}
+
+	us = parseUnitStatus("Restart=on-failure\nMainPID=0\n")
+	if us.mainPid != 0 || us.restart != "on-failure" {
+		t.Errorf("unexpected status for stopped unit: %+v", us)
+	}
+
+	us = parseUnitStatus("")
+	if us.mainPid != 0 || us.restart != "" {
+		t.Errorf("unexpected status for no output: %+v", us)
+	}
+}
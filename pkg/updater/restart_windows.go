@@ -0,0 +1,8 @@
+package updater
+
+import "github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+
+// Restart isn't supported on Windows, Core must be restarted by the user.
+func Restart(_ platforms.Platform, _ string) error {
+	return ErrRestartUnsupported
+}
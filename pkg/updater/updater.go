@@ -0,0 +1,393 @@
+// Package updater installs new releases of Core from a signed release feed.
+//
+// The feed is a JSON document listing the latest release and a download for
+// each platform, with the SHA-256 checksum of each download:
+//
+//	{
+//	  "version": "2.3.0",
+//	  "notes": "...",
+//	  "assets": [
+//	    {"platform": "mister", "arch": "arm", "url": "zaparoo-mister", "sha256": "..."}
+//	  ]
+//	}
+//
+// The feed must be signed, with the base64 encoded Ed25519 signature of the
+// feed's bytes at the feed's URL plus ".sig". Asset URLs may be relative to
+// the feed and can be the binary itself or a zip file containing it.
+package updater
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	RequestTimeout  = 30 * time.Second
+	DownloadTimeout = 10 * time.Minute
+	// Largest feed or signature which will be read.
+	maxFeedSize = 1 << 20
+	// Suffix of the previous binary kept after an update.
+	OldSuffix = ".old"
+	newSuffix = ".new"
+)
+
+var (
+	ErrNotConfigured = errors.New("no update feed configured")
+	ErrNoPublicKey   = errors.New("no update public key configured")
+	ErrBadSignature  = errors.New("release feed signature is invalid")
+	ErrBadChecksum   = errors.New("download checksum doesn't match release feed")
+	ErrNoAsset       = errors.New("no release for this platform")
+	ErrUpToDate      = errors.New("already up to date")
+	ErrNoRollback    = errors.New("no previous version to roll back to")
+	// Restart Core manually to run the updated binary.
+	ErrRestartUnsupported = errors.New("service can't be restarted automatically")
+)
+
+type Asset struct {
+	Platform string `json:"platform"`
+	// GOARCH the binary was built for, any if it's empty.
+	Arch   string `json:"arch,omitempty"`
+	Url    string `json:"url"`
+	Sha256 string `json:"sha256"`
+}
+
+type Release struct {
+	Version string  `json:"version"`
+	Notes   string  `json:"notes,omitempty"`
+	Assets  []Asset `json:"assets"`
+}
+
+// Status is the result of checking the feed for a newer release.
+type Status struct {
+	Current   string
+	Latest    string
+	Notes     string
+	Available bool
+	// Download for this platform, with its URL resolved against the feed.
+	Asset *Asset
+}
+
+var client = &http.Client{Timeout: RequestTimeout}
+
+func fetch(c *http.Client, u string, limit int64) ([]byte, error) {
+	resp, err := c.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status fetching %s: %s", u, resp.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, limit))
+}
+
+// FetchRelease downloads the release feed and checks its signature against
+// the public key.
+func FetchRelease(feedUrl string, publicKey string) (*Release, error) {
+	if feedUrl == "" {
+		return nil, ErrNotConfigured
+	} else if publicKey == "" {
+		return nil, ErrNoPublicKey
+	}
+
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid update public key")
+	}
+
+	feed, err := fetch(client, feedUrl, maxFeedSize)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching release feed: %w", err)
+	}
+
+	sigData, err := fetch(client, feedUrl+".sig", maxFeedSize)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching release feed signature: %w", err)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sigData)))
+	if err != nil || !ed25519.Verify(key, feed, sig) {
+		return nil, ErrBadSignature
+	}
+
+	var release Release
+	err = json.Unmarshal(feed, &release)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing release feed: %w", err)
+	}
+
+	return &release, nil
+}
+
+// FindAsset returns the release's download for a platform and architecture,
+// with its URL resolved against the feed's.
+func (r *Release) FindAsset(feedUrl string, platform string, arch string) (*Asset, error) {
+	base, err := url.Parse(feedUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range r.Assets {
+		if a.Platform != platform || (a.Arch != "" && a.Arch != arch) {
+			continue
+		}
+
+		ref, err := url.Parse(a.Url)
+		if err != nil {
+			return nil, fmt.Errorf("invalid asset url: %w", err)
+		}
+
+		found := a
+		found.Url = base.ResolveReference(ref).String()
+		return &found, nil
+	}
+
+	return nil, ErrNoAsset
+}
+
+// Check fetches the configured release feed and reports if it has a newer
+// release for the platform.
+func Check(cfg *config.Instance, platform string) (*Status, error) {
+	uc := cfg.Update()
+
+	release, err := FetchRelease(uc.FeedUrl, uc.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{
+		Current: config.AppVersion,
+		Latest:  release.Version,
+		Notes:   release.Notes,
+	}
+
+	if CompareVersions(release.Version, config.AppVersion) <= 0 {
+		return status, nil
+	}
+
+	asset, err := release.FindAsset(uc.FeedUrl, platform, runtime.GOARCH)
+	if errors.Is(err, ErrNoAsset) {
+		log.Info().Msgf("release %s has no download for %s", release.Version, platform)
+		return status, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	status.Available = true
+	status.Asset = asset
+
+	return status, nil
+}
+
+// BinPath returns the path of the installed binary, which is not the running
+// one if the service was started from a temporary copy.
+func BinPath() (string, error) {
+	if appPath := os.Getenv(config.AppEnv); appPath != "" {
+		return appPath, nil
+	}
+
+	exePath, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.EvalSymlinks(exePath)
+}
+
+// download writes the asset next to the binary at binPath, checking it
+// matches the asset's checksum, and returns the written file's path.
+func download(asset *Asset, binPath string) (string, error) {
+	want, err := hex.DecodeString(asset.Sha256)
+	if err != nil || len(want) != sha256.Size {
+		return "", fmt.Errorf("invalid checksum in release feed")
+	}
+
+	dc := &http.Client{Timeout: DownloadTimeout}
+	resp, err := dc.Get(asset.Url)
+	if err != nil {
+		return "", fmt.Errorf("error downloading release: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status downloading release: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error downloading release: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	if !bytes.Equal(sum[:], want) {
+		return "", ErrBadChecksum
+	}
+
+	if isZip(asset.Url, data) {
+		data, err = extractBinary(data, filepath.Base(binPath))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	newPath := binPath + newSuffix
+	err = os.WriteFile(newPath, data, 0755)
+	if err != nil {
+		return "", fmt.Errorf("error writing release: %w", err)
+	}
+
+	return newPath, nil
+}
+
+func isZip(u string, data []byte) bool {
+	return strings.HasSuffix(strings.ToLower(u), ".zip") ||
+		bytes.HasPrefix(data, []byte("PK\x03\x04"))
+}
+
+// extractBinary returns the file with the given name from anywhere in a zip
+// file.
+func extractBinary(data []byte, name string) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("error opening release zip: %w", err)
+	}
+
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || filepath.Base(f.Name) != name {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer func(rc io.ReadCloser) {
+			_ = rc.Close()
+		}(rc)
+
+		return io.ReadAll(rc)
+	}
+
+	return nil, fmt.Errorf("release zip doesn't contain %s", name)
+}
+
+// swap replaces the binary at binPath with the one at newPath, keeping the
+// replaced binary for rollback. The binary is hard linked to its backup and
+// replaced with a single rename where possible, so it always exists. Where
+// links aren't supported, like on FAT filesystems and for a running binary
+// on Windows, it's moved aside first and restored if the new one can't be
+// moved in.
+func swap(binPath string, newPath string) error {
+	oldPath := binPath + OldSuffix
+
+	err := os.Remove(oldPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error removing previous backup: %w", err)
+	}
+
+	if err := os.Link(binPath, oldPath); err == nil {
+		return os.Rename(newPath, binPath)
+	}
+
+	err = os.Rename(binPath, oldPath)
+	if err != nil {
+		return fmt.Errorf("error backing up binary: %w", err)
+	}
+
+	err = os.Rename(newPath, binPath)
+	if err != nil {
+		if rerr := os.Rename(oldPath, binPath); rerr != nil {
+			log.Error().Err(rerr).Msg("error restoring binary")
+		}
+		return fmt.Errorf("error replacing binary: %w", err)
+	}
+
+	return nil
+}
+
+// Install downloads the status's release and swaps it in for the binary at
+// binPath. The replaced binary is kept next to it for Rollback.
+func Install(status *Status, binPath string) error {
+	if !status.Available || status.Asset == nil {
+		return ErrUpToDate
+	}
+
+	log.Info().Msgf("downloading release %s: %s", status.Latest, status.Asset.Url)
+	newPath, err := download(status.Asset, binPath)
+	if err != nil {
+		return err
+	}
+
+	err = swap(binPath, newPath)
+	if err != nil {
+		_ = os.Remove(newPath)
+		return err
+	}
+
+	log.Info().Msgf("updated %s from %s to %s", binPath, status.Current, status.Latest)
+	return nil
+}
+
+// Apply checks for a newer release and installs it over the binary at
+// binPath.
+func Apply(cfg *config.Instance, platform string, binPath string) (*Status, error) {
+	status, err := Check(cfg, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	err = Install(status, binPath)
+	if err != nil {
+		return status, err
+	}
+
+	return status, nil
+}
+
+// Rollback restores the binary replaced by the last update. The updated
+// binary becomes the backup, so a rollback can be undone the same way.
+func Rollback(binPath string) error {
+	oldPath := binPath + OldSuffix
+	if _, err := os.Stat(oldPath); err != nil {
+		return ErrNoRollback
+	}
+
+	tmpPath := binPath + newSuffix
+	err := os.Rename(oldPath, tmpPath)
+	if err != nil {
+		return fmt.Errorf("error preparing rollback: %w", err)
+	}
+
+	err = swap(binPath, tmpPath)
+	if err != nil {
+		_ = os.Rename(tmpPath, oldPath)
+		return err
+	}
+
+	log.Info().Msgf("rolled back %s to previous version", binPath)
+	return nil
+}
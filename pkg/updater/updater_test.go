@@ -0,0 +1,211 @@
+package updater
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+)
+
+// serveFeed serves a signed feed for a release of bin, with the checksum
+// given or, if it's empty, bin's checksum. Returns a config using the feed.
+func serveFeed(t *testing.T, version string, bin []byte, checksum string) *config.Instance {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if checksum == "" {
+		sum := sha256.Sum256(bin)
+		checksum = hex.EncodeToString(sum[:])
+	}
+
+	feed, err := json.Marshal(Release{
+		Version: version,
+		Assets: []Asset{
+			{Platform: "other", Url: "other.bin", Sha256: checksum},
+			{Platform: "test", Url: "zaparoo.bin", Sha256: checksum},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, feed))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/releases/feed.json", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(feed)
+	})
+	mux.HandleFunc("/releases/feed.json.sig", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(sig))
+	})
+	mux.HandleFunc("/releases/zaparoo.bin", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(bin)
+	})
+
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	defaults := config.BaseDefaults
+	defaults.Update = config.Update{
+		FeedUrl:   ts.URL + "/releases/feed.json",
+		PublicKey: base64.StdEncoding.EncodeToString(pub),
+	}
+
+	cfg, err := config.NewConfig(t.TempDir(), defaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cfg
+}
+
+func writeBin(t *testing.T, data string) string {
+	binPath := filepath.Join(t.TempDir(), "zaparoo.bin")
+	err := os.WriteFile(binPath, []byte(data), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return binPath
+}
+
+func readFile(t *testing.T, path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestInstallAndRollback(t *testing.T) {
+	cfg := serveFeed(t, "99.0.0", []byte("new version"), "")
+	binPath := writeBin(t, "old version")
+
+	status, err := Check(cfg, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !status.Available {
+		t.Fatal("expected update to be available")
+	}
+
+	if filepath.Base(status.Asset.Url) != "zaparoo.bin" {
+		t.Errorf("wrong asset for platform: %s", status.Asset.Url)
+	}
+
+	err = Install(status, binPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readFile(t, binPath); got != "new version" {
+		t.Errorf("binary not updated: %q", got)
+	}
+	if got := readFile(t, binPath+OldSuffix); got != "old version" {
+		t.Errorf("previous binary not kept: %q", got)
+	}
+
+	err = Rollback(binPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readFile(t, binPath); got != "old version" {
+		t.Errorf("binary not rolled back: %q", got)
+	}
+	if got := readFile(t, binPath+OldSuffix); got != "new version" {
+		t.Errorf("updated binary not kept: %q", got)
+	}
+}
+
+func TestInstallBadChecksum(t *testing.T) {
+	sum := sha256.Sum256([]byte("something else"))
+	cfg := serveFeed(t, "99.0.0", []byte("new version"), hex.EncodeToString(sum[:]))
+	binPath := writeBin(t, "old version")
+
+	status, err := Check(cfg, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Install(status, binPath)
+	if !errors.Is(err, ErrBadChecksum) {
+		t.Fatalf("expected checksum error, got: %v", err)
+	}
+
+	if got := readFile(t, binPath); got != "old version" {
+		t.Errorf("binary changed: %q", got)
+	}
+	if _, err := os.Stat(binPath + newSuffix); !os.IsNotExist(err) {
+		t.Error("download left behind")
+	}
+}
+
+func TestFetchReleaseBadSignature(t *testing.T) {
+	cfg := serveFeed(t, "99.0.0", []byte("new version"), "")
+
+	other, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = FetchRelease(cfg.Update().FeedUrl, base64.StdEncoding.EncodeToString(other))
+	if !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("expected signature error, got: %v", err)
+	}
+}
+
+func TestUpToDate(t *testing.T) {
+	cfg := serveFeed(t, config.AppVersion, []byte("same version"), "")
+	binPath := writeBin(t, "old version")
+
+	status, err := Check(cfg, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status.Available {
+		t.Error("same version shouldn't be available")
+	}
+
+	if err := Install(status, binPath); !errors.Is(err, ErrUpToDate) {
+		t.Errorf("expected up to date error, got: %v", err)
+	}
+
+	if err := Rollback(binPath); !errors.Is(err, ErrNoRollback) {
+		t.Errorf("expected no rollback error, got: %v", err)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2.2.0", "2.2.0", 0},
+		{"v2.2.0", "2.2.0", 0},
+		{"2.3.0", "2.2.0", 1},
+		{"2.10.0", "2.9.1", 1},
+		{"2.2", "2.2.1", -1},
+		{"2.2.0", "2.2.0-dev", 1},
+		{"2.2.0-beta", "2.2.0-alpha", 1},
+		{"2.1.9", "2.2.0-dev", -1},
+	}
+
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
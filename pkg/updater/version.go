@@ -0,0 +1,61 @@
+package updater
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parseVersion splits a version like "v2.2.0-dev" into its numbers and
+// pre-release label.
+func parseVersion(v string) ([]int, string) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	v, _, _ = strings.Cut(v, "+")
+	v, pre, _ := strings.Cut(v, "-")
+
+	var nums []int
+	for _, p := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			n = 0
+		}
+		nums = append(nums, n)
+	}
+
+	return nums, pre
+}
+
+// CompareVersions returns -1 if version a is older than b, 1 if it's newer
+// and 0 if they're the same. A pre-release is older than its release.
+func CompareVersions(a string, b string) int {
+	an, apre := parseVersion(a)
+	bn, bpre := parseVersion(b)
+
+	for i := 0; i < max(len(an), len(bn)); i++ {
+		var x, y int
+		if i < len(an) {
+			x = an[i]
+		}
+		if i < len(bn) {
+			y = bn[i]
+		}
+
+		if x < y {
+			return -1
+		} else if x > y {
+			return 1
+		}
+	}
+
+	switch {
+	case apre == bpre:
+		return 0
+	case apre == "":
+		return 1
+	case bpre == "":
+		return -1
+	case apre < bpre:
+		return -1
+	default:
+		return 1
+	}
+}
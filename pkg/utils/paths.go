@@ -3,8 +3,6 @@ package utils
 import (
 	"github.com/ZaparooProject/zaparoo-core/pkg/config"
 	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
-	"github.com/andygrunwald/vdf"
-	"github.com/rs/zerolog/log"
 	"os"
 	"path/filepath"
 	"strings"
@@ -75,7 +73,7 @@ func MatchSystemFile(
 	systemId string,
 	path string,
 ) bool {
-	for _, l := range pl.Launchers() {
+	for _, l := range pl.Launchers(cfg) {
 		if l.SystemId == systemId {
 			if PathIsLauncher(cfg, pl, l, path) {
 				return true
@@ -93,7 +91,7 @@ func PathToLaunchers(
 	path string,
 ) []platforms.Launcher {
 	var launchers []platforms.Launcher
-	for _, l := range pl.Launchers() {
+	for _, l := range pl.Launchers(cfg) {
 		if PathIsLauncher(cfg, pl, l, path) {
 			launchers = append(launchers, l)
 		}
@@ -101,75 +99,54 @@ func PathToLaunchers(
 	return launchers
 }
 
-func ExeDir() string {
-	exe, err := os.Executable()
+// inDir returns true if path is dir or anywhere inside it.
+func inDir(dir string, path string) bool {
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+
+	dir, err := filepath.Abs(dir)
 	if err != nil {
-		return ""
+		return false
 	}
 
-	return filepath.Dir(exe)
-}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
 
-func ScanSteamApps(steamDir string) ([]platforms.ScanResult, error) {
-	var results []platforms.ScanResult
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
 
-	f, err := os.Open(filepath.Join(steamDir, "libraryfolders.vdf"))
+// PathInDataOrRootDir returns true if the file at path, with any symlinks
+// resolved, is in the platform's data dir or one of its root dirs. It's
+// used to stop remote clients reading arbitrary files from the host.
+func PathInDataOrRootDir(cfg *config.Instance, pl platforms.Platform, path string) bool {
+	path, err := filepath.EvalSymlinks(path)
 	if err != nil {
-		log.Error().Err(err).Msg("error opening libraryfolders.vdf")
-		return results, nil
+		return false
 	}
 
-	p := vdf.NewParser(f)
-	m, err := p.Parse()
+	path, err = filepath.Abs(path)
 	if err != nil {
-		log.Error().Err(err).Msg("error parsing libraryfolders.vdf")
-		return results, nil
+		return false
 	}
 
-	lfs := m["libraryfolders"].(map[string]interface{})
-	for l, v := range lfs {
-		log.Debug().Msgf("library id: %s", l)
-		ls := v.(map[string]interface{})
-
-		libraryPath := ls["path"].(string)
-		steamApps, err := os.ReadDir(filepath.Join(libraryPath, "steamapps"))
-		if err != nil {
-			log.Error().Err(err).Msg("error listing steamapps folder")
-			continue
-		}
-
-		var manifestFiles []string
-		for _, mf := range steamApps {
-			if strings.HasPrefix(mf.Name(), "appmanifest_") {
-				manifestFiles = append(manifestFiles, filepath.Join(libraryPath, "steamapps", mf.Name()))
-			}
+	dirs := append([]string{pl.DataDir()}, pl.RootDirs(cfg)...)
+	for _, dir := range dirs {
+		if dir != "" && inDir(dir, path) {
+			return true
 		}
+	}
 
-		for _, mf := range manifestFiles {
-			log.Debug().Msgf("manifest file: %s", mf)
-
-			af, err := os.Open(mf)
-			if err != nil {
-				log.Error().Err(err).Msgf("error opening manifest: %s", mf)
-				return results, nil
-			}
-
-			ap := vdf.NewParser(af)
-			am, err := ap.Parse()
-			if err != nil {
-				log.Error().Err(err).Msgf("error parsing manifest: %s", mf)
-				return results, nil
-			}
-
-			appState := am["AppState"].(map[string]interface{})
-			log.Debug().Msgf("app name: %v", appState["name"])
+	return false
+}
 
-			results = append(results, platforms.ScanResult{
-				Path: "steam://" + appState["appid"].(string),
-				Name: appState["name"].(string),
-			})
-		}
+func ExeDir() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
 	}
 
-	return results, nil
+	return filepath.Dir(exe)
 }
@@ -0,0 +1,328 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+	"github.com/andygrunwald/vdf"
+	"github.com/rs/zerolog/log"
+)
+
+// Apps installed by Steam which aren't games.
+var steamToolIds = map[string]bool{
+	"228980":  true, // Steamworks Common Redistributables
+	"1070560": true, // Steam Linux Runtime 1.0 (scout)
+	"1391110": true, // Steam Linux Runtime 2.0 (soldier)
+	"1628350": true, // Steam Linux Runtime 3.0 (sniper)
+	"1493710": true, // Proton Experimental
+	"2180100": true, // Proton Hotfix
+	"1887720": true, // Proton EasyAntiCheat Runtime
+	"1826330": true, // Proton BattlEye Runtime
+}
+
+var steamToolPrefixes = []string{
+	"Proton ",
+	"Steam Linux Runtime",
+	"Steamworks ",
+}
+
+// Set in an app manifest's StateFlags when the app is fully installed.
+const steamStateFullyInstalled = 4
+
+type SteamApp struct {
+	AppId     string
+	Name      string
+	Installed bool
+}
+
+// IsTool returns true if the app is a compatibility tool or runtime rather
+// than a game.
+func (a SteamApp) IsTool() bool {
+	if steamToolIds[a.AppId] {
+		return true
+	}
+
+	for _, prefix := range steamToolPrefixes {
+		if strings.HasPrefix(a.Name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func readVdf(path string) (map[string]interface{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+
+	return vdf.NewParser(f).Parse()
+}
+
+// lookupKey finds a key in a parsed VDF map. Key case isn't consistent
+// between Steam versions.
+func lookupKey(m map[string]interface{}, key string) (interface{}, bool) {
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func lookupString(m map[string]interface{}, key string) string {
+	v, _ := lookupKey(m, key)
+	s, _ := v.(string)
+	return s
+}
+
+// SteamLibraries returns the folders of every Steam library, such as ones
+// on SD cards or other drives, starting with the one in the Steam install.
+func SteamLibraries(steamDir string) ([]string, error) {
+	libraries := []string{steamDir}
+	seen := map[string]bool{filepath.Clean(steamDir): true}
+
+	m, err := readVdf(filepath.Join(steamDir, "steamapps", "libraryfolders.vdf"))
+	if err != nil {
+		return libraries, err
+	}
+
+	v, ok := lookupKey(m, "libraryfolders")
+	if !ok {
+		return libraries, errors.New("libraryfolders not found in vdf")
+	}
+	lfs, _ := v.(map[string]interface{})
+
+	for _, lf := range lfs {
+		var path string
+		switch lv := lf.(type) {
+		case map[string]interface{}:
+			path = lookupString(lv, "path")
+		case string:
+			// older format has the path as the value, alongside other
+			// keys which aren't libraries
+			if strings.ContainsAny(lv, `/\`) {
+				path = lv
+			}
+		}
+
+		if path == "" || seen[filepath.Clean(path)] {
+			continue
+		}
+		seen[filepath.Clean(path)] = true
+		libraries = append(libraries, path)
+	}
+
+	return libraries, nil
+}
+
+// ReadSteamAppManifest reads an appmanifest_<id>.acf file.
+func ReadSteamAppManifest(path string) (SteamApp, error) {
+	var app SteamApp
+
+	m, err := readVdf(path)
+	if err != nil {
+		return app, err
+	}
+
+	v, ok := lookupKey(m, "AppState")
+	if !ok {
+		return app, errors.New("AppState not found in manifest")
+	}
+	state, _ := v.(map[string]interface{})
+
+	app.AppId = lookupString(state, "appid")
+	app.Name = lookupString(state, "name")
+	if app.AppId == "" {
+		return app, errors.New("appid not found in manifest")
+	}
+
+	flags, err := strconv.Atoi(lookupString(state, "StateFlags"))
+	app.Installed = err == nil && flags&steamStateFullyInstalled != 0
+
+	return app, nil
+}
+
+// SteamApps returns the apps in a library's steamapps folder.
+func SteamApps(libraryPath string) ([]SteamApp, error) {
+	dir := filepath.Join(libraryPath, "steamapps")
+	manifests, err := filepath.Glob(filepath.Join(dir, "appmanifest_*.acf"))
+	if err != nil {
+		return nil, err
+	}
+
+	var apps []SteamApp
+	for _, mf := range manifests {
+		app, err := ReadSteamAppManifest(mf)
+		if err != nil {
+			log.Error().Err(err).Msgf("error reading manifest: %s", mf)
+			continue
+		}
+		apps = append(apps, app)
+	}
+
+	return apps, nil
+}
+
+// Binary VDF value types.
+const (
+	bvdfMap    = 0x00
+	bvdfString = 0x01
+	bvdfInt    = 0x02
+	bvdfEnd    = 0x08
+)
+
+func readCString(r *bufio.Reader) (string, error) {
+	s, err := r.ReadString(0)
+	if err != nil {
+		return "", err
+	}
+	return s[:len(s)-1], nil
+}
+
+// readBinaryVdf reads a map from a binary VDF file, as used by
+// shortcuts.vdf. Int values are returned as uint32.
+func readBinaryVdf(r *bufio.Reader) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+
+	for {
+		t, err := r.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return m, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		if t == bvdfEnd {
+			return m, nil
+		}
+
+		key, err := readCString(r)
+		if err != nil {
+			return nil, err
+		}
+
+		switch t {
+		case bvdfMap:
+			m[key], err = readBinaryVdf(r)
+		case bvdfString:
+			m[key], err = readCString(r)
+		case bvdfInt:
+			var i uint32
+			err = binary.Read(r, binary.LittleEndian, &i)
+			m[key] = i
+		default:
+			return nil, fmt.Errorf("unknown binary vdf type: %d", t)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// SteamShortcutGameId returns the game ID Steam uses to launch a non-Steam
+// shortcut from its app ID.
+func SteamShortcutGameId(appId uint32) uint64 {
+	return uint64(appId)<<32 | 0x02000000
+}
+
+// SteamShortcuts returns the non-Steam games added to Steam by every user.
+func SteamShortcuts(steamDir string) ([]platforms.ScanResult, error) {
+	paths, err := filepath.Glob(filepath.Join(steamDir, "userdata", "*", "config", "shortcuts.vdf"))
+	if err != nil {
+		return nil, err
+	}
+
+	var results []platforms.ScanResult
+	seen := make(map[uint32]bool)
+
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Error().Err(err).Msgf("error opening shortcuts: %s", path)
+			continue
+		}
+
+		m, err := readBinaryVdf(bufio.NewReader(f))
+		_ = f.Close()
+		if err != nil {
+			log.Error().Err(err).Msgf("error parsing shortcuts: %s", path)
+			continue
+		}
+
+		v, _ := lookupKey(m, "shortcuts")
+		shortcuts, _ := v.(map[string]interface{})
+		for _, sv := range shortcuts {
+			s, ok := sv.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			av, _ := lookupKey(s, "appid")
+			appId, ok := av.(uint32)
+			name := lookupString(s, "AppName")
+			if !ok || name == "" || seen[appId] {
+				continue
+			}
+			seen[appId] = true
+
+			results = append(results, platforms.ScanResult{
+				Path: "steam://rungameid/" + strconv.FormatUint(SteamShortcutGameId(appId), 10),
+				Name: name,
+			})
+		}
+	}
+
+	return results, nil
+}
+
+// ScanSteamApps returns the installed games from every Steam library and
+// the user's non-Steam shortcuts, given the folder Steam is installed in.
+func ScanSteamApps(steamDir string) ([]platforms.ScanResult, error) {
+	var results []platforms.ScanResult
+
+	libraries, err := SteamLibraries(steamDir)
+	if err != nil {
+		log.Warn().Err(err).Msg("error reading steam libraries")
+	}
+
+	seen := make(map[string]bool)
+	for _, library := range libraries {
+		apps, err := SteamApps(library)
+		if err != nil {
+			log.Error().Err(err).Msgf("error reading steam library: %s", library)
+			continue
+		}
+
+		for _, app := range apps {
+			if !app.Installed || app.IsTool() || seen[app.AppId] {
+				continue
+			}
+			seen[app.AppId] = true
+
+			results = append(results, platforms.ScanResult{
+				Path: "steam://" + app.AppId,
+				Name: app.Name,
+			})
+		}
+	}
+
+	shortcuts, err := SteamShortcuts(steamDir)
+	if err != nil {
+		log.Error().Err(err).Msg("error reading steam shortcuts")
+	}
+
+	return append(results, shortcuts...), nil
+}
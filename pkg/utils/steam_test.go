@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+)
+
+func writeTestFile(t *testing.T, path string, data []byte) {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testManifest(appId string, name string, flags int) []byte {
+	return []byte(`"AppState"
+{
+	"appid"		"` + appId + `"
+	"name"		"` + name + `"
+	"StateFlags"		"` + strconv.Itoa(flags) + `"
+}
+`)
+}
+
+func testShortcuts(appId uint32, name string) []byte {
+	var b bytes.Buffer
+	b.WriteByte(bvdfMap)
+	b.WriteString("shortcuts\x00")
+	b.WriteByte(bvdfMap)
+	b.WriteString("0\x00")
+	b.WriteByte(bvdfInt)
+	b.WriteString("appid\x00")
+	_ = binary.Write(&b, binary.LittleEndian, appId)
+	b.WriteByte(bvdfString)
+	b.WriteString("AppName\x00" + name + "\x00")
+	b.WriteByte(bvdfString)
+	b.WriteString("Exe\x00\"/usr/bin/game\"\x00")
+	b.WriteByte(bvdfEnd)
+	b.WriteByte(bvdfEnd)
+	b.WriteByte(bvdfEnd)
+	return b.Bytes()
+}
+
+func TestScanSteamApps(t *testing.T) {
+	steamDir := t.TempDir()
+	sdCard := t.TempDir()
+
+	writeTestFile(t, filepath.Join(steamDir, "steamapps", "libraryfolders.vdf"), []byte(`"libraryfolders"
+{
+	"0"
+	{
+		"path"		"`+steamDir+`"
+	}
+	"1"
+	{
+		"path"		"`+sdCard+`"
+	}
+}
+`))
+
+	apps := filepath.Join(steamDir, "steamapps")
+	writeTestFile(t, filepath.Join(apps, "appmanifest_10.acf"), testManifest("10", "Counter-Strike", 4))
+	writeTestFile(t, filepath.Join(apps, "appmanifest_20.acf"), testManifest("20", "Half-Life", 1026))
+	writeTestFile(t, filepath.Join(apps, "appmanifest_1493710.acf"), testManifest("1493710", "Proton Experimental", 4))
+	writeTestFile(t, filepath.Join(apps, "appmanifest_2805730.acf"), testManifest("2805730", "Proton 9.0", 4))
+	writeTestFile(t, filepath.Join(sdCard, "steamapps", "appmanifest_70.acf"), testManifest("70", "Half-Life 2", 6))
+
+	writeTestFile(t, filepath.Join(steamDir, "userdata", "1234", "config", "shortcuts.vdf"),
+		testShortcuts(3000000000, "My Emulator"))
+
+	results, err := ScanSteamApps(steamDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Path < results[j].Path
+	})
+
+	want := []platforms.ScanResult{
+		{Path: "steam://10", Name: "Counter-Strike"},
+		{Path: "steam://70", Name: "Half-Life 2"},
+		{Path: "steam://rungameid/12884901888033554432", Name: "My Emulator"},
+	}
+
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("got %+v, want %+v", results, want)
+	}
+}
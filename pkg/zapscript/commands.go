@@ -49,6 +49,18 @@ var commandMappings = map[string]func(platforms.Platform, platforms.CmdEnv) erro
 	"playlist.play":     cmdPlaylistPlay,
 	"playlist.next":     cmdPlaylistNext,
 	"playlist.previous": cmdPlaylistPrevious,
+	"playlist.stop":     cmdPlaylistStop,
+	"playlist.goto":     cmdPlaylistGoto,
+
+	"profile.switch": cmdProfileSwitch,
+
+	"emulator.savestate":  cmdEmulator(platforms.EmulatorSaveState),
+	"emulator.loadstate":  cmdEmulator(platforms.EmulatorLoadState),
+	"emulator.reset":      cmdEmulator(platforms.EmulatorReset),
+	"emulator.pause":      cmdEmulator(platforms.EmulatorPause),
+	"emulator.screenshot": cmdEmulator(platforms.EmulatorScreenshot),
+
+	"sound.play": cmdSoundPlay,
 
 	"execute": cmdExecute,
 	"delay":   cmdDelay,
@@ -88,15 +100,25 @@ var softwareChangeCommands = []string{
 	"mister.mgl",
 }
 
+// Commands playlist items are allowed to run. Items can only launch media,
+// the same as an item which is a plain path.
+var playlistCommands = []string{
+	"launch",
+	"launch.system",
+	"launch.random",
+	"launch.search",
+}
+
 func forwardCmd(pl platforms.Platform, env platforms.CmdEnv) error {
 	return pl.ForwardCmd(env)
 }
 
-// Check all games folders for a relative path to a file
-func findFile(pl platforms.Platform, cfg *config.Instance, path string) (string, error) {
+// Check all games folders for a relative path to a file. The paths checked
+// are returned for error reporting.
+func findFile(pl platforms.Platform, cfg *config.Instance, path string) (string, []string, error) {
 	// TODO: can do basic file exists check here too
 	if filepath.IsAbs(path) {
-		return path, nil
+		return path, nil, nil
 	}
 
 	ps := strings.Split(path, string(filepath.Separator))
@@ -115,15 +137,17 @@ func findFile(pl platforms.Platform, cfg *config.Instance, path string) (string,
 		}
 	}
 
+	var tried []string
 	for _, gf := range pl.RootDirs(cfg) {
 		fullPath := filepath.Join(gf, statPath)
 		if _, err := os.Stat(fullPath); err == nil {
 			log.Debug().Msgf("found file: %s", fullPath)
-			return filepath.Join(gf, path), nil
+			return filepath.Join(gf, path), nil, nil
 		}
+		tried = append(tried, fullPath)
 	}
 
-	return path, fmt.Errorf("file not found: %s", path)
+	return path, tried, fmt.Errorf("file not found: %s", path)
 }
 
 // LaunchToken parses and runs a single ZapScript command. Returns true if
@@ -142,12 +166,12 @@ func LaunchToken(
 	if i := strings.LastIndex(text, "?"); i != -1 {
 		u, err := url.Parse(text[i:])
 		if err != nil {
-			return err, false
+			return tokens.AsLaunchError(err, tokens.ErrCodeInvalidArgs, tokens.StageParse), false
 		}
 
 		qs, err := url.ParseQuery(u.RawQuery)
 		if err != nil {
-			return err, false
+			return tokens.AsLaunchError(err, tokens.ErrCodeInvalidArgs, tokens.StageParse), false
 		}
 
 		text = text[:i]
@@ -160,19 +184,30 @@ func LaunchToken(
 
 	// explicit commands must begin with **
 	if strings.HasPrefix(text, "**") {
-		if t.Source == tokens.SourcePlaylist {
-			log.Debug().Str("text", text).Msgf("playlists cannot run commands, skipping")
-			return nil, false
-		}
-
 		text = strings.TrimPrefix(text, "**")
 		ps := strings.SplitN(text, ":", 2)
 		if len(ps) < 2 {
-			return fmt.Errorf("invalid command: %s", text), false
+			return tokens.NewLaunchError(
+				tokens.ErrCodeInvalidCommand,
+				tokens.StageParse,
+				"invalid command: %s",
+				text,
+			), false
 		}
 
 		cmd, args := strings.ToLower(strings.TrimSpace(ps[0])), strings.TrimSpace(ps[1])
 
+		if t.Source == tokens.SourcePlaylist && !slices.Contains(playlistCommands, cmd) {
+			le := tokens.NewLaunchError(
+				tokens.ErrCodeInvalidCommand,
+				tokens.StageParse,
+				"playlists can only run launch commands: %s",
+				cmd,
+			)
+			le.Command = cmd
+			return le, false
+		}
+
 		env := platforms.CmdEnv{
 			Cmd:           cmd,
 			Args:          args,
@@ -183,39 +218,65 @@ func LaunchToken(
 			Text:          text,
 			TotalCommands: totalCommands,
 			CurrentIndex:  currentIndex,
+			Hook:          t.Source == tokens.SourceHook,
+			Remote:        t.Remote,
 		}
 
 		if f, ok := commandMappings[cmd]; ok {
 			log.Info().Msgf("launching command: %s", cmd)
 
 			softwareChange := slices.Contains(softwareChangeCommands, cmd)
-			if softwareChange {
+			if softwareChange && t.Source != tokens.SourcePlaylist && t.Source != tokens.SourceHook {
 				// a launch triggered outside a playlist itself
 				log.Debug().Msg("clearing current playlist")
 				plsc.Queue <- nil
 			}
 
-			return f(pl, env), softwareChange
+			return commandError(f(pl, env), cmd), softwareChange
 		} else {
-			return fmt.Errorf("unknown command: %s", cmd), false
+			le := tokens.NewLaunchError(
+				tokens.ErrCodeUnknownCommand,
+				tokens.StageParse,
+				"unknown command: %s",
+				cmd,
+			)
+			le.Command = cmd
+			return le, false
 		}
 	}
 
-	if t.Source != tokens.SourcePlaylist {
+	if t.Source != tokens.SourcePlaylist && t.Source != tokens.SourceHook {
 		// a launch triggered outside a playlist itself
 		log.Debug().Msg("clearing current playlist")
 		plsc.Queue <- nil
 	}
 
 	// if it's not a command, treat it as a generic launch command
-	return cmdLaunch(pl, platforms.CmdEnv{
+	return commandError(cmdLaunch(pl, platforms.CmdEnv{
 		Cmd:           "launch",
 		Args:          text,
 		NamedArgs:     namedArgs,
 		Cfg:           cfg,
+		Playlist:      plsc,
 		Manual:        manual,
 		Text:          text,
 		TotalCommands: totalCommands,
 		CurrentIndex:  currentIndex,
-	}), true
+		Hook:          t.Source == tokens.SourceHook,
+		Remote:        t.Remote,
+	}), "launch"), true
+}
+
+// commandError makes an error returned by a command a launch error, noting
+// which command it came from.
+func commandError(err error, cmd string) error {
+	if err == nil {
+		return nil
+	}
+
+	le := tokens.AsLaunchError(err, tokens.ErrCodeCommandFailed, tokens.StageRun)
+	if le.Command == "" {
+		le.Command = cmd
+	}
+	return le
 }
@@ -0,0 +1,30 @@
+package zapscript
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/playlists"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/tokens"
+)
+
+func TestLaunchTokenPlaylistCommand(t *testing.T) {
+	token := tokens.Token{Source: tokens.SourcePlaylist}
+	text := "**execute:rm -rf /"
+
+	err, launched := LaunchToken(nil, nil, playlists.PlaylistController{}, token, false, text, 1, 0)
+	if launched {
+		t.Error("command shouldn't have launched media")
+	}
+
+	var le *tokens.LaunchError
+	if !errors.As(err, &le) {
+		t.Fatalf("expected launch error, got: %v", err)
+	}
+	if le.Code != tokens.ErrCodeInvalidCommand || le.Stage != tokens.StageParse {
+		t.Errorf("wrong code or stage: %s, %s", le.Code, le.Stage)
+	}
+	if le.Command != "execute" {
+		t.Errorf("wrong command: %s", le.Command)
+	}
+}
@@ -0,0 +1,35 @@
+package zapscript
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+)
+
+// cmdEmulator runs an operation on the running emulator. Save state
+// commands take an optional slot number as their argument.
+func cmdEmulator(op platforms.EmulatorOp) func(platforms.Platform, platforms.CmdEnv) error {
+	return func(pl platforms.Platform, env platforms.CmdEnv) error {
+		slot := 0
+
+		arg := strings.TrimSpace(env.Args)
+		if arg != "" {
+			if op != platforms.EmulatorSaveState && op != platforms.EmulatorLoadState {
+				return fmt.Errorf("%s takes no arguments", env.Cmd)
+			}
+
+			var err error
+			slot, err = strconv.Atoi(arg)
+			if err != nil || slot < 0 {
+				return fmt.Errorf("invalid save state slot: %s", arg)
+			}
+		}
+
+		log.Info().Msgf("emulator %s, slot: %d", op, slot)
+		return platforms.ControlEmulator(pl, env.Cfg, op, slot)
+	}
+}
@@ -0,0 +1,85 @@
+package zapscript
+
+import (
+	"errors"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/database/gamesdb"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/tokens"
+)
+
+// Most similar names suggested when a launch finds nothing.
+const maxSuggestions = 5
+
+// suggestNames returns indexed games similar to the query, as ZapScript
+// which would launch them.
+func suggestNames(pl platforms.Platform, systems []gamesdb.System, query string) []string {
+	res, err := gamesdb.SimilarNames(pl, systems, query, maxSuggestions)
+	if err != nil {
+		log.Debug().Err(err).Msgf("error finding names similar to: %s", query)
+		return nil
+	}
+
+	names := make([]string, 0, len(res))
+	for _, r := range res {
+		names = append(names, r.SystemId+"/"+r.Name)
+	}
+
+	return names
+}
+
+func systemIds(systems []gamesdb.System) []string {
+	ids := make([]string, 0, len(systems))
+	for _, s := range systems {
+		ids = append(ids, s.Id)
+	}
+	return ids
+}
+
+// errNoResults is the error for a search of the games index which found
+// nothing, with the systems searched and suggestions of what was meant.
+func errNoResults(pl platforms.Platform, systems []gamesdb.System, query string) error {
+	le := tokens.NewLaunchError(
+		tokens.ErrCodeNoResults,
+		tokens.StageResolve,
+		"no results found for: %s",
+		query,
+	)
+	le.Candidates = systemIds(systems)
+	le.Suggestions = suggestNames(pl, systems, query)
+	return le
+}
+
+// errSearch is the error for a failed search of the games index.
+func errSearch(err error) error {
+	if errors.Is(err, gamesdb.ErrNotExist) {
+		return &tokens.LaunchError{
+			Code:    tokens.ErrCodeNoIndex,
+			Stage:   tokens.StageResolve,
+			Message: "media has not been indexed",
+			Err:     err,
+		}
+	}
+	return tokens.AsLaunchError(err, tokens.ErrCodeCommandFailed, tokens.StageResolve)
+}
+
+// errLaunch is the error for a launcher which failed to start the media at
+// a path.
+func errLaunch(err error, path string) error {
+	if err == nil {
+		return nil
+	}
+
+	le := tokens.AsLaunchError(err, tokens.ErrCodeLaunchFailed, tokens.StageLaunch)
+	if len(le.Candidates) == 0 {
+		le.Candidates = []string{path}
+	}
+	return le
+}
+
+// errSystem is the error for a system ID which couldn't be looked up.
+func errSystem(err error) error {
+	return tokens.AsLaunchError(err, tokens.ErrCodeSystemNotFound, tokens.StageResolve)
+}
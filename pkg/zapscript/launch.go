@@ -6,12 +6,16 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
 	"github.com/ZaparooProject/zaparoo-core/pkg/database/gamesdb"
 	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/tokens"
 	"github.com/ZaparooProject/zaparoo-core/pkg/utils"
 )
 
@@ -27,7 +31,7 @@ func cmdSystem(pl platforms.Platform, env platforms.CmdEnv) error {
 
 func cmdRandom(pl platforms.Platform, env platforms.CmdEnv) error {
 	if env.Args == "" {
-		return fmt.Errorf("no system specified")
+		return tokens.NewLaunchError(tokens.ErrCodeInvalidArgs, tokens.StageParse, "no system specified")
 	}
 
 	launch, err := getAltLauncher(pl, env)
@@ -38,7 +42,7 @@ func cmdRandom(pl platforms.Platform, env platforms.CmdEnv) error {
 	if env.Args == "all" {
 		game, err := gamesdb.RandomGame(pl, gamesdb.AllSystems())
 		if err != nil {
-			return err
+			return errSearch(err)
 		}
 
 		return launch(game.Path)
@@ -49,7 +53,9 @@ func cmdRandom(pl platforms.Platform, env platforms.CmdEnv) error {
 	// TODO: doesn't filter on extensions
 	if filepath.IsAbs(env.Args) {
 		if _, err := os.Stat(env.Args); err != nil {
-			return err
+			le := tokens.AsLaunchError(err, tokens.ErrCodeFileNotFound, tokens.StageResolve)
+			le.Candidates = []string{env.Args}
+			return le
 		}
 
 		files, err := filepath.Glob(filepath.Join(env.Args, "*"))
@@ -58,7 +64,14 @@ func cmdRandom(pl platforms.Platform, env platforms.CmdEnv) error {
 		}
 
 		if len(files) == 0 {
-			return fmt.Errorf("no files found in: %s", env.Args)
+			le := tokens.NewLaunchError(
+				tokens.ErrCodeFileNotFound,
+				tokens.StageResolve,
+				"no files found in: %s",
+				env.Args,
+			)
+			le.Candidates = []string{env.Args}
+			return le
 		}
 
 		file, err := utils.RandomElem(files)
@@ -77,20 +90,26 @@ func cmdRandom(pl platforms.Platform, env platforms.CmdEnv) error {
 
 		system, err := gamesdb.LookupSystem(systemId)
 		if err != nil {
-			return err
+			return errSystem(err)
 		} else if system == nil {
-			return fmt.Errorf("system not found: %s", systemId)
+			return tokens.NewLaunchError(
+				tokens.ErrCodeSystemNotFound,
+				tokens.StageResolve,
+				"system not found: %s",
+				systemId,
+			)
 		}
 
 		query = strings.ToLower(query)
 
-		res, err := gamesdb.SearchNamesGlob(pl, []gamesdb.System{*system}, query)
+		systems := []gamesdb.System{*system}
+		res, err := gamesdb.SearchNamesGlob(pl, systems, query)
 		if err != nil {
-			return err
+			return errSearch(err)
 		}
 
 		if len(res) == 0 {
-			return fmt.Errorf("no results found for: %s", query)
+			return errNoResults(pl, systems, query)
 		}
 
 		game, err := utils.RandomElem(res)
@@ -116,12 +135,92 @@ func cmdRandom(pl platforms.Platform, env platforms.CmdEnv) error {
 
 	game, err := gamesdb.RandomGame(pl, systems)
 	if err != nil {
-		return err
+		return errSearch(err)
 	}
 
 	return launch(game.Path)
 }
 
+// Hooks run commands through LaunchToken, which is set in init to avoid an
+// initialization cycle with commandMappings.
+var runHookCmd func(
+	pl platforms.Platform,
+	cfg *config.Instance,
+	plsc playlists.PlaylistController,
+	t tokens.Token,
+	manual bool,
+	text string,
+	totalCommands int,
+	currentIndex int,
+) (error, bool)
+
+func init() {
+	runHookCmd = LaunchToken
+}
+
+// runHook runs the ZapScript of a launch hook.
+func runHook(pl platforms.Platform, env platforms.CmdEnv, text string) error {
+	t := tokens.Token{
+		Text:     text,
+		ScanTime: time.Now(),
+		Source:   tokens.SourceHook,
+	}
+
+	cmds := strings.Split(text, "||")
+	for i, cmd := range cmds {
+		err, _ := runHookCmd(pl, env.Cfg, env.Playlist, t, false, cmd, len(cmds), i)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// withPreLaunch wraps a launch function to first run the pre-launch hook
+// configured for the launcher, or the launcher which would be picked for
+// the path. The launch is cancelled if the hook fails.
+func withPreLaunch(
+	pl platforms.Platform,
+	env platforms.CmdEnv,
+	launcher *platforms.Launcher,
+	launch func(args string) error,
+) func(args string) error {
+	return func(args string) error {
+		if env.Hook {
+			return launch(args)
+		}
+
+		l := launcher
+		if l == nil {
+			launchers := utils.PathToLaunchers(env.Cfg, pl, args)
+			if len(launchers) > 0 {
+				l = &launchers[0]
+			}
+		}
+
+		if l != nil {
+			opts := env.Cfg.LookupLaunchOptions(l.Id, l.SystemId)
+			if opts.PreLaunch != "" {
+				log.Info().Msgf("running pre-launch hook: %s", opts.PreLaunch)
+				err := runHook(pl, env, opts.PreLaunch)
+				if err != nil {
+					log.Error().Err(err).Msg("error running pre-launch hook, not launching")
+					return &tokens.LaunchError{
+						Code:       tokens.ErrCodeHookFailed,
+						Stage:      tokens.StageLaunch,
+						Message:    "pre-launch hook failed: " + err.Error(),
+						Candidates: []string{args},
+						Err:        err,
+					}
+				}
+			}
+		}
+
+		return launch(args)
+	}
+}
+
 func getAltLauncher(
 	pl platforms.Platform,
 	env platforms.CmdEnv,
@@ -129,7 +228,7 @@ func getAltLauncher(
 	if env.NamedArgs["launcher"] != "" {
 		var launcher platforms.Launcher
 
-		for _, l := range pl.Launchers() {
+		for _, l := range pl.Launchers(env.Cfg) {
 			if l.Id == env.NamedArgs["launcher"] {
 				launcher = l
 				break
@@ -137,18 +236,23 @@ func getAltLauncher(
 		}
 
 		if launcher.Launch == nil {
-			return nil, fmt.Errorf("alt launcher not found: %s", env.NamedArgs["launcher"])
+			return nil, tokens.NewLaunchError(
+				tokens.ErrCodeLauncherMissing,
+				tokens.StageResolve,
+				"alt launcher not found: %s",
+				env.NamedArgs["launcher"],
+			)
 		}
 
 		log.Info().Msgf("launching with alt launcher: %s", env.NamedArgs["launcher"])
 
-		return func(args string) error {
-			return launcher.Launch(env.Cfg, args)
-		}, nil
+		return withPreLaunch(pl, env, &launcher, func(args string) error {
+			return errLaunch(launcher.Launch(env.Cfg, args), args)
+		}), nil
 	} else {
-		return func(args string) error {
-			return pl.LaunchFile(env.Cfg, args)
-		}, nil
+		return withPreLaunch(pl, env, nil, func(args string) error {
+			return errLaunch(pl.LaunchFile(env.Cfg, args), args)
+		}), nil
 	}
 }
 
@@ -174,7 +278,8 @@ func cmdLaunch(pl platforms.Platform, env platforms.CmdEnv) error {
 
 	// for relative paths, perform a basic check if the file exists in a games folder
 	// this always takes precedence over the system/path format (but is not totally cross platform)
-	if p, err := findFile(pl, env.Cfg, env.Args); err == nil {
+	p, candidates, err := findFile(pl, env.Cfg, env.Args)
+	if err == nil {
 		log.Debug().Msgf("launching found relative path: %s", p)
 		return launch(p)
 	} else {
@@ -184,20 +289,27 @@ func cmdLaunch(pl platforms.Platform, env platforms.CmdEnv) error {
 	// attempt to parse the <system>/<path> format
 	ps := strings.SplitN(env.Text, "/", 2)
 	if len(ps) < 2 {
-		return fmt.Errorf("invalid launch format: %s", env.Text)
+		le := tokens.NewLaunchError(
+			tokens.ErrCodeInvalidArgs,
+			tokens.StageParse,
+			"invalid launch format: %s",
+			env.Text,
+		)
+		le.Candidates = candidates
+		return le
 	}
 
 	systemId, path := ps[0], ps[1]
 
 	system, err := gamesdb.LookupSystem(systemId)
 	if err != nil {
-		return err
+		return errSystem(err)
 	}
 
 	log.Info().Msgf("launching system: %s, path: %s", systemId, path)
 
 	var launchers []platforms.Launcher
-	for _, l := range pl.Launchers() {
+	for _, l := range pl.Launchers(env.Cfg) {
 		if l.SystemId == system.Id {
 			launchers = append(launchers, l)
 		}
@@ -214,14 +326,18 @@ func cmdLaunch(pl platforms.Platform, env platforms.CmdEnv) error {
 
 	for _, f := range folders {
 		systemPath := filepath.Join(f, path)
-		if fp, err := findFile(pl, env.Cfg, systemPath); err == nil {
+		fp, tried, err := findFile(pl, env.Cfg, systemPath)
+		if err == nil {
 			log.Debug().Msgf("launching found system path: %s", fp)
 			return launch(fp)
 		} else {
 			log.Debug().Err(err).Msgf("error finding system file: %s", path)
 		}
+		candidates = append(candidates, tried...)
 	}
 
+	systems := []gamesdb.System{*system}
+
 	// search if the path contains no / or file extensions
 	if !strings.Contains(path, "/") && filepath.Ext(path) == "" {
 		if strings.Contains(path, "*") {
@@ -233,14 +349,14 @@ func cmdLaunch(pl platforms.Platform, env platforms.CmdEnv) error {
 			// treat as a direct title launch
 			res, err := gamesdb.SearchNamesExact(
 				pl,
-				[]gamesdb.System{*system},
+				systems,
 				path,
 			)
 
 			if err != nil {
-				return err
+				return errSearch(err)
 			} else if len(res) == 0 {
-				return fmt.Errorf("no results found for: %s", path)
+				return errNoResults(pl, systems, path)
 			}
 
 			log.Info().Msgf("found result: %s", res[0].Path)
@@ -250,12 +366,21 @@ func cmdLaunch(pl platforms.Platform, env platforms.CmdEnv) error {
 		}
 	}
 
-	return fmt.Errorf("file not found: %s", env.Args)
+	le := tokens.NewLaunchError(
+		tokens.ErrCodeFileNotFound,
+		tokens.StageResolve,
+		"file not found: %s",
+		env.Args,
+	)
+	le.Candidates = candidates
+	name := filepath.Base(path)
+	le.Suggestions = suggestNames(pl, systems, strings.TrimSuffix(name, filepath.Ext(name)))
+	return le
 }
 
 func cmdSearch(pl platforms.Platform, env platforms.CmdEnv) error {
 	if env.Args == "" {
-		return fmt.Errorf("no query specified")
+		return tokens.NewLaunchError(tokens.ErrCodeInvalidArgs, tokens.StageParse, "no query specified")
 	}
 
 	launch, err := getAltLauncher(pl, env)
@@ -268,13 +393,14 @@ func cmdSearch(pl platforms.Platform, env platforms.CmdEnv) error {
 
 	if !strings.Contains(env.Args, "/") {
 		// search all systems
-		res, err := gamesdb.SearchNamesGlob(pl, gamesdb.AllSystems(), query)
+		systems := gamesdb.AllSystems()
+		res, err := gamesdb.SearchNamesGlob(pl, systems, query)
 		if err != nil {
-			return err
+			return errSearch(err)
 		}
 
 		if len(res) == 0 {
-			return fmt.Errorf("no results found for: %s", query)
+			return errNoResults(pl, systems, query)
 		}
 
 		return launch(res[0].Path)
@@ -282,13 +408,18 @@ func cmdSearch(pl platforms.Platform, env platforms.CmdEnv) error {
 
 	ps := strings.SplitN(query, "/", 2)
 	if len(ps) < 2 {
-		return fmt.Errorf("invalid search format: %s", query)
+		return tokens.NewLaunchError(
+			tokens.ErrCodeInvalidArgs,
+			tokens.StageParse,
+			"invalid search format: %s",
+			query,
+		)
 	}
 
 	systemId, query := ps[0], ps[1]
 
 	if query == "" {
-		return fmt.Errorf("no query specified")
+		return tokens.NewLaunchError(tokens.ErrCodeInvalidArgs, tokens.StageParse, "no query specified")
 	}
 
 	systems := make([]gamesdb.System, 0)
@@ -298,7 +429,7 @@ func cmdSearch(pl platforms.Platform, env platforms.CmdEnv) error {
 	} else {
 		system, err := gamesdb.LookupSystem(systemId)
 		if err != nil {
-			return err
+			return errSystem(err)
 		}
 
 		systems = append(systems, *system)
@@ -306,56 +437,91 @@ func cmdSearch(pl platforms.Platform, env platforms.CmdEnv) error {
 
 	res, err := gamesdb.SearchNamesGlob(pl, systems, query)
 	if err != nil {
-		return err
+		return errSearch(err)
 	}
 
 	if len(res) == 0 {
-		return fmt.Errorf("no results found for: %s", query)
+		return errNoResults(pl, systems, query)
 	}
 
 	return launch(res[0].Path)
 }
 
-func cmdPlaylistPlay(_ platforms.Platform, env platforms.CmdEnv) error {
+func cmdPlaylistPlay(pl platforms.Platform, env platforms.CmdEnv) error {
 	if env.Args == "" {
-		return fmt.Errorf("no playlist path specified")
+		return fmt.Errorf("no playlist path or name specified")
+	}
+
+	var pls *playlists.Playlist
+	var err error
+	if _, statErr := os.Stat(env.Args); statErr == nil || env.Playlist.Store == nil {
+		// remote clients can't read arbitrary files from the host
+		if env.Remote && !utils.PathInDataOrRootDir(env.Cfg, pl, env.Args) {
+			return tokens.NewLaunchError(
+				tokens.ErrCodeInvalidArgs,
+				tokens.StageResolve,
+				"remote playlist file not allowed: %s",
+				env.Args,
+			)
+		}
+		pls, err = playlists.Load(env.Args)
+	} else {
+		// not a file, try a saved playlist with the same name
+		pls, err = env.Playlist.Store.LoadPlaylist(env.Args)
 	}
-
-	if _, err := os.Stat(env.Args); err != nil {
+	if err != nil {
 		return err
 	}
 
-	files, err := os.ReadDir(env.Args)
+	opts, err := playlists.ParseOptions(env.NamedArgs, pls.Options)
 	if err != nil {
 		return err
 	}
+	pls.SetOptions(opts)
 
-	media := make([]string, 0)
-	for _, file := range files {
-		if file.IsDir() || filepath.Ext(file.Name()) == "" {
-			continue
-		}
+	log.Info().Any("items", pls.Items).Msgf("new playlist: %s", env.Args)
+	env.Playlist.Queue <- pls
+
+	return nil
+}
+
+func cmdPlaylistNext(_ platforms.Platform, env platforms.CmdEnv) error {
+	if env.Playlist.Active == nil {
+		return fmt.Errorf("no playlist active")
+	}
+
+	// nil at the end of a playlist without repeat, which stops it
+	env.Playlist.Queue <- playlists.Next(*env.Playlist.Active)
 
-		media = append(media, filepath.Join(env.Args, file.Name()))
+	return nil
+}
+
+func cmdPlaylistGoto(_ platforms.Platform, env platforms.CmdEnv) error {
+	if env.Playlist.Active == nil {
+		return fmt.Errorf("no playlist active")
+	}
+
+	idx, err := strconv.Atoi(env.Args)
+	if err != nil {
+		return fmt.Errorf("invalid playlist index: %s", env.Args)
 	}
 
-	if len(media) == 0 {
-		return fmt.Errorf("no media found in: %s", env.Args)
+	pls, err := playlists.Goto(*env.Playlist.Active, idx)
+	if err != nil {
+		return err
 	}
 
-	log.Info().Any("media", media).Msgf("new playlist: %s", env.Args)
-	pls := playlists.NewPlaylist(media)
 	env.Playlist.Queue <- pls
 
 	return nil
 }
 
-func cmdPlaylistNext(_ platforms.Platform, env platforms.CmdEnv) error {
+func cmdPlaylistStop(_ platforms.Platform, env platforms.CmdEnv) error {
 	if env.Playlist.Active == nil {
 		return fmt.Errorf("no playlist active")
 	}
 
-	env.Playlist.Queue <- playlists.Next(*env.Playlist.Active)
+	env.Playlist.Queue <- nil
 
 	return nil
 }
@@ -0,0 +1,211 @@
+package zapscript
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/playlists"
+	"github.com/ZaparooProject/zaparoo-core/pkg/service/tokens"
+)
+
+// stubHookCmd replaces the hook command runner for the test, recording the
+// commands run and failing any equal to fail.
+func stubHookCmd(t *testing.T, fail string) *[]string {
+	var ran []string
+
+	prev := runHookCmd
+	runHookCmd = func(
+		_ platforms.Platform,
+		_ *config.Instance,
+		_ playlists.PlaylistController,
+		t tokens.Token,
+		_ bool,
+		text string,
+		_ int,
+		_ int,
+	) (error, bool) {
+		if t.Source != tokens.SourceHook {
+			return errors.New("hook run with wrong source"), false
+		}
+
+		ran = append(ran, text)
+		if text == fail {
+			return errors.New("hook failed"), false
+		}
+		return nil, false
+	}
+	t.Cleanup(func() {
+		runHookCmd = prev
+	})
+
+	return &ran
+}
+
+func hookEnv(t *testing.T, preLaunch string) platforms.CmdEnv {
+	defaults := config.BaseDefaults
+	defaults.Launchers.Default = []config.LaunchersDefault{{
+		Launcher: "Test",
+		LaunchOptions: config.LaunchOptions{
+			PreLaunch: preLaunch,
+		},
+	}}
+
+	cfg, err := config.NewConfig(t.TempDir(), defaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return platforms.CmdEnv{Cfg: cfg}
+}
+
+func TestRunHook(t *testing.T) {
+	ran := stubHookCmd(t, "**fail:1")
+	env := hookEnv(t, "")
+
+	err := runHook(nil, env, "**first:1||**second:2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = runHook(nil, env, "**fail:1||**after:1")
+	if err == nil {
+		t.Error("expected hook error")
+	}
+
+	want := []string{"**first:1", "**second:2", "**fail:1"}
+	if !reflect.DeepEqual(*ran, want) {
+		t.Errorf("got commands %q, want %q", *ran, want)
+	}
+}
+
+func TestWithPreLaunch(t *testing.T) {
+	launcher := platforms.Launcher{Id: "Test"}
+
+	tests := []struct {
+		name      string
+		preLaunch string
+		hook      bool
+		wantHooks []string
+		launched  bool
+	}{
+		{
+			name:      "hook runs before launch",
+			preLaunch: "**input.keyboard:a",
+			wantHooks: []string{"**input.keyboard:a"},
+			launched:  true,
+		},
+		{
+			name:      "failed hook cancels launch",
+			preLaunch: "**fail:1",
+			wantHooks: []string{"**fail:1"},
+			launched:  false,
+		},
+		{
+			name:      "no hooks from hooks",
+			preLaunch: "**input.keyboard:a",
+			hook:      true,
+			launched:  true,
+		},
+		{
+			name:     "no hook set",
+			launched: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := stubHookCmd(t, "**fail:1")
+			env := hookEnv(t, tt.preLaunch)
+			env.Hook = tt.hook
+
+			launched := false
+			launch := withPreLaunch(nil, env, &launcher, func(string) error {
+				launched = true
+				return nil
+			})
+
+			err := launch("/games/test.bin")
+			if launched != tt.launched {
+				t.Errorf("launched: got %v, want %v", launched, tt.launched)
+			}
+
+			var le *tokens.LaunchError
+			if !tt.launched && (!errors.As(err, &le) || le.Code != tokens.ErrCodeHookFailed) {
+				t.Errorf("expected hook failed error, got: %v", err)
+			} else if tt.launched && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(*ran, tt.wantHooks) {
+				t.Errorf("got hooks %q, want %q", *ran, tt.wantHooks)
+			}
+		})
+	}
+}
+
+type dirsPlatform struct {
+	platforms.Platform
+	dataDir string
+}
+
+func (p dirsPlatform) DataDir() string {
+	return p.dataDir
+}
+
+func (p dirsPlatform) RootDirs(_ *config.Instance) []string {
+	return nil
+}
+
+func TestPlaylistPlayRemote(t *testing.T) {
+	pl := dirsPlatform{dataDir: t.TempDir()}
+
+	write := func(dir string) string {
+		path := filepath.Join(dir, "games.txt")
+		err := os.WriteFile(path, []byte("**launch.random:snes\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	inData := write(pl.dataDir)
+	outside := write(t.TempDir())
+
+	tests := []struct {
+		name   string
+		path   string
+		remote bool
+		queued bool
+	}{
+		{"local outside", outside, false, true},
+		{"remote outside", outside, true, false},
+		{"remote data dir", inData, true, true},
+		{"remote dir listing", filepath.Dir(outside), true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := make(chan *playlists.Playlist, 1)
+			env := platforms.CmdEnv{
+				Args:     tt.path,
+				Playlist: playlists.PlaylistController{Queue: q},
+				Remote:   tt.remote,
+			}
+
+			err := cmdPlaylistPlay(pl, env)
+			if tt.queued && err != nil {
+				t.Fatal(err)
+			} else if !tt.queued && err == nil {
+				t.Fatal("expected error")
+			}
+
+			if got := len(q) == 1; got != tt.queued {
+				t.Errorf("queued = %v, want %v", got, tt.queued)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package zapscript
+
+import (
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+)
+
+// cmdProfileSwitch makes the named profile active and saves it to the
+// config file. With no name, only the config file's values are used. Tokens
+// can't leave an active profile or enter one which allows more to be run.
+func cmdProfileSwitch(_ platforms.Platform, env platforms.CmdEnv) error {
+	name := strings.TrimSpace(env.Args)
+	log.Info().Msgf("switching profile to: %s", name)
+
+	err := env.Cfg.CheckProfileSwitch(name)
+	if err != nil {
+		return err
+	}
+
+	err = env.Cfg.SetProfile(name)
+	if err != nil {
+		return err
+	}
+
+	return env.Cfg.Save()
+}
@@ -0,0 +1,47 @@
+package zapscript
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/config"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+)
+
+func TestProfileSwitch(t *testing.T) {
+	cfg, err := config.NewConfig(t.TempDir(), config.BaseDefaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.MkdirAll(cfg.ProfilesPath(), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(
+		filepath.Join(cfg.ProfilesPath(), "kids.toml"),
+		[]byte("[readers.scan]\nignore_system = [\"PC\"]\n"),
+		0644,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = cmdProfileSwitch(nil, platforms.CmdEnv{Cfg: cfg, Args: "kids"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Profile() != "kids" {
+		t.Fatalf("profile not switched: %q", cfg.Profile())
+	}
+
+	err = cmdProfileSwitch(nil, platforms.CmdEnv{Cfg: cfg, Args: ""})
+	if !errors.Is(err, config.ErrProfileRestricted) {
+		t.Errorf("expected restricted error, got: %v", err)
+	}
+	if cfg.Profile() != "kids" {
+		t.Errorf("token left active profile: %q", cfg.Profile())
+	}
+}
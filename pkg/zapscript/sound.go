@@ -0,0 +1,28 @@
+package zapscript
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/ZaparooProject/zaparoo-core/pkg/audio"
+	"github.com/ZaparooProject/zaparoo-core/pkg/platforms"
+)
+
+// cmdSoundPlay plays the sound of an event, such as "launch_success", or a
+// WAV or Ogg file. Relative paths are in the sounds folder of the data
+// directory.
+func cmdSoundPlay(pl platforms.Platform, env platforms.CmdEnv) error {
+	arg := strings.TrimSpace(env.Args)
+	if arg == "" {
+		return errors.New("no sound specified")
+	}
+
+	log.Info().Msgf("playing sound: %s", arg)
+	if audio.IsEvent(arg) {
+		return audio.PlayEvent(pl, env.Cfg, arg)
+	}
+
+	return audio.PlayFile(pl, env.Cfg, arg)
+}